@@ -0,0 +1,200 @@
+package contracts
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+
+	"ricetypes"
+)
+
+type (
+	AgronomicEvent       = ricetypes.AgronomicEvent
+	PreHarvestCheck      = ricetypes.PreHarvestCheck
+	PreHarvestCheckEntry = ricetypes.PreHarvestCheckEntry
+)
+
+// Helper: validate and store a new event against a field's current season (only by farmer)
+func (c *RiceContract) recordAgronomicEvent(ctx contractapi.TransactionContextInterface, event *AgronomicEvent) (string, error) {
+	clientOrgID, _ := ctx.GetClientIdentity().GetMSPID()
+	if clientOrgID != "Org1MSP" {
+		return "", forbiddenError("Only Org1MSP (Farmer) can record agronomic events")
+	}
+
+	existing, err := ctx.GetStub().GetState(event.EventID)
+	if err != nil {
+		return "", fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if existing != nil {
+		return "", conflictError("the agronomic event %s already exists", event.EventID)
+	}
+	field, err := c.ReadField(ctx, event.FieldID)
+	if err != nil {
+		return "", err
+	}
+	if _, err := time.Parse("2006-01-02", event.Date); err != nil {
+		return "", invalidArgumentError("date must be YYYY-MM-DD: %v", err)
+	}
+
+	event.AssetType = "agronomicEvent"
+	event.CropSeason = field.CropSeason
+	event.RecordedBy = clientOrgID
+	event.RecordedAt = txTimestamp(ctx)
+	bytes, _ := json.Marshal(event)
+	return fmt.Sprintf("%v recorded for field %v in season %v", event.EventType, event.FieldID, event.CropSeason), ctx.GetStub().PutState(event.EventID, bytes)
+}
+
+// RecordSowing logs the sowing of a field with a seed lot
+func (c *RiceContract) RecordSowing(ctx contractapi.TransactionContextInterface, eventID string, fieldID string, date string, seedLot string, variety string) (string, error) {
+	if seedLot == "" {
+		return "", invalidArgumentError("seedLot is required")
+	}
+	return c.recordAgronomicEvent(ctx, &AgronomicEvent{
+		EventID:   eventID,
+		FieldID:   fieldID,
+		EventType: "sowing",
+		Date:      date,
+		SeedLot:   seedLot,
+		Variety:   variety,
+	})
+}
+
+// RecordFertiliserApplication logs a fertiliser application with its product and dose
+func (c *RiceContract) RecordFertiliserApplication(ctx contractapi.TransactionContextInterface, eventID string, fieldID string, date string, product string, dose string, doseUnit string) (string, error) {
+	if product == "" || dose == "" {
+		return "", invalidArgumentError("product and dose are required")
+	}
+	return c.recordAgronomicEvent(ctx, &AgronomicEvent{
+		EventID:   eventID,
+		FieldID:   fieldID,
+		EventType: "fertiliser",
+		Date:      date,
+		Product:   product,
+		Dose:      dose,
+		DoseUnit:  doseUnit,
+	})
+}
+
+// RecordPesticideApplication logs a pesticide application with its dose and pre-harvest interval
+func (c *RiceContract) RecordPesticideApplication(ctx contractapi.TransactionContextInterface, eventID string, fieldID string, date string, product string, activeIngredient string, dose string, doseUnit string, preHarvestIntervalDays int) (string, error) {
+	if product == "" || dose == "" {
+		return "", invalidArgumentError("product and dose are required")
+	}
+	if preHarvestIntervalDays < 0 {
+		return "", invalidArgumentError("preHarvestIntervalDays cannot be negative")
+	}
+	return c.recordAgronomicEvent(ctx, &AgronomicEvent{
+		EventID:                eventID,
+		FieldID:                fieldID,
+		EventType:              "pesticide",
+		Date:                   date,
+		Product:                product,
+		ActiveIngredient:       activeIngredient,
+		Dose:                   dose,
+		DoseUnit:               doseUnit,
+		PreHarvestIntervalDays: preHarvestIntervalDays,
+	})
+}
+
+// RecordIrrigation logs an irrigation event as depth of water applied
+func (c *RiceContract) RecordIrrigation(ctx contractapi.TransactionContextInterface, eventID string, fieldID string, date string, irrigationMm int) (string, error) {
+	if irrigationMm <= 0 {
+		return "", invalidArgumentError("irrigationMm must be positive")
+	}
+	return c.recordAgronomicEvent(ctx, &AgronomicEvent{
+		EventID:      eventID,
+		FieldID:      fieldID,
+		EventType:    "irrigation",
+		Date:         date,
+		IrrigationMm: irrigationMm,
+	})
+}
+
+// GetFieldLog returns a field's agronomic events for a crop season, oldest first
+func (c *RiceContract) GetFieldLog(ctx contractapi.TransactionContextInterface, fieldID string, cropSeason string) ([]*AgronomicEvent, error) {
+	queryString := richQuery(map[string]interface{}{"assetType": "agronomicEvent", "fieldID": fieldID, "cropSeason": cropSeason}, map[string]string{"date": "asc"})
+	resultsIterator, err := ctx.GetStub().GetQueryResult(queryString)
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	var events []*AgronomicEvent
+	for resultsIterator.HasNext() {
+		queryResult, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		var event AgronomicEvent
+		err = json.Unmarshal(queryResult.Value, &event)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, &event)
+	}
+	return events, nil
+}
+
+// GetBatchAgronomicLog returns the agronomic events of the field and season a batch was harvested from
+func (c *RiceContract) GetBatchAgronomicLog(ctx contractapi.TransactionContextInterface, batchID string) ([]*AgronomicEvent, error) {
+	batch, err := c.ReadRiceBatch(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+	if batch.FieldID == "" {
+		return nil, conflictError("batch %v is not linked to a field", batchID)
+	}
+	return c.GetFieldLog(ctx, batch.FieldID, batch.CropSeason)
+}
+
+// CheckPreHarvestIntervals verifies that no pesticide was applied inside its pre-harvest interval
+func (c *RiceContract) CheckPreHarvestIntervals(ctx contractapi.TransactionContextInterface, batchID string) (*PreHarvestCheck, error) {
+	batch, err := c.ReadRiceBatch(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+	harvest, err := time.Parse("2006-01-02", batch.HarvestDate)
+	if err != nil {
+		return nil, fmt.Errorf("batch %v has no valid harvest date: %v", batchID, err)
+	}
+	events, err := c.GetBatchAgronomicLog(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+
+	check := &PreHarvestCheck{
+		BatchID:      batchID,
+		FieldID:      batch.FieldID,
+		CropSeason:   batch.CropSeason,
+		HarvestDate:  batch.HarvestDate,
+		Compliant:    true,
+		Applications: []*PreHarvestCheckEntry{},
+	}
+	for _, event := range events {
+		if event.EventType != "pesticide" {
+			continue
+		}
+		applied, err := time.Parse("2006-01-02", event.Date)
+		if err != nil || applied.After(harvest) {
+			continue
+		}
+		earliest := applied.AddDate(0, 0, event.PreHarvestIntervalDays)
+		entry := &PreHarvestCheckEntry{
+			EventID:                event.EventID,
+			Product:                event.Product,
+			ActiveIngredient:       event.ActiveIngredient,
+			AppliedOn:              event.Date,
+			PreHarvestIntervalDays: event.PreHarvestIntervalDays,
+			EarliestHarvest:        earliest.Format("2006-01-02"),
+			DaysBeforeHarvest:      int(harvest.Sub(applied).Hours() / 24),
+			Compliant:              !harvest.Before(earliest),
+		}
+		if !entry.Compliant {
+			check.Compliant = false
+		}
+		check.Applications = append(check.Applications, entry)
+	}
+	return check, nil
+}
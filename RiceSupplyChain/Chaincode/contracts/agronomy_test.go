@@ -0,0 +1,75 @@
+package contracts
+
+import (
+	"testing"
+
+	"ricetypes"
+)
+
+func TestRecordAgronomicEvents(t *testing.T) {
+	h := newContractHarness(t)
+	h.harvest("B1", 100)
+
+	h.reject("Org2MSP", "RecordSowing", []string{"E1", "FIELD1", "2024-06-15", "LOT-7", "Basmati"}, nil, ricetypes.ErrCodeForbidden)
+	h.reject("Org1MSP", "RecordSowing", []string{"E1", "FIELD9", "2024-06-15", "LOT-7", "Basmati"}, nil, ricetypes.ErrCodeNotFound)
+	h.reject("Org1MSP", "RecordSowing", []string{"E1", "FIELD1", "15/06/2024", "LOT-7", "Basmati"}, nil, ricetypes.ErrCodeInvalidArgument)
+	h.reject("Org1MSP", "RecordSowing", []string{"E1", "FIELD1", "2024-06-15", "", "Basmati"}, nil, ricetypes.ErrCodeInvalidArgument)
+	h.reject("Org1MSP", "RecordFertiliserApplication", []string{"E2", "FIELD1", "2024-07-01", "Urea", "", "kg/ha"}, nil, ricetypes.ErrCodeInvalidArgument)
+	h.reject("Org1MSP", "RecordPesticideApplication", []string{"E3", "FIELD1", "2024-10-01", "Tilt", "propiconazole", "500", "ml/ha", "-1"}, nil, ricetypes.ErrCodeInvalidArgument)
+	h.reject("Org1MSP", "RecordIrrigation", []string{"E4", "FIELD1", "2024-08-01", "0"}, nil, ricetypes.ErrCodeInvalidArgument)
+
+	h.invoke("Org1MSP", "RecordSowing", []string{"E1", "FIELD1", "2024-06-15", "LOT-7", "Basmati"}, nil)
+	h.reject("Org1MSP", "RecordSowing", []string{"E1", "FIELD1", "2024-06-15", "LOT-7", "Basmati"}, nil, ricetypes.ErrCodeConflict)
+	h.invoke("Org1MSP", "RecordFertiliserApplication", []string{"E2", "FIELD1", "2024-07-01", "Urea", "100", "kg/ha"}, nil)
+	h.invoke("Org1MSP", "RecordIrrigation", []string{"E3", "FIELD1", "2024-08-01", "50"}, nil)
+
+	h.invoke("Org1MSP", "StartCropSeason", []string{"FIELD1", "2025-rabi"}, nil)
+	h.invoke("Org1MSP", "RecordSowing", []string{"E4", "FIELD1", "2025-01-05", "LOT-8", "Basmati"}, nil)
+
+	var log []*AgronomicEvent
+	h.read("Org3MSP", "GetBatchAgronomicLog", []string{"B1"}, &log)
+	if len(log) != 3 || log[0].EventType != "sowing" || log[2].IrrigationMm != 50 || log[2].CropSeason != "2024-kharif" {
+		t.Errorf("agronomic log of B1 %+v", log)
+	}
+	h.read("Org3MSP", "GetFieldLog", []string{"FIELD1", "2025-rabi"}, &log)
+	if len(log) != 1 || log[0].EventID != "E4" {
+		t.Errorf("2025-rabi log of FIELD1 %+v", log)
+	}
+}
+
+func TestCheckPreHarvestIntervals(t *testing.T) {
+	h := newContractHarness(t)
+	h.harvest("B1", 100)
+
+	var check PreHarvestCheck
+	h.read("Org3MSP", "CheckPreHarvestIntervals", []string{"B1"}, &check)
+	if !check.Compliant || len(check.Applications) != 0 {
+		t.Errorf("check without pesticides %+v", check)
+	}
+
+	// B1 was harvested on 2024-10-20
+	h.invoke("Org1MSP", "RecordPesticideApplication", []string{"E1", "FIELD1", "2024-10-01", "Tilt", "propiconazole", "500", "ml/ha", "14"}, nil)
+	h.invoke("Org1MSP", "RecordPesticideApplication", []string{"E2", "FIELD1", "2024-10-10", "Nativo", "tebuconazole", "400", "g/ha", "21"}, nil)
+	h.invoke("Org1MSP", "RecordPesticideApplication", []string{"E3", "FIELD1", "2024-10-25", "Tilt", "propiconazole", "500", "ml/ha", "14"}, nil)
+	h.invoke("Org1MSP", "RecordIrrigation", []string{"E4", "FIELD1", "2024-10-12", "30"}, nil)
+
+	h.read("Org3MSP", "CheckPreHarvestIntervals", []string{"B1"}, &check)
+	if check.Compliant || len(check.Applications) != 2 {
+		t.Fatalf("check with a late application %+v", check)
+	}
+	for _, entry := range check.Applications {
+		switch entry.EventID {
+		case "E1":
+			if !entry.Compliant || entry.EarliestHarvest != "2024-10-15" || entry.DaysBeforeHarvest != 19 {
+				t.Errorf("E1 %+v", entry)
+			}
+		case "E2":
+			if entry.Compliant || entry.EarliestHarvest != "2024-10-31" || entry.DaysBeforeHarvest != 10 {
+				t.Errorf("E2 %+v", entry)
+			}
+		default:
+			t.Errorf("unexpected application %+v", entry)
+		}
+	}
+	h.reject("Org3MSP", "CheckPreHarvestIntervals", []string{"B9"}, nil, ricetypes.ErrCodeNotFound)
+}
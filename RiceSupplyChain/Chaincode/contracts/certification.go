@@ -0,0 +1,333 @@
+package contracts
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+
+	"ricetypes"
+)
+
+type Certification = ricetypes.Certification
+
+// Certificates are matched to batches through composite keys rather than
+// rich queries, so the lookups are re-checked at commit and a batch created
+// while a certificate is issued cannot be missed:
+//
+//	holderBatch~holder~harvestDate~batchID   batches a farmer harvested
+//	holderCertification~holder~certID        certificates a farmer holds
+//	certifiedBatch~certID~batchID            batches carrying a certificate
+const holderBatchPrefix = "holderBatch"
+const holderCertificationPrefix = "holderCertification"
+const certifiedBatchPrefix = "certifiedBatch"
+
+// certifierMSP is the org whose CA enrolls certifiers. It is not the farmer
+// org, so producers cannot certify their own batches by enrolling an
+// identity with role=certifier.
+const certifierMSP = "Org3MSP"
+
+// Helper: certifiers are certifierMSP identities enrolled with the attribute role=certifier
+func assertCertifier(ctx contractapi.TransactionContextInterface) (string, error) {
+	clientOrgID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return "", err
+	}
+	if clientOrgID != certifierMSP || ctx.GetClientIdentity().AssertAttributeValue("role", "certifier") != nil {
+		return "", forbiddenError("only %v identities with role=certifier can manage certifications", certifierMSP)
+	}
+	return clientOrgID, nil
+}
+
+// IssueCertification records a certificate and marks the holder's batches harvested during its validity
+func (c *RiceContract) IssueCertification(ctx contractapi.TransactionContextInterface, certificationID string, scheme string, certificateNumber string, holder string, scope string, validFrom string, validUntil string, documentHash string) (string, error) {
+	clientOrgID, err := assertCertifier(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	existing, err := ctx.GetStub().GetState(certificationID)
+	if err != nil {
+		return "", fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if existing != nil {
+		return "", conflictError("the certification %s already exists", certificationID)
+	}
+
+	from, err := time.Parse("2006-01-02", validFrom)
+	if err != nil {
+		return "", invalidArgumentError("validFrom must be YYYY-MM-DD: %v", err)
+	}
+	until, err := time.Parse("2006-01-02", validUntil)
+	if err != nil {
+		return "", invalidArgumentError("validUntil must be YYYY-MM-DD: %v", err)
+	}
+	if until.Before(from) {
+		return "", invalidArgumentError("validUntil cannot be before validFrom")
+	}
+	if hash, err := hex.DecodeString(documentHash); err != nil || len(hash) != 32 {
+		return "", invalidArgumentError("documentHash must be a hex encoded SHA-256 hash")
+	}
+	if scheme == "" || holder == "" {
+		return "", invalidArgumentError("scheme and holder are required")
+	}
+
+	certification := Certification{
+		AssetType:         "certification",
+		CertificationID:   certificationID,
+		Scheme:            scheme,
+		CertificateNumber: certificateNumber,
+		Holder:            holder,
+		Scope:             scope,
+		ValidFrom:         validFrom,
+		ValidUntil:        validUntil,
+		DocumentHash:      documentHash,
+		IssuedBy:          clientOrgID,
+		Status:            "Active",
+	}
+	bytes, _ := json.Marshal(certification)
+	err = ctx.GetStub().PutState(certificationID, bytes)
+	if err != nil {
+		return "", err
+	}
+	err = putIndexKey(ctx, holderCertificationPrefix, holder, certificationID)
+	if err != nil {
+		return "", err
+	}
+
+	batches, err := c.batchesHarvestedBy(ctx, holder, validFrom, validUntil)
+	if err != nil {
+		return "", err
+	}
+	for _, batch := range batches {
+		batch.Certifications = append(batch.Certifications, certificationID)
+		bytes, _ := json.Marshal(batch)
+		if err := ctx.GetStub().PutState(batch.BatchID, bytes); err != nil {
+			return "", err
+		}
+		if err := putIndexKey(ctx, certifiedBatchPrefix, certificationID, batch.BatchID); err != nil {
+			return "", err
+		}
+	}
+
+	return fmt.Sprintf("certification %v issued, %d batches certified", certificationID, len(batches)), nil
+}
+
+// ReadCertification retrieves a certification from world state
+func (c *RiceContract) ReadCertification(ctx contractapi.TransactionContextInterface, certificationID string) (*Certification, error) {
+	bytes, err := ctx.GetStub().GetState(certificationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if bytes == nil {
+		return nil, notFoundError("the certification %s does not exist", certificationID)
+	}
+
+	var certification Certification
+	err = json.Unmarshal(bytes, &certification)
+	if err != nil {
+		return nil, fmt.Errorf("could not unmarshal world state data to type Certification")
+	}
+	if certification.AssetType != "certification" {
+		return nil, notFoundError("the certification %s does not exist", certificationID)
+	}
+	return &certification, nil
+}
+
+// RevokeCertification withdraws a certificate and flags every batch that relied on it
+func (c *RiceContract) RevokeCertification(ctx contractapi.TransactionContextInterface, certificationID string, reason string) (string, error) {
+	clientOrgID, err := assertCertifier(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	certification, err := c.ReadCertification(ctx, certificationID)
+	if err != nil {
+		return "", err
+	}
+	if certification.IssuedBy != clientOrgID {
+		return "", forbiddenError("only certifiers of %v can revoke certification %v", certification.IssuedBy, certificationID)
+	}
+	if certification.Status == "Revoked" {
+		return "", conflictError("certification %v is already revoked", certificationID)
+	}
+
+	certification.Status = "Revoked"
+	certification.RevokedAt = txTimestamp(ctx)
+	certification.RevocationReason = reason
+	bytes, _ := json.Marshal(certification)
+	err = ctx.GetStub().PutState(certificationID, bytes)
+	if err != nil {
+		return "", err
+	}
+
+	batches, err := c.certifiedBatches(ctx, certificationID)
+	if err != nil {
+		return "", err
+	}
+	for _, batch := range batches {
+		var remaining []string
+		for _, id := range batch.Certifications {
+			if id != certificationID {
+				remaining = append(remaining, id)
+			}
+		}
+		batch.Certifications = remaining
+		batch.Flags = append(batch.Flags, &BatchFlag{
+			RuleID:    "certification-revoked:" + certificationID,
+			Reason:    fmt.Sprintf("%v certificate %v revoked: %v", certification.Scheme, certification.CertificateNumber, reason),
+			FlaggedAt: certification.RevokedAt,
+			FlaggedBy: clientOrgID,
+		})
+		bytes, _ := json.Marshal(batch)
+		if err := ctx.GetStub().PutState(batch.BatchID, bytes); err != nil {
+			return "", err
+		}
+	}
+
+	return fmt.Sprintf("certification %v revoked, %d batches flagged", certificationID, len(batches)), nil
+}
+
+// GetCertificationsByHolder returns all certifications issued to a producer
+func (c *RiceContract) GetCertificationsByHolder(ctx contractapi.TransactionContextInterface, holder string) ([]*Certification, error) {
+	return c.certifications(ctx, richQuery(map[string]interface{}{"assetType": "certification", "holder": holder}))
+}
+
+// activeCertificationIDs lists the holder's active certifications valid on date (YYYY-MM-DD)
+func (c *RiceContract) activeCertificationIDs(ctx contractapi.TransactionContextInterface, holder string, date string) ([]string, error) {
+	entries, err := indexEntries(ctx, holderCertificationPrefix, holder)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, attributes := range entries {
+		certification, err := c.ReadCertification(ctx, attributes[0])
+		if err != nil {
+			return nil, err
+		}
+		// Dates are YYYY-MM-DD so string comparison orders them correctly
+		if certification.Status == "Active" && certification.ValidFrom <= date && date <= certification.ValidUntil {
+			ids = append(ids, certification.CertificationID)
+		}
+	}
+	return ids, nil
+}
+
+// indexBatchCertifications records a new batch under its farmer and its certificates
+func indexBatchCertifications(ctx contractapi.TransactionContextInterface, batch *RiceBatch) error {
+	err := putIndexKey(ctx, holderBatchPrefix, batch.FarmerName, batch.HarvestDate, batch.BatchID)
+	if err != nil {
+		return err
+	}
+	for _, certificationID := range batch.Certifications {
+		if err := putIndexKey(ctx, certifiedBatchPrefix, certificationID, batch.BatchID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// batchesHarvestedBy returns the holder's batches harvested between from and until (YYYY-MM-DD)
+func (c *RiceContract) batchesHarvestedBy(ctx contractapi.TransactionContextInterface, holder string, from string, until string) ([]*RiceBatch, error) {
+	keys, err := indexEntries(ctx, holderBatchPrefix, holder)
+	if err != nil {
+		return nil, err
+	}
+	var batchIDs []string
+	for _, attributes := range keys {
+		if harvestDate := attributes[0]; from <= harvestDate && harvestDate <= until {
+			batchIDs = append(batchIDs, attributes[1])
+		}
+	}
+	return c.indexedBatches(ctx, batchIDs)
+}
+
+// certifiedBatches returns the batches that carry a certificate
+func (c *RiceContract) certifiedBatches(ctx contractapi.TransactionContextInterface, certificationID string) ([]*RiceBatch, error) {
+	keys, err := indexEntries(ctx, certifiedBatchPrefix, certificationID)
+	if err != nil {
+		return nil, err
+	}
+	var batchIDs []string
+	for _, attributes := range keys {
+		batchIDs = append(batchIDs, attributes[0])
+	}
+	return c.indexedBatches(ctx, batchIDs)
+}
+
+// Helper: read indexed batches, skipping any deleted since they were indexed
+func (c *RiceContract) indexedBatches(ctx contractapi.TransactionContextInterface, batchIDs []string) ([]*RiceBatch, error) {
+	var batches []*RiceBatch
+	for _, batchID := range batchIDs {
+		exists, err := c.RiceBatchExists(ctx, batchID)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			continue
+		}
+		batch, err := c.ReadRiceBatch(ctx, batchID)
+		if err != nil {
+			return nil, err
+		}
+		batches = append(batches, batch)
+	}
+	return batches, nil
+}
+
+// Helper: write an index entry; Fabric treats an empty value as a delete
+func putIndexKey(ctx contractapi.TransactionContextInterface, prefix string, attributes ...string) error {
+	key, err := ctx.GetStub().CreateCompositeKey(prefix, attributes)
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(key, []byte{0x00})
+}
+
+// Helper: the attributes after first of every index entry under prefix~first
+func indexEntries(ctx contractapi.TransactionContextInterface, prefix string, first string) ([][]string, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(prefix, []string{first})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	var entries [][]string
+	for resultsIterator.HasNext() {
+		queryResult, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		_, attributes, err := ctx.GetStub().SplitCompositeKey(queryResult.Key)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, attributes[1:])
+	}
+	return entries, nil
+}
+
+func (c *RiceContract) certifications(ctx contractapi.TransactionContextInterface, queryString string) ([]*Certification, error) {
+	resultsIterator, err := ctx.GetStub().GetQueryResult(queryString)
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	var certifications []*Certification
+	for resultsIterator.HasNext() {
+		queryResult, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		var certification Certification
+		err = json.Unmarshal(queryResult.Value, &certification)
+		if err != nil {
+			return nil, err
+		}
+		certifications = append(certifications, &certification)
+	}
+	return certifications, nil
+}
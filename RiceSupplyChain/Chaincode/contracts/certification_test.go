@@ -0,0 +1,65 @@
+package contracts
+
+import (
+	"strings"
+	"testing"
+
+	"ricetypes"
+)
+
+func certificationArgs(certificationID string, validFrom string, validUntil string) []string {
+	return []string{certificationID, "Organic", "ORG-" + certificationID, "Ravi", "Basmati paddy", validFrom, validUntil, strings.Repeat("ab", 32)}
+}
+
+func TestCertificationNeedsTheCertifierOrg(t *testing.T) {
+	h := newContractHarness(t)
+	h.identity("farmer-certifier", "Org1MSP", map[string]string{"role": "certifier"})
+	h.identity("certifier", certifierMSP, map[string]string{"role": "certifier"})
+
+	args := certificationArgs("C1", "2024-01-01", "2024-12-31")
+	h.reject(certifierMSP, "IssueCertification", args, nil, ricetypes.ErrCodeForbidden)
+	h.reject("farmer-certifier", "IssueCertification", args, nil, ricetypes.ErrCodeForbidden)
+	h.invoke("certifier", "IssueCertification", args, nil)
+	h.reject("farmer-certifier", "RevokeCertification", []string{"C1", "self-revoked"}, nil, ricetypes.ErrCodeForbidden)
+}
+
+func TestCertificationPropagatesAndRevocationFlags(t *testing.T) {
+	h := newContractHarness(t)
+	h.identity("certifier", certifierMSP, map[string]string{"role": "certifier"})
+	h.harvest("B1", 100)
+
+	h.reject("certifier", "IssueCertification", certificationArgs("C1", "2024-12-31", "2024-01-01"), nil, ricetypes.ErrCodeInvalidArgument)
+	h.reject("certifier", "IssueCertification", certificationArgs("C1", "2024-01-01", "31/12/2024"), nil, ricetypes.ErrCodeInvalidArgument)
+	badHash := certificationArgs("C1", "2024-01-01", "2024-12-31")
+	badHash[7] = "abcd"
+	h.reject("certifier", "IssueCertification", badHash, nil, ricetypes.ErrCodeInvalidArgument)
+
+	h.invoke("certifier", "IssueCertification", certificationArgs("C1", "2024-01-01", "2024-12-31"), nil)
+	h.invoke("certifier", "IssueCertification", certificationArgs("C2", "2025-01-01", "2025-12-31"), nil)
+	h.reject("certifier", "IssueCertification", certificationArgs("C1", "2024-01-01", "2024-12-31"), nil, ricetypes.ErrCodeConflict)
+	h.harvest("B2", 100)
+	for _, batchID := range []string{"B1", "B2"} {
+		if certifications := h.batch(batchID).Certifications; len(certifications) != 1 || certifications[0] != "C1" {
+			t.Errorf("batch %v certifications %v, want [C1]", batchID, certifications)
+		}
+	}
+
+	var held []*Certification
+	h.read("Org1MSP", "GetCertificationsByHolder", []string{"Ravi"}, &held)
+	if len(held) != 2 {
+		t.Errorf("holder has %d certifications, want 2", len(held))
+	}
+
+	h.invoke("certifier", "RevokeCertification", []string{"C1", "audit failed"}, nil)
+	h.reject("certifier", "RevokeCertification", []string{"C1", "audit failed"}, nil, ricetypes.ErrCodeConflict)
+	for _, batchID := range []string{"B1", "B2"} {
+		batch := h.batch(batchID)
+		if len(batch.Certifications) != 0 || len(batch.Flags) != 1 || batch.Flags[0].RuleID != "certification-revoked:C1" {
+			t.Errorf("revoked batch %v: certifications %v, flags %+v", batchID, batch.Certifications, batch.Flags)
+		}
+	}
+	h.harvest("B3", 100)
+	if certifications := h.batch("B3").Certifications; len(certifications) != 0 {
+		t.Errorf("batch harvested under a revoked certificate carries %v", certifications)
+	}
+}
@@ -0,0 +1,52 @@
+package contracts
+
+import (
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+
+	"ricetypes"
+)
+
+// The frontend calls these functions by name, passes the arguments from the
+// ricetypes argument structs and decodes the results into ricetypes values.
+// Pinning their signatures here turns a change to either side into a build
+// failure instead of a transaction that fails, or decodes badly, at runtime.
+type ctxType = contractapi.TransactionContextInterface
+
+// Transactions submitted with ricetypes argument structs, in the order of their Args
+var (
+	// CreateRiceBatchArgs: batchID, variety, harvestDate, quantityInKg, farmerName, fieldID
+	_ func(*RiceContract, ctxType, string, string, string, int, string, string) (string, error) = (*RiceContract).CreateRiceBatch
+	// CreateProcessingOrderArgs: orderID
+	_ func(*RiceContract, ctxType, string) (string, error) = (*RiceContract).CreateProcessingOrder
+	// MatchProcessingOrderArgs: batchID, orderID
+	_ func(*RiceContract, ctxType, string, string) (string, error) = (*RiceContract).MatchProcessingOrder
+	// DispatchToRetailerArgs: batchID, retailerName
+	_ func(*RiceContract, ctxType, string, string) (string, error) = (*RiceContract).DispatchToRetailer
+)
+
+// Other transactions the frontend submits
+var (
+	_ func(*RiceContract, ctxType, string, string, string, string) (string, error)              = (*RiceContract).AttachDocument
+	_ func(*RiceContract, ctxType, string, string, string) (string, error)                      = (*RiceContract).FlagBatch
+	_ func(*RiceContract, ctxType, string, string, string, int, string, string) (string, error) = (*RiceContract).AnchorTelemetry
+)
+
+// Queries and the shared types the frontend decodes their results into
+var (
+	_ func(*RiceContract, ctxType, string) (*ricetypes.RiceBatch, error)            = (*RiceContract).ReadRiceBatch
+	_ func(*RiceContract, ctxType) ([]*ricetypes.RiceBatch, error)                  = (*RiceContract).GetAllRiceBatches
+	_ func(*RiceContract, ctxType, string, string) ([]*ricetypes.RiceBatch, error)  = (*RiceContract).GetRiceBatchByRange
+	_ func(*RiceContract, ctxType, string) ([]*ricetypes.HistoryQueryResult, error) = (*RiceContract).GetRiceBatchHistory
+	_ func(*RiceContract, ctxType, string) (*ricetypes.RetailUnitProvenance, error) = (*RiceContract).VerifyRetailUnit
+	_ func(*RiceContract, ctxType, string) (*ricetypes.Footprint, error)            = (*RiceContract).GetFootprint
+	_ func(*RiceContract, ctxType, string) ([]*ricetypes.DocumentAnchor, error)     = (*RiceContract).GetDocuments
+	_ func(*RiceContract, ctxType, string) ([]*ricetypes.DocumentAnchor, error)     = (*RiceContract).GetDocumentsByHash
+	_ func(*RiceContract, ctxType, string) (*ricetypes.PackingList, error)          = (*RiceContract).GetPackingList
+	_ func(*RiceContract, ctxType, string) ([]*ricetypes.Field, error)              = (*RiceContract).GetFields
+	_ func(*RiceContract, ctxType, string) (*ricetypes.Field, error)                = (*RiceContract).ReadField
+	_ func(*RiceContract, ctxType, string) (*ricetypes.Farm, error)                 = (*RiceContract).ReadFarm
+	_ func(*RiceContract, ctxType, string) (*ricetypes.Certification, error)        = (*RiceContract).ReadCertification
+	_ func(*RiceContract, ctxType, string) (*ricetypes.TelemetryAnchor, error)      = (*RiceContract).ReadTelemetryAnchor
+	_ func(*RiceContract, ctxType, string) ([]*ricetypes.ProcessingOrder, error)    = (*RiceContract).GetMatchingOrders
+	_ func(*RiceContract, ctxType, string) (*ricetypes.ProcessingOrder, error)      = (*RiceContract).ReadProcessingOrder
+)
@@ -0,0 +1,238 @@
+package contracts
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"ricetypes"
+)
+
+// compat.go pins the contract's Go signatures; these tests check what the
+// signatures cannot: that each ricetypes argument struct lands its values in
+// the right parameters and transient keys, that the shared types survive
+// JSON, and that the vendored ricetypes is the one in the repository.
+
+func TestArgsReachContractParameters(t *testing.T) {
+	h := newContractHarness(t)
+	h.invoke("Org1MSP", "RegisterFarm", []string{"FARM1", "Green Acres", "Ravi", "Punjab"}, nil)
+	boundary := `{"type":"Polygon","coordinates":[[[75.0,30.0],[75.01,30.0],[75.01,30.01],[75.0,30.01],[75.0,30.0]]]}`
+	h.invoke("Org1MSP", "RegisterField", []string{"FIELD1", "FARM1", "North", boundary, "10707", "2024-kharif"}, nil)
+
+	create := &ricetypes.CreateRiceBatchArgs{
+		BatchID:              "B1",
+		Variety:              "Basmati",
+		HarvestDate:          "2024-10-20",
+		QuantityInKg:         1000,
+		FarmerName:           "Ravi",
+		FieldID:              "FIELD1",
+		WaterUsageM3:         1200,
+		FertiliserNKg:        80,
+		FieldEmissionsMethod: "measured",
+		FieldEmissionsKgCO2e: 950,
+	}
+	h.submit("Org1MSP", create)
+	batch := h.batch("B1")
+	want := RiceBatch{
+		AssetType:    "riceBatch",
+		BatchID:      "B1",
+		Variety:      "Basmati",
+		HarvestDate:  "2024-10-20",
+		QuantityInKg: 1000,
+		ProducedBy:   "Ravi",
+		FarmerName:   "Ravi",
+		FieldID:      "FIELD1",
+		CropSeason:   "2024-kharif",
+		Status:       "Harvested",
+	}
+	if !reflect.DeepEqual(*batch, want) {
+		t.Errorf("CreateRiceBatch stored %+v, want %+v", *batch, want)
+	}
+	if event := h.event(ricetypes.EventBatchCreated); event.BatchID != "B1" || event.ActorMSP != "Org1MSP" {
+		t.Errorf("BatchCreated event %+v", event)
+	}
+
+	order := &ricetypes.CreateProcessingOrderArgs{OrderID: "O1", Variety: "Basmati", MillerName: "Mill Co", QuantityInKg: 600}
+	h.submit("Org2MSP", order)
+	var stored ProcessingOrder
+	if err := json.Unmarshal(h.stub.private[getCollectionName()]["O1"], &stored); err != nil {
+		t.Fatal(err)
+	}
+	if stored.OrderID != "O1" || stored.Variety != "Basmati" || stored.MillerName != "Mill Co" || stored.QuantityInKg != 600 {
+		t.Errorf("CreateProcessingOrder stored %+v", stored)
+	}
+
+	h.submit("Org2MSP", &ricetypes.MatchProcessingOrderArgs{BatchID: "B1", OrderID: "O1"})
+	if status := h.batch("B1").Status; status != "Assigned to Miller Mill Co" {
+		t.Errorf("MatchProcessingOrder left status %q", status)
+	}
+	if event := h.event(ricetypes.EventBatchMilled); event.BatchID != "B1" || event.Status != "Assigned to Miller Mill Co" {
+		t.Errorf("BatchMilled event %+v", event)
+	}
+
+	dispatch := &ricetypes.DispatchToRetailerArgs{
+		BatchID:      "B1",
+		RetailerName: "Fresh Mart",
+		SaleTerms:    ricetypes.SaleTerms{UnitPrice: 120, Currency: "INR", TaxRateBps: 500},
+	}
+	h.submit("Org3MSP", dispatch)
+	if status := h.batch("B1").Status; status != "Dispatched to Fresh Mart" {
+		t.Errorf("DispatchToRetailer left status %q", status)
+	}
+	var invoice ricetypes.Invoice
+	h.read("Org3MSP", "ReadInvoice", []string{"INV-B1-DISPATCH"}, &invoice)
+	if invoice.UnitPrice != 120 || invoice.Currency != "INR" || invoice.TaxRateBps != 500 {
+		t.Errorf("dispatch invoice priced %d %s at %d bps", invoice.UnitPrice, invoice.Currency, invoice.TaxRateBps)
+	}
+	if event := h.event(ricetypes.EventBatchDispatched); event.ActorMSP != "Org3MSP" {
+		t.Errorf("BatchDispatched event %+v", event)
+	}
+}
+
+func TestContractErrorsCarryCodes(t *testing.T) {
+	h := newContractHarness(t)
+	cases := []struct {
+		mspID    string
+		function string
+		args     []string
+		code     string
+	}{
+		{"Org1MSP", "ReadRiceBatch", []string{"missing"}, ricetypes.ErrCodeNotFound},
+		{"Org2MSP", "CreateRiceBatch", []string{"B1", "Basmati", "2024-10-20", "1000", "Ravi", "FIELD1"}, ricetypes.ErrCodeForbidden},
+		{"Org3MSP", "RegisterFarm", []string{"FARM1", "Green Acres", "Ravi", "Punjab"}, ricetypes.ErrCodeForbidden},
+	}
+	for _, tc := range cases {
+		h.stub.function, h.stub.args, h.stub.transient = tc.function, tc.args, nil
+		h.stub.creator = h.creators[tc.mspID]
+		response := h.chaincode.Invoke(h.stub)
+		if !strings.HasPrefix(response.Message, tc.code+": ") {
+			t.Errorf("%s as %s: message %q does not start with %s", tc.function, tc.mspID, response.Message, tc.code)
+		}
+	}
+
+	h.invoke("Org1MSP", "RegisterFarm", []string{"FARM1", "Green Acres", "Ravi", "Punjab"}, nil)
+	h.stub.function, h.stub.args = "RegisterFarm", []string{"FARM1", "Green Acres", "Ravi", "Punjab"}
+	if response := h.chaincode.Invoke(h.stub); !strings.HasPrefix(response.Message, ricetypes.ErrCodeConflict+": ") {
+		t.Errorf("duplicate RegisterFarm: message %q", response.Message)
+	}
+}
+
+// fill sets every exported field of v to a non-zero value
+func fill(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString("x")
+	case reflect.Bool:
+		v.SetBool(true)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(7)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(7)
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(1.5)
+	case reflect.Ptr:
+		v.Set(reflect.New(v.Type().Elem()))
+		fill(v.Elem())
+	case reflect.Slice:
+		v.Set(reflect.MakeSlice(v.Type(), 1, 1))
+		fill(v.Index(0))
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			fill(v.Index(i))
+		}
+	case reflect.Map:
+		v.Set(reflect.MakeMap(v.Type()))
+		key := reflect.New(v.Type().Key()).Elem()
+		fill(key)
+		value := reflect.New(v.Type().Elem()).Elem()
+		fill(value)
+		v.SetMapIndex(key, value)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				fill(v.Field(i))
+			}
+		}
+	}
+}
+
+func TestSharedTypesRoundTripJSON(t *testing.T) {
+	values := []interface{}{
+		&ricetypes.RiceBatch{},
+		&ricetypes.ProcessingOrder{},
+		&ricetypes.HistoryQueryResult{},
+		&ricetypes.RetailUnitProvenance{},
+		&ricetypes.Footprint{},
+		&ricetypes.DocumentAnchor{},
+		&ricetypes.PackingList{},
+		&ricetypes.Field{},
+		&ricetypes.Farm{},
+		&ricetypes.Certification{},
+		&ricetypes.TelemetryAnchor{},
+		&ricetypes.Invoice{},
+		&ricetypes.InvoiceStatus{},
+		&ricetypes.BatchEvent{},
+		&ricetypes.CreateRiceBatchArgs{},
+		&ricetypes.CreateProcessingOrderArgs{},
+		&ricetypes.MatchProcessingOrderArgs{},
+		&ricetypes.DispatchToRetailerArgs{},
+	}
+	for _, value := range values {
+		original := reflect.ValueOf(value)
+		fill(original.Elem())
+		data, err := json.Marshal(value)
+		if err != nil {
+			t.Fatalf("%T: %v", value, err)
+		}
+		decoded := reflect.New(original.Elem().Type())
+		if err := json.Unmarshal(data, decoded.Interface()); err != nil {
+			t.Fatalf("%T: %v", value, err)
+		}
+		if !reflect.DeepEqual(original.Interface(), decoded.Interface()) {
+			t.Errorf("%T does not survive a JSON round trip:\n%s", value, data)
+		}
+	}
+}
+
+func TestVendoredRiceTypesMatchesModule(t *testing.T) {
+	module := filepath.Join("..", "..", "RiceTypes")
+	vendored := filepath.Join("..", "vendor", "ricetypes")
+
+	sources := func(dir string) map[string][]byte {
+		matches, err := filepath.Glob(filepath.Join(dir, "*.go"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		files := make(map[string][]byte)
+		for _, path := range matches {
+			if strings.HasSuffix(path, "_test.go") {
+				continue
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			files[filepath.Base(path)] = data
+		}
+		return files
+	}
+
+	want, got := sources(module), sources(vendored)
+	if len(want) == 0 {
+		t.Fatalf("no sources found in %s", module)
+	}
+	for name, data := range want {
+		if !bytes.Equal(got[name], data) {
+			t.Errorf("vendor/ricetypes/%s differs from RiceTypes, run go mod vendor", name)
+		}
+	}
+	for name := range got {
+		if _, ok := want[name]; !ok {
+			t.Errorf("vendor/ricetypes/%s is not in RiceTypes, run go mod vendor", name)
+		}
+	}
+}
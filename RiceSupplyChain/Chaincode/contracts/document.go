@@ -0,0 +1,97 @@
+package contracts
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+
+	"ricetypes"
+)
+
+type DocumentAnchor = ricetypes.DocumentAnchor
+
+func documentKey(assetID string, sha256 string) string {
+	return fmt.Sprintf("DOC-%s-%s", sha256, assetID)
+}
+
+// AttachDocument anchors the hash of a document against an existing asset
+func (c *RiceContract) AttachDocument(ctx contractapi.TransactionContextInterface, assetID string, docType string, sha256 string, uri string) (string, error) {
+	clientOrgID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return "", err
+	}
+
+	sha256 = strings.ToLower(sha256)
+	if hash, err := hex.DecodeString(sha256); err != nil || len(hash) != 32 {
+		return "", invalidArgumentError("sha256 must be a hex encoded SHA-256 hash")
+	}
+	if docType == "" {
+		return "", invalidArgumentError("docType is required")
+	}
+
+	asset, err := ctx.GetStub().GetState(assetID)
+	if err != nil {
+		return "", fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if asset == nil {
+		return "", notFoundError("the asset %s does not exist", assetID)
+	}
+
+	documentID := documentKey(assetID, sha256)
+	existing, err := ctx.GetStub().GetState(documentID)
+	if err != nil {
+		return "", fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if existing != nil {
+		return "", conflictError("document %s is already attached to %s", sha256, assetID)
+	}
+
+	document := DocumentAnchor{
+		AssetType:  "document",
+		DocumentID: documentID,
+		AssetID:    assetID,
+		DocType:    docType,
+		SHA256:     sha256,
+		URI:        uri,
+		AttachedBy: clientOrgID,
+		AttachedAt: txTimestamp(ctx),
+	}
+	bytes, _ := json.Marshal(document)
+	return fmt.Sprintf("document %v attached to %v", sha256, assetID), ctx.GetStub().PutState(documentID, bytes)
+}
+
+// GetDocuments returns the documents attached to an asset
+func (c *RiceContract) GetDocuments(ctx contractapi.TransactionContextInterface, assetID string) ([]*DocumentAnchor, error) {
+	return c.documents(ctx, richQuery(map[string]interface{}{"assetType": "document", "assetID": assetID}))
+}
+
+// GetDocumentsByHash returns every anchor recorded for a document hash
+func (c *RiceContract) GetDocumentsByHash(ctx contractapi.TransactionContextInterface, sha256 string) ([]*DocumentAnchor, error) {
+	return c.documents(ctx, richQuery(map[string]interface{}{"assetType": "document", "sha256": strings.ToLower(sha256)}))
+}
+
+func (c *RiceContract) documents(ctx contractapi.TransactionContextInterface, queryString string) ([]*DocumentAnchor, error) {
+	resultsIterator, err := ctx.GetStub().GetQueryResult(queryString)
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	var documents []*DocumentAnchor
+	for resultsIterator.HasNext() {
+		queryResult, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		var document DocumentAnchor
+		err = json.Unmarshal(queryResult.Value, &document)
+		if err != nil {
+			return nil, err
+		}
+		documents = append(documents, &document)
+	}
+	return documents, nil
+}
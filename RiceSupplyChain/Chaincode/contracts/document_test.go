@@ -0,0 +1,33 @@
+package contracts
+
+import (
+	"strings"
+	"testing"
+
+	"ricetypes"
+)
+
+func TestAttachDocument(t *testing.T) {
+	h := newContractHarness(t)
+	h.harvest("B1", 100)
+	h.harvest("B2", 100)
+	hash := strings.Repeat("AB", 32)
+
+	h.reject("Org1MSP", "AttachDocument", []string{"B1", "lab-report", "abcd", ""}, nil, ricetypes.ErrCodeInvalidArgument)
+	h.reject("Org1MSP", "AttachDocument", []string{"B1", "", hash, ""}, nil, ricetypes.ErrCodeInvalidArgument)
+	h.reject("Org1MSP", "AttachDocument", []string{"B9", "lab-report", hash, ""}, nil, ricetypes.ErrCodeNotFound)
+
+	h.invoke("Org1MSP", "AttachDocument", []string{"B1", "lab-report", hash, "https://docs.example.com/lab.pdf"}, nil)
+	h.reject("Org2MSP", "AttachDocument", []string{"B1", "lab-report", strings.ToLower(hash), ""}, nil, ricetypes.ErrCodeConflict)
+	h.invoke("Org2MSP", "AttachDocument", []string{"B2", "lab-report", hash, ""}, nil)
+
+	var documents []*DocumentAnchor
+	h.read("Org3MSP", "GetDocuments", []string{"B1"}, &documents)
+	if len(documents) != 1 || documents[0].SHA256 != strings.ToLower(hash) || documents[0].AttachedBy != "Org1MSP" {
+		t.Errorf("documents of B1 %+v", documents)
+	}
+	h.read("Org3MSP", "GetDocumentsByHash", []string{hash}, &documents)
+	if len(documents) != 2 {
+		t.Errorf("%d anchors for the hash, want 2", len(documents))
+	}
+}
@@ -0,0 +1,31 @@
+package contracts
+
+import (
+	"fmt"
+
+	"ricetypes"
+)
+
+// Errors a client can act on start with a ricetypes error code and ": ", so
+// the frontend reads the code instead of guessing from the wording. Other
+// errors, such as world state failures, stay plain.
+
+func codedError(code string, format string, args ...interface{}) error {
+	return fmt.Errorf("%s: %w", code, fmt.Errorf(format, args...))
+}
+
+func notFoundError(format string, args ...interface{}) error {
+	return codedError(ricetypes.ErrCodeNotFound, format, args...)
+}
+
+func conflictError(format string, args ...interface{}) error {
+	return codedError(ricetypes.ErrCodeConflict, format, args...)
+}
+
+func forbiddenError(format string, args ...interface{}) error {
+	return codedError(ricetypes.ErrCodeForbidden, format, args...)
+}
+
+func invalidArgumentError(format string, args ...interface{}) error {
+	return codedError(ricetypes.ErrCodeInvalidArgument, format, args...)
+}
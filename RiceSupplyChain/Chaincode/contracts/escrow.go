@@ -0,0 +1,256 @@
+package contracts
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+
+	"ricetypes"
+)
+
+type Escrow = ricetypes.Escrow
+
+func escrowKey(batchID string) string {
+	return "ESC-" + batchID
+}
+
+// lockEscrow debits the payer and records the locked amount against the batch.
+// Until the token is initialized there is nothing to escrow, and the invoice
+// is paid with RecordPayment and ConfirmPayment instead.
+func (c *RiceContract) lockEscrow(ctx contractapi.TransactionContextInterface, batchID string, invoice *Invoice) error {
+	tokenInfo, err := ctx.GetStub().GetState(tokenInfoKey)
+	if err != nil {
+		return fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if tokenInfo == nil {
+		return nil
+	}
+	if len(invoice.Payments) > 0 {
+		return conflictError("invoice %v already has payments recorded and cannot be escrowed", invoice.InvoiceID)
+	}
+
+	escrowID := escrowKey(batchID)
+	existing, err := ctx.GetStub().GetState(escrowID)
+	if err != nil {
+		return fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if existing != nil {
+		return conflictError("batch %s already has an escrow", batchID)
+	}
+
+	balance, err := readBalance(ctx, invoice.Payer)
+	if err != nil {
+		return err
+	}
+	if balance < invoice.Total {
+		return conflictError("cannot escrow payment: %v holds %d of the %d due on invoice %v", invoice.Payer, balance, invoice.Total, invoice.InvoiceID)
+	}
+	err = addBalance(ctx, invoice.Payer, -invoice.Total)
+	if err != nil {
+		return err
+	}
+
+	escrow := Escrow{
+		AssetType: "escrow",
+		EscrowID:  escrowID,
+		BatchID:   batchID,
+		InvoiceID: invoice.InvoiceID,
+		Payer:     invoice.Payer,
+		Payee:     invoice.Payee,
+		Amount:    invoice.Total,
+		Status:    "Locked",
+		LockedAt:  txTimestamp(ctx),
+	}
+	bytes, _ := json.Marshal(escrow)
+	return ctx.GetStub().PutState(escrowID, bytes)
+}
+
+// ReadEscrow retrieves the escrow held for a batch
+func (c *RiceContract) ReadEscrow(ctx contractapi.TransactionContextInterface, batchID string) (*Escrow, error) {
+	bytes, err := ctx.GetStub().GetState(escrowKey(batchID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if bytes == nil {
+		return nil, notFoundError("no escrow exists for batch %s", batchID)
+	}
+
+	var escrow Escrow
+	err = json.Unmarshal(bytes, &escrow)
+	if err != nil {
+		return nil, fmt.Errorf("could not unmarshal world state data to type Escrow")
+	}
+	if escrow.AssetType != "escrow" {
+		return nil, notFoundError("no escrow exists for batch %s", batchID)
+	}
+	return &escrow, nil
+}
+
+// escrowedInvoice reports whether an invoice is awaiting payment from the escrow locked for its batch
+func escrowedInvoice(ctx contractapi.TransactionContextInterface, invoice *Invoice) (bool, error) {
+	bytes, err := ctx.GetStub().GetState(escrowKey(invoice.BatchID))
+	if err != nil {
+		return false, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if bytes == nil {
+		return false, nil
+	}
+	var escrow Escrow
+	err = json.Unmarshal(bytes, &escrow)
+	if err != nil {
+		return false, fmt.Errorf("could not unmarshal world state data to type Escrow")
+	}
+	return escrow.Status == "Locked" && escrow.InvoiceID == invoice.InvoiceID, nil
+}
+
+// settleEscrow releases or refunds a locked escrow; batches without escrow are left alone
+func (c *RiceContract) settleEscrow(ctx contractapi.TransactionContextInterface, batchID string, release bool) error {
+	bytes, err := ctx.GetStub().GetState(escrowKey(batchID))
+	if err != nil {
+		return fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if bytes == nil {
+		return nil
+	}
+	var escrow Escrow
+	err = json.Unmarshal(bytes, &escrow)
+	if err != nil {
+		return fmt.Errorf("could not unmarshal world state data to type Escrow")
+	}
+	if escrow.Status != "Locked" {
+		return nil
+	}
+
+	if release {
+		err = addBalance(ctx, escrow.Payee, escrow.Amount)
+		escrow.Status = "Released"
+	} else {
+		err = addBalance(ctx, escrow.Payer, escrow.Amount)
+		escrow.Status = "Refunded"
+	}
+	if err != nil {
+		return err
+	}
+	escrow.SettledAt = txTimestamp(ctx)
+
+	err = c.settleInvoiceFromEscrow(ctx, &escrow, release)
+	if err != nil {
+		return err
+	}
+
+	bytes, _ = json.Marshal(escrow)
+	return ctx.GetStub().PutState(escrow.EscrowID, bytes)
+}
+
+// settleInvoiceFromEscrow marks the invoice Paid when its escrow is released and
+// Cancelled when it is refunded. Only the public status changes, since the
+// retailer settling the escrow cannot write the private invoice.
+func (c *RiceContract) settleInvoiceFromEscrow(ctx contractapi.TransactionContextInterface, escrow *Escrow, release bool) error {
+	status, err := c.ReadInvoiceStatus(ctx, escrow.InvoiceID)
+	if err != nil {
+		return err
+	}
+	if status.Status != "Issued" {
+		return conflictError("invoice %v is %v and cannot be settled from escrow", status.InvoiceID, status.Status)
+	}
+	if release {
+		status.Status = "Paid"
+	} else {
+		status.Status = "Cancelled"
+	}
+	return putInvoiceStatus(ctx, status)
+}
+
+// AcceptDelivery confirms receipt of a dispatched batch and releases its escrow (only by retailer)
+func (c *RiceContract) AcceptDelivery(ctx contractapi.TransactionContextInterface, batchID string) (string, error) {
+	clientOrgID, _ := ctx.GetClientIdentity().GetMSPID()
+	if clientOrgID != "Org3MSP" {
+		return "", forbiddenError("Only Org3MSP (Retailer) can accept delivery")
+	}
+
+	batch, err := c.ReadRiceBatch(ctx, batchID)
+	if err != nil {
+		return "", err
+	}
+	err = c.acceptDelivery(ctx, batch)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Batch %v delivered", batchID), nil
+}
+
+// acceptDelivery marks a dispatched batch delivered and pays out its escrow
+func (c *RiceContract) acceptDelivery(ctx contractapi.TransactionContextInterface, batch *RiceBatch) error {
+	if !strings.HasPrefix(batch.Status, "Dispatched") {
+		return conflictError("batch %v is not awaiting delivery, status: %v", batch.BatchID, batch.Status)
+	}
+
+	err := c.settleEscrow(ctx, batch.BatchID, true)
+	if err != nil {
+		return err
+	}
+	batch.Status = "Delivered"
+	bytes, _ := json.Marshal(batch)
+	err = ctx.GetStub().PutState(batch.BatchID, bytes)
+	if err != nil {
+		return err
+	}
+	return setBatchEvent(ctx, ricetypes.EventBatchDelivered, batch, "")
+}
+
+// RejectDelivery refuses a dispatched batch and refunds its escrow (only by retailer)
+func (c *RiceContract) RejectDelivery(ctx contractapi.TransactionContextInterface, batchID string, reason string) (string, error) {
+	clientOrgID, _ := ctx.GetClientIdentity().GetMSPID()
+	if clientOrgID != "Org3MSP" {
+		return "", forbiddenError("Only Org3MSP (Retailer) can reject delivery")
+	}
+
+	batch, err := c.ReadRiceBatch(ctx, batchID)
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(batch.Status, "Dispatched") {
+		return "", conflictError("batch %v is not awaiting delivery, status: %v", batchID, batch.Status)
+	}
+
+	err = c.settleEscrow(ctx, batchID, false)
+	if err != nil {
+		return "", err
+	}
+	batch.Status = fmt.Sprintf("Rejected by retailer: %v", reason)
+	bytes, _ := json.Marshal(batch)
+	err = ctx.GetStub().PutState(batchID, bytes)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Batch %v rejected", batchID), setBatchEvent(ctx, ricetypes.EventBatchRejected, batch, reason)
+}
+
+// RecallBatch withdraws a batch from the supply chain and refunds any locked escrow (only by farmer)
+func (c *RiceContract) RecallBatch(ctx contractapi.TransactionContextInterface, batchID string, reason string) (string, error) {
+	clientOrgID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return "", err
+	}
+	if clientOrgID != "Org1MSP" {
+		return "", forbiddenError("user under following MSPID: %v can't recall batches", clientOrgID)
+	}
+
+	batch, err := c.ReadRiceBatch(ctx, batchID)
+	if err != nil {
+		return "", err
+	}
+	err = c.settleEscrow(ctx, batchID, false)
+	if err != nil {
+		return "", err
+	}
+	batch.Status = fmt.Sprintf("Recalled: %v", reason)
+	bytes, _ := json.Marshal(batch)
+	err = ctx.GetStub().PutState(batchID, bytes)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Batch %v recalled", batchID), setBatchEvent(ctx, ricetypes.EventBatchRecalled, batch, reason)
+}
@@ -0,0 +1,168 @@
+package contracts
+
+import (
+	"testing"
+
+	"ricetypes"
+)
+
+// escrowed mints the miller enough to pay and mills B1 against a priced order,
+// locking 63000 for INV-B1-O1
+func escrowed(t *testing.T, minted string) *contractHarness {
+	h := newContractHarness(t)
+	h.harvest("B1", 1000)
+	h.invoke("Org1MSP", "InitializeToken", []string{"Rice Rupee", "RINR", "2", "Org1MSP"}, nil)
+	h.invoke("Org1MSP", "Mint", []string{"Org2MSP", minted}, nil)
+	h.mill("B1", "O1", 600, ricetypes.SaleTerms{UnitPrice: 100, Currency: "INR", TaxRateBps: 500})
+	return h
+}
+
+func (h *contractHarness) balance(account string) int64 {
+	h.t.Helper()
+	var balance int64
+	h.read("Org1MSP", "BalanceOf", []string{account}, &balance)
+	return balance
+}
+
+func (h *contractHarness) escrowStatus(batchID string) string {
+	h.t.Helper()
+	var escrow Escrow
+	h.read("Org3MSP", "ReadEscrow", []string{batchID}, &escrow)
+	return escrow.Status
+}
+
+func (h *contractHarness) invoiceStatus(invoiceID string) string {
+	h.t.Helper()
+	var status InvoiceStatus
+	h.read("Org3MSP", "ReadInvoiceStatus", []string{invoiceID}, &status)
+	return status.Status
+}
+
+func TestTokenSetupAndTransfers(t *testing.T) {
+	h := newContractHarness(t)
+	h.reject("Org2MSP", "InitializeToken", []string{"Rice Rupee", "RINR", "2", "Org2MSP"}, nil, ricetypes.ErrCodeForbidden)
+	h.reject("Org1MSP", "Mint", []string{"Org2MSP", "100"}, nil, ricetypes.ErrCodeConflict)
+	h.invoke("Org1MSP", "InitializeToken", []string{"Rice Rupee", "RINR", "2", "Org1MSP"}, nil)
+	h.reject("Org1MSP", "InitializeToken", []string{"Rice Rupee", "RINR", "2", "Org1MSP"}, nil, ricetypes.ErrCodeConflict)
+
+	h.reject("Org2MSP", "Mint", []string{"Org2MSP", "100"}, nil, ricetypes.ErrCodeForbidden)
+	h.reject("Org1MSP", "Mint", []string{"Org2MSP", "0"}, nil, ricetypes.ErrCodeInvalidArgument)
+	h.invoke("Org1MSP", "Mint", []string{"Org2MSP", "100"}, nil)
+
+	h.reject("Org2MSP", "Transfer", []string{"Org3MSP", "101"}, nil, ricetypes.ErrCodeConflict)
+	h.reject("Org2MSP", "Transfer", []string{"Org2MSP", "10"}, nil, ricetypes.ErrCodeInvalidArgument)
+	h.invoke("Org2MSP", "Transfer", []string{"Org3MSP", "40"}, nil)
+
+	h.invoke("Org2MSP", "Approve", []string{"Org3MSP", "25"}, nil)
+	h.reject("Org3MSP", "TransferFrom", []string{"Org2MSP", "Org3MSP", "26"}, nil, ricetypes.ErrCodeConflict)
+	h.invoke("Org3MSP", "TransferFrom", []string{"Org2MSP", "Org3MSP", "25"}, nil)
+	if got, want := h.balance("Org2MSP"), int64(35); got != want {
+		t.Errorf("Org2MSP balance %d, want %d", got, want)
+	}
+	if got, want := h.balance("Org3MSP"), int64(65); got != want {
+		t.Errorf("Org3MSP balance %d, want %d", got, want)
+	}
+}
+
+func TestOnlyTheMillerLocksEscrow(t *testing.T) {
+	h := newContractHarness(t)
+	h.harvest("B1", 1000)
+	h.invoke("Org1MSP", "InitializeToken", []string{"Rice Rupee", "RINR", "2", "Org1MSP"}, nil)
+	h.invoke("Org1MSP", "Mint", []string{"Org2MSP", "100000"}, nil)
+	h.submit("Org2MSP", &ricetypes.CreateProcessingOrderArgs{
+		OrderID: "O1", Variety: "Basmati", MillerName: "Mill Co", QuantityInKg: 600,
+		SaleTerms: ricetypes.SaleTerms{UnitPrice: 100, Currency: "INR", TaxRateBps: 500},
+	})
+	h.reject("Org1MSP", "MatchProcessingOrder", []string{"B1", "O1"}, nil, ricetypes.ErrCodeForbidden)
+	if got := h.balance("Org2MSP"); got != 100000 {
+		t.Errorf("miller balance %d after refused match", got)
+	}
+}
+
+func TestEscrowNeedsTheFullInvoiceTotal(t *testing.T) {
+	h := newContractHarness(t)
+	h.harvest("B1", 1000)
+	h.invoke("Org1MSP", "InitializeToken", []string{"Rice Rupee", "RINR", "2", "Org1MSP"}, nil)
+	h.invoke("Org1MSP", "Mint", []string{"Org2MSP", "62999"}, nil)
+	h.submit("Org2MSP", &ricetypes.CreateProcessingOrderArgs{
+		OrderID: "O1", Variety: "Basmati", MillerName: "Mill Co", QuantityInKg: 600,
+		SaleTerms: ricetypes.SaleTerms{UnitPrice: 100, Currency: "INR", TaxRateBps: 500},
+	})
+	h.reject("Org2MSP", "MatchProcessingOrder", []string{"B1", "O1"}, nil, ricetypes.ErrCodeConflict)
+}
+
+func TestAcceptedDeliveryReleasesEscrow(t *testing.T) {
+	h := escrowed(t, "100000")
+	if got := h.balance("Org2MSP"); got != 37000 {
+		t.Errorf("miller balance %d after lock, want 37000", got)
+	}
+	if got := h.escrowStatus("B1"); got != "Locked" {
+		t.Errorf("escrow %s after match", got)
+	}
+	h.reject("Org2MSP", "RecordPayment", []string{"INV-B1-O1", "P1", "63000"}, nil, ricetypes.ErrCodeConflict)
+
+	h.reject("Org3MSP", "AcceptDelivery", []string{"B1"}, nil, ricetypes.ErrCodeConflict)
+	h.submit("Org3MSP", &ricetypes.DispatchToRetailerArgs{BatchID: "B1", RetailerName: "Fresh Mart"})
+	h.reject("Org2MSP", "AcceptDelivery", []string{"B1"}, nil, ricetypes.ErrCodeForbidden)
+	h.invoke("Org3MSP", "AcceptDelivery", []string{"B1"}, nil)
+
+	if got := h.balance("Org1MSP"); got != 63000 {
+		t.Errorf("farmer balance %d after release, want 63000", got)
+	}
+	if got := h.escrowStatus("B1"); got != "Released" {
+		t.Errorf("escrow %s after delivery", got)
+	}
+	var invoice Invoice
+	h.read("Org1MSP", "ReadInvoice", []string{"INV-B1-O1"}, &invoice)
+	if invoice.Status != "Paid" || invoice.AmountPaid != invoice.Total {
+		t.Errorf("invoice %s, paid %d of %d", invoice.Status, invoice.AmountPaid, invoice.Total)
+	}
+	if event := h.event(ricetypes.EventBatchDelivered); event.BatchID != "B1" {
+		t.Errorf("BatchDelivered event %+v", event)
+	}
+	h.reject("Org3MSP", "AcceptDelivery", []string{"B1"}, nil, ricetypes.ErrCodeConflict)
+}
+
+func TestRejectedDeliveryRefundsAndCancels(t *testing.T) {
+	h := escrowed(t, "100000")
+	h.submit("Org3MSP", &ricetypes.DispatchToRetailerArgs{BatchID: "B1", RetailerName: "Fresh Mart"})
+	h.invoke("Org3MSP", "RejectDelivery", []string{"B1", "damp sacks"}, nil)
+
+	if got := h.balance("Org2MSP"); got != 100000 {
+		t.Errorf("miller balance %d after refund, want 100000", got)
+	}
+	if got := h.escrowStatus("B1"); got != "Refunded" {
+		t.Errorf("escrow %s after rejection", got)
+	}
+	if got := h.invoiceStatus("INV-B1-O1"); got != "Cancelled" {
+		t.Errorf("invoice %s after refund", got)
+	}
+	h.reject("Org2MSP", "RecordPayment", []string{"INV-B1-O1", "P1", "100"}, nil, ricetypes.ErrCodeConflict)
+
+	var receivables []*Invoice
+	h.read("Org1MSP", "GetOutstandingReceivables", nil, &receivables)
+	if len(receivables) != 0 {
+		t.Errorf("cancelled invoice still receivable: %+v", receivables)
+	}
+	if event := h.event(ricetypes.EventBatchRejected); event.Reason != "damp sacks" {
+		t.Errorf("BatchRejected event %+v", event)
+	}
+}
+
+func TestRecallRefundsLockedEscrow(t *testing.T) {
+	h := escrowed(t, "100000")
+	h.reject("Org2MSP", "RecallBatch", []string{"B1", "aflatoxin"}, nil, ricetypes.ErrCodeForbidden)
+	h.invoke("Org1MSP", "RecallBatch", []string{"B1", "aflatoxin"}, nil)
+
+	if got := h.balance("Org2MSP"); got != 100000 {
+		t.Errorf("miller balance %d after recall, want 100000", got)
+	}
+	if got := h.invoiceStatus("INV-B1-O1"); got != "Cancelled" {
+		t.Errorf("invoice %s after recall", got)
+	}
+	// A settled escrow is left alone by a second recall
+	h.invoke("Org1MSP", "RecallBatch", []string{"B1", "aflatoxin"}, nil)
+	if got := h.balance("Org2MSP"); got != 100000 {
+		t.Errorf("miller balance %d after second recall", got)
+	}
+}
@@ -0,0 +1,365 @@
+package contracts
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+
+	"ricetypes"
+)
+
+type (
+	ExportConsignment = ricetypes.ExportConsignment
+	ConsignmentLot    = ricetypes.ConsignmentLot
+	ClearanceStep     = ricetypes.ClearanceStep
+	PackingList       = ricetypes.PackingList
+	PackingListLine   = ricetypes.PackingListLine
+)
+
+// The consignment a batch is booked on is kept under exportBooking~batchID,
+// so concurrent bookings of the same batch conflict at commit
+const exportBookingPrefix = "exportBooking"
+
+// Clearance statuses in the order customs moves a consignment through them
+var clearanceSequence = []string{
+	"Booked",
+	"ExportDeclarationFiled",
+	"CustomsInspected",
+	"ExportCleared",
+	"ShippedOnBoard",
+	"ImportCleared",
+}
+
+var incoterms = map[string]bool{
+	"EXW": true, "FCA": true, "CPT": true, "CIP": true, "DAP": true, "DPU": true,
+	"DDP": true, "FAS": true, "FOB": true, "CFR": true, "CIF": true,
+}
+
+var hsCodePattern = regexp.MustCompile(`^\d{6}(\d{2}){0,2}$`)
+var countryCodePattern = regexp.MustCompile(`^[A-Z]{2}$`)
+var containerNumberPattern = regexp.MustCompile(`^[A-Z]{4}\d{7}$`)
+
+// Helper: check an ISO 6346 container number including its check digit
+func validContainerNumber(number string) bool {
+	if !containerNumberPattern.MatchString(number) {
+		return false
+	}
+	sum := 0
+	for i := 0; i < 10; i++ {
+		value := int(number[i] - '0')
+		if i < 4 {
+			// Letters count from 10 for A, skipping multiples of 11
+			value = 10
+			for ch := byte('A'); ch < number[i]; ch++ {
+				value++
+				if value%11 == 0 {
+					value++
+				}
+			}
+		}
+		sum += value << i
+	}
+	return sum%11%10 == int(number[10]-'0')
+}
+
+// CreateExportConsignment books milled batches into a new export consignment (only by miller)
+func (c *RiceContract) CreateExportConsignment(ctx contractapi.TransactionContextInterface, consignmentID string, hsCode string, destinationCountry string, incoterm string, consignee string, batchIDs []string, containerNumbers []string) (string, error) {
+	clientOrgID, _ := ctx.GetClientIdentity().GetMSPID()
+	if clientOrgID != "Org2MSP" {
+		return "", forbiddenError("Only Org2MSP (Miller) can create export consignments")
+	}
+
+	existing, err := ctx.GetStub().GetState(consignmentID)
+	if err != nil {
+		return "", fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if existing != nil {
+		return "", conflictError("the consignment %s already exists", consignmentID)
+	}
+
+	if !hsCodePattern.MatchString(hsCode) {
+		return "", invalidArgumentError("hsCode %q must be 6, 8 or 10 digits", hsCode)
+	}
+	destinationCountry = strings.ToUpper(destinationCountry)
+	if !countryCodePattern.MatchString(destinationCountry) {
+		return "", invalidArgumentError("destinationCountry %q must be an ISO 3166 alpha-2 code", destinationCountry)
+	}
+	incoterm = strings.ToUpper(incoterm)
+	if !incoterms[incoterm] {
+		return "", invalidArgumentError("unknown incoterm %q", incoterm)
+	}
+	if len(batchIDs) == 0 {
+		return "", invalidArgumentError("a consignment needs at least one batch")
+	}
+	for _, number := range containerNumbers {
+		if !validContainerNumber(number) {
+			return "", invalidArgumentError("container number %q is not a valid ISO 6346 number", number)
+		}
+	}
+
+	consignment := ExportConsignment{
+		AssetType:          "exportConsignment",
+		ConsignmentID:      consignmentID,
+		HSCode:             hsCode,
+		DestinationCountry: destinationCountry,
+		Incoterm:           incoterm,
+		Consignee:          consignee,
+		ContainerNumbers:   containerNumbers,
+		ClearanceStatus:    clearanceSequence[0],
+		Exporter:           clientOrgID,
+		CreatedAt:          txTimestamp(ctx),
+	}
+
+	seen := map[string]bool{}
+	for _, batchID := range batchIDs {
+		if seen[batchID] {
+			return "", invalidArgumentError("batch %v is listed twice", batchID)
+		}
+		seen[batchID] = true
+
+		batch, err := c.ReadRiceBatch(ctx, batchID)
+		if err != nil {
+			return "", err
+		}
+		if !strings.HasPrefix(batch.Status, "Assigned to Miller") {
+			return "", invalidArgumentError("batch %v must be milled before export, status: %v", batchID, batch.Status)
+		}
+		bookingKey, err := ctx.GetStub().CreateCompositeKey(exportBookingPrefix, []string{batchID})
+		if err != nil {
+			return "", err
+		}
+		booked, err := ctx.GetStub().GetState(bookingKey)
+		if err != nil {
+			return "", fmt.Errorf("failed to read from world state: %v", err)
+		}
+		if booked != nil {
+			return "", conflictError("batch %v is already booked on consignment %v", batchID, string(booked))
+		}
+		err = ctx.GetStub().PutState(bookingKey, []byte(consignmentID))
+		if err != nil {
+			return "", err
+		}
+
+		consignment.Lots = append(consignment.Lots, &ConsignmentLot{
+			BatchID:      batch.BatchID,
+			Variety:      batch.Variety,
+			HarvestDate:  batch.HarvestDate,
+			QuantityInKg: batch.QuantityInKg,
+		})
+	}
+
+	consignment.ClearanceSteps = []*ClearanceStep{{
+		Status:     consignment.ClearanceStatus,
+		Reference:  consignmentID,
+		Timestamp:  consignment.CreatedAt,
+		RecordedBy: clientOrgID,
+	}}
+	bytes, _ := json.Marshal(consignment)
+	return fmt.Sprintf("consignment %v booked with %d lots", consignmentID, len(consignment.Lots)), ctx.GetStub().PutState(consignmentID, bytes)
+}
+
+// ReadExportConsignment retrieves an export consignment from world state
+func (c *RiceContract) ReadExportConsignment(ctx contractapi.TransactionContextInterface, consignmentID string) (*ExportConsignment, error) {
+	bytes, err := ctx.GetStub().GetState(consignmentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if bytes == nil {
+		return nil, notFoundError("the consignment %s does not exist", consignmentID)
+	}
+
+	var consignment ExportConsignment
+	err = json.Unmarshal(bytes, &consignment)
+	if err != nil {
+		return nil, fmt.Errorf("could not unmarshal world state data to type ExportConsignment")
+	}
+	if consignment.AssetType != "exportConsignment" {
+		return nil, notFoundError("the consignment %s does not exist", consignmentID)
+	}
+	return &consignment, nil
+}
+
+// Helper: move a consignment to the next clearance status, which must be status
+func (c *RiceContract) advanceClearance(ctx contractapi.TransactionContextInterface, consignmentID string, status string, reference string, note string) (*ExportConsignment, error) {
+	clientOrgID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return nil, err
+	}
+
+	consignment, err := c.ReadExportConsignment(ctx, consignmentID)
+	if err != nil {
+		return nil, err
+	}
+	if clientOrgID != consignment.Exporter {
+		return nil, forbiddenError("only the exporter %v can update consignment %v", consignment.Exporter, consignmentID)
+	}
+	if reference == "" {
+		return nil, invalidArgumentError("a reference is required for %v", status)
+	}
+
+	next := ""
+	for i, s := range clearanceSequence[:len(clearanceSequence)-1] {
+		if s == consignment.ClearanceStatus {
+			next = clearanceSequence[i+1]
+		}
+	}
+	if next != status {
+		return nil, conflictError("consignment %v is %v and cannot move to %v", consignmentID, consignment.ClearanceStatus, status)
+	}
+
+	consignment.ClearanceStatus = status
+	consignment.ClearanceSteps = append(consignment.ClearanceSteps, &ClearanceStep{
+		Status:     status,
+		Reference:  reference,
+		Note:       note,
+		Timestamp:  txTimestamp(ctx),
+		RecordedBy: clientOrgID,
+	})
+	return consignment, nil
+}
+
+func (c *RiceContract) putConsignment(ctx contractapi.TransactionContextInterface, consignment *ExportConsignment) (string, error) {
+	bytes, _ := json.Marshal(consignment)
+	return fmt.Sprintf("consignment %v is %v", consignment.ConsignmentID, consignment.ClearanceStatus), ctx.GetStub().PutState(consignment.ConsignmentID, bytes)
+}
+
+// FileExportDeclaration records the shipping bill filed with export customs
+func (c *RiceContract) FileExportDeclaration(ctx contractapi.TransactionContextInterface, consignmentID string, declarationNumber string) (string, error) {
+	consignment, err := c.advanceClearance(ctx, consignmentID, "ExportDeclarationFiled", declarationNumber, "")
+	if err != nil {
+		return "", err
+	}
+	return c.putConsignment(ctx, consignment)
+}
+
+// RecordCustomsInspection records the outcome of the customs examination
+func (c *RiceContract) RecordCustomsInspection(ctx contractapi.TransactionContextInterface, consignmentID string, inspectionReference string, passed bool, note string) (string, error) {
+	if !passed {
+		return "", conflictError("consignment %v failed inspection; resolve it with customs before recording the step", consignmentID)
+	}
+	consignment, err := c.advanceClearance(ctx, consignmentID, "CustomsInspected", inspectionReference, note)
+	if err != nil {
+		return "", err
+	}
+	return c.putConsignment(ctx, consignment)
+}
+
+// GrantExportClearance records the let export order issued by customs
+func (c *RiceContract) GrantExportClearance(ctx contractapi.TransactionContextInterface, consignmentID string, clearanceReference string) (string, error) {
+	consignment, err := c.advanceClearance(ctx, consignmentID, "ExportCleared", clearanceReference, "")
+	if err != nil {
+		return "", err
+	}
+	return c.putConsignment(ctx, consignment)
+}
+
+// RecordShippedOnBoard records the bill of lading once the containers are loaded
+func (c *RiceContract) RecordShippedOnBoard(ctx contractapi.TransactionContextInterface, consignmentID string, billOfLading string, vessel string) (string, error) {
+	consignment, err := c.advanceClearance(ctx, consignmentID, "ShippedOnBoard", billOfLading, vessel)
+	if err != nil {
+		return "", err
+	}
+	if len(consignment.ContainerNumbers) == 0 {
+		return "", conflictError("consignment %v has no containers to ship", consignmentID)
+	}
+	consignment.BillOfLading = billOfLading
+	consignment.Vessel = vessel
+	return c.putConsignment(ctx, consignment)
+}
+
+// RecordImportClearance records clearance at the destination port
+func (c *RiceContract) RecordImportClearance(ctx contractapi.TransactionContextInterface, consignmentID string, entryReference string) (string, error) {
+	consignment, err := c.advanceClearance(ctx, consignmentID, "ImportCleared", entryReference, "")
+	if err != nil {
+		return "", err
+	}
+	return c.putConsignment(ctx, consignment)
+}
+
+// GetPackingList builds the consolidated packing list of a consignment from its lots and packaging runs
+func (c *RiceContract) GetPackingList(ctx contractapi.TransactionContextInterface, consignmentID string) (*PackingList, error) {
+	consignment, err := c.ReadExportConsignment(ctx, consignmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	list := &PackingList{
+		ConsignmentID:      consignment.ConsignmentID,
+		Exporter:           consignment.Exporter,
+		Consignee:          consignment.Consignee,
+		DestinationCountry: consignment.DestinationCountry,
+		Incoterm:           consignment.Incoterm,
+		HSCode:             consignment.HSCode,
+		BillOfLading:       consignment.BillOfLading,
+		Vessel:             consignment.Vessel,
+		ContainerNumbers:   consignment.ContainerNumbers,
+		Lines:              []*PackingListLine{},
+	}
+	for _, lot := range consignment.Lots {
+		runs, err := c.GetPackagingRuns(ctx, lot.BatchID)
+		if err != nil {
+			return nil, err
+		}
+		packed := 0
+		for _, run := range runs {
+			list.Lines = append(list.Lines, &PackingListLine{
+				BatchID:        lot.BatchID,
+				Variety:        lot.Variety,
+				HarvestDate:    lot.HarvestDate,
+				Description:    fmt.Sprintf("%s rice, %d kg bags", lot.Variety, run.PackSizeKg),
+				Packages:       run.UnitCount,
+				PackSizeKg:     run.PackSizeKg,
+				NetWeightInKg:  run.PackSizeKg * run.UnitCount,
+				SerialRange:    run.FirstSerial + " to " + run.LastSerial,
+				PackagingRunID: run.RunID,
+			})
+			packed += run.PackSizeKg * run.UnitCount
+		}
+		if remainder := lot.QuantityInKg - packed; remainder > 0 {
+			list.Lines = append(list.Lines, &PackingListLine{
+				BatchID:       lot.BatchID,
+				Variety:       lot.Variety,
+				HarvestDate:   lot.HarvestDate,
+				Description:   fmt.Sprintf("%s rice, bulk", lot.Variety),
+				Packages:      1,
+				NetWeightInKg: remainder,
+			})
+		}
+	}
+	for _, line := range list.Lines {
+		list.TotalPackages += line.Packages
+		list.TotalNetWeightInKg += line.NetWeightInKg
+	}
+	return list, nil
+}
+
+// GetExportConsignmentsByStatus returns consignments at a clearance status
+func (c *RiceContract) GetExportConsignmentsByStatus(ctx contractapi.TransactionContextInterface, clearanceStatus string) ([]*ExportConsignment, error) {
+	return c.exportConsignments(ctx, richQuery(map[string]interface{}{"assetType": "exportConsignment", "clearanceStatus": clearanceStatus}))
+}
+
+func (c *RiceContract) exportConsignments(ctx contractapi.TransactionContextInterface, queryString string) ([]*ExportConsignment, error) {
+	resultsIterator, err := ctx.GetStub().GetQueryResult(queryString)
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	var consignments []*ExportConsignment
+	for resultsIterator.HasNext() {
+		queryResult, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		var consignment ExportConsignment
+		err = json.Unmarshal(queryResult.Value, &consignment)
+		if err != nil {
+			return nil, err
+		}
+		consignments = append(consignments, &consignment)
+	}
+	return consignments, nil
+}
@@ -0,0 +1,91 @@
+package contracts
+
+import (
+	"testing"
+
+	"ricetypes"
+)
+
+func consignmentArgs(consignmentID string, hsCode string, country string, incoterm string, batchIDs string, containers string) []string {
+	return []string{consignmentID, hsCode, country, incoterm, "Gulf Foods LLC", batchIDs, containers}
+}
+
+func TestCreateExportConsignment(t *testing.T) {
+	h := newContractHarness(t)
+	h.harvest("B1", 1000)
+	h.harvest("B2", 1000)
+	h.mill("B1", "O1", 1000, ricetypes.SaleTerms{})
+
+	h.reject("Org1MSP", "CreateExportConsignment", consignmentArgs("X1", "100630", "AE", "FOB", `["B1"]`, `[]`), nil, ricetypes.ErrCodeForbidden)
+	for _, args := range [][]string{
+		consignmentArgs("X1", "1006", "AE", "FOB", `["B1"]`, `[]`),
+		consignmentArgs("X1", "100630", "ARE", "FOB", `["B1"]`, `[]`),
+		consignmentArgs("X1", "100630", "AE", "XYZ", `["B1"]`, `[]`),
+		consignmentArgs("X1", "100630", "AE", "FOB", `[]`, `[]`),
+		consignmentArgs("X1", "100630", "AE", "FOB", `["B1"]`, `["CSQU3054384"]`),
+		consignmentArgs("X1", "100630", "AE", "FOB", `["B1","B1"]`, `[]`),
+		consignmentArgs("X1", "100630", "AE", "FOB", `["B2"]`, `[]`),
+	} {
+		h.reject("Org2MSP", "CreateExportConsignment", args, nil, ricetypes.ErrCodeInvalidArgument)
+	}
+
+	h.invoke("Org2MSP", "CreateExportConsignment", consignmentArgs("X1", "10063020", "ae", "fob", `["B1"]`, `["CSQU3054383"]`), nil)
+	h.reject("Org2MSP", "CreateExportConsignment", consignmentArgs("X1", "100630", "AE", "FOB", `["B1"]`, `[]`), nil, ricetypes.ErrCodeConflict)
+	h.reject("Org2MSP", "CreateExportConsignment", consignmentArgs("X2", "100630", "AE", "FOB", `["B1"]`, `[]`), nil, ricetypes.ErrCodeConflict)
+
+	var consignment ExportConsignment
+	h.read("Org3MSP", "ReadExportConsignment", []string{"X1"}, &consignment)
+	if consignment.DestinationCountry != "AE" || consignment.Incoterm != "FOB" || consignment.ClearanceStatus != "Booked" || len(consignment.Lots) != 1 || consignment.Lots[0].QuantityInKg != 1000 {
+		t.Errorf("booked consignment %+v", consignment)
+	}
+}
+
+func TestExportClearanceSequence(t *testing.T) {
+	h := newContractHarness(t)
+	h.harvest("B1", 1000)
+	h.harvest("B2", 500)
+	h.mill("B1", "O1", 1000, ricetypes.SaleTerms{})
+	h.mill("B2", "O2", 500, ricetypes.SaleTerms{})
+	h.invoke("Org2MSP", "CreateExportConsignment", consignmentArgs("X1", "100630", "AE", "FOB", `["B1"]`, `["CSQU3054383"]`), nil)
+	h.invoke("Org2MSP", "CreateExportConsignment", consignmentArgs("X2", "100630", "AE", "FOB", `["B2"]`, `[]`), nil)
+
+	h.reject("Org3MSP", "FileExportDeclaration", []string{"X1", "SB-1"}, nil, ricetypes.ErrCodeForbidden)
+	h.reject("Org2MSP", "FileExportDeclaration", []string{"X1", ""}, nil, ricetypes.ErrCodeInvalidArgument)
+	h.reject("Org2MSP", "GrantExportClearance", []string{"X1", "LEO-1"}, nil, ricetypes.ErrCodeConflict)
+
+	h.invoke("Org2MSP", "FileExportDeclaration", []string{"X1", "SB-1"}, nil)
+	h.reject("Org2MSP", "RecordCustomsInspection", []string{"X1", "EX-1", "false", "moisture too high"}, nil, ricetypes.ErrCodeConflict)
+	h.invoke("Org2MSP", "RecordCustomsInspection", []string{"X1", "EX-1", "true", ""}, nil)
+	h.invoke("Org2MSP", "GrantExportClearance", []string{"X1", "LEO-1"}, nil)
+	h.invoke("Org2MSP", "RecordShippedOnBoard", []string{"X1", "BL-1", "MV Rice Star"}, nil)
+	h.invoke("Org2MSP", "RecordImportClearance", []string{"X1", "ENTRY-1"}, nil)
+	h.reject("Org2MSP", "RecordImportClearance", []string{"X1", "ENTRY-2"}, nil, ricetypes.ErrCodeConflict)
+
+	h.invoke("Org2MSP", "FileExportDeclaration", []string{"X2", "SB-2"}, nil)
+	h.invoke("Org2MSP", "RecordCustomsInspection", []string{"X2", "EX-2", "true", ""}, nil)
+	h.invoke("Org2MSP", "GrantExportClearance", []string{"X2", "LEO-2"}, nil)
+	h.reject("Org2MSP", "RecordShippedOnBoard", []string{"X2", "BL-2", "MV Rice Star"}, nil, ricetypes.ErrCodeConflict)
+
+	var cleared []*ExportConsignment
+	h.read("Org1MSP", "GetExportConsignmentsByStatus", []string{"ImportCleared"}, &cleared)
+	if len(cleared) != 1 || cleared[0].BillOfLading != "BL-1" || len(cleared[0].ClearanceSteps) != 6 {
+		t.Errorf("import cleared consignments %+v", cleared)
+	}
+}
+
+func TestPackingListAddsUnpackedBulk(t *testing.T) {
+	h := newContractHarness(t)
+	h.harvest("B1", 1000)
+	h.mill("B1", "O1", 1000, ricetypes.SaleTerms{})
+	h.invoke("Org2MSP", "PackageBatch", []string{"R1", "B1", "5", "120", "2025-01-10", "2026-01-10"}, nil)
+	h.invoke("Org2MSP", "CreateExportConsignment", consignmentArgs("X1", "100630", "AE", "FOB", `["B1"]`, `["CSQU3054383"]`), nil)
+
+	var list PackingList
+	h.read("Org3MSP", "GetPackingList", []string{"X1"}, &list)
+	if len(list.Lines) != 2 || list.Lines[0].SerialRange != "R1-000001 to R1-000120" || list.Lines[1].NetWeightInKg != 400 {
+		t.Errorf("packing list lines %+v", list.Lines)
+	}
+	if list.TotalPackages != 121 || list.TotalNetWeightInKg != 1000 {
+		t.Errorf("packing list totals %d packages, %d kg", list.TotalPackages, list.TotalNetWeightInKg)
+	}
+}
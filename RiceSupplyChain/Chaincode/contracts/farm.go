@@ -0,0 +1,259 @@
+package contracts
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+
+	"ricetypes"
+)
+
+type (
+	Farm           = ricetypes.Farm
+	Field          = ricetypes.Field
+	GeoJSONPolygon = ricetypes.GeoJSONPolygon
+)
+
+// Upper plausible paddy yields in kg per hectare, by lower-case variety
+var varietyMaxYieldKgPerHa = map[string]int64{
+	"basmati":     6000,
+	"sona masuri": 8000,
+	"ponni":       8000,
+	"ir64":        9000,
+	"swarna":      9000,
+}
+
+const defaultMaxYieldKgPerHa = 10000
+
+// The kilograms harvested from a field in a crop season are kept under
+// fieldHarvest~fieldID~cropSeason. Each harvest reads and rewrites the total,
+// so two batches from the same field and season conflict at commit instead
+// of both passing the yield check.
+const fieldHarvestPrefix = "fieldHarvest"
+
+// Helper: parse and validate a GeoJSON Polygon
+func parseGeoJSONPolygon(geoJSON string) (*GeoJSONPolygon, error) {
+	var polygon GeoJSONPolygon
+	if err := json.Unmarshal([]byte(geoJSON), &polygon); err != nil {
+		return nil, invalidArgumentError("boundary is not valid GeoJSON: %v", err)
+	}
+	if polygon.Type != "Polygon" {
+		return nil, invalidArgumentError("boundary must be a GeoJSON Polygon, got %q", polygon.Type)
+	}
+	if len(polygon.Coordinates) == 0 {
+		return nil, invalidArgumentError("boundary has no rings")
+	}
+	for _, ring := range polygon.Coordinates {
+		if len(ring) < 4 {
+			return nil, invalidArgumentError("boundary rings need at least 4 positions")
+		}
+		for _, position := range ring {
+			if len(position) < 2 || position[0] < -180 || position[0] > 180 || position[1] < -90 || position[1] > 90 {
+				return nil, invalidArgumentError("boundary position %v is not a valid [longitude, latitude]", position)
+			}
+		}
+		first, last := ring[0], ring[len(ring)-1]
+		if first[0] != last[0] || first[1] != last[1] {
+			return nil, invalidArgumentError("boundary rings must be closed")
+		}
+	}
+	return &polygon, nil
+}
+
+// Helper: render an area in hundredths of a hectare as hectares
+func formatCentiHa(areaCentiHa int64) string {
+	return fmt.Sprintf("%d.%02d ha", areaCentiHa/100, areaCentiHa%100)
+}
+
+// RegisterFarm records a farm and its owner (only by farmer)
+func (c *RiceContract) RegisterFarm(ctx contractapi.TransactionContextInterface, farmID string, name string, owner string, region string) (string, error) {
+	clientOrgID, _ := ctx.GetClientIdentity().GetMSPID()
+	if clientOrgID != "Org1MSP" {
+		return "", forbiddenError("Only Org1MSP (Farmer) can register farms")
+	}
+
+	existing, err := ctx.GetStub().GetState(farmID)
+	if err != nil {
+		return "", fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if existing != nil {
+		return "", conflictError("the farm %s already exists", farmID)
+	}
+	if owner == "" {
+		return "", invalidArgumentError("owner is required")
+	}
+
+	farm := Farm{
+		AssetType:    "farm",
+		FarmID:       farmID,
+		Name:         name,
+		Owner:        owner,
+		Region:       region,
+		RegisteredBy: clientOrgID,
+	}
+	bytes, _ := json.Marshal(farm)
+	return fmt.Sprintf("farm %v registered", farmID), ctx.GetStub().PutState(farmID, bytes)
+}
+
+// ReadFarm retrieves a farm from world state
+func (c *RiceContract) ReadFarm(ctx contractapi.TransactionContextInterface, farmID string) (*Farm, error) {
+	bytes, err := ctx.GetStub().GetState(farmID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if bytes == nil {
+		return nil, notFoundError("the farm %s does not exist", farmID)
+	}
+
+	var farm Farm
+	err = json.Unmarshal(bytes, &farm)
+	if err != nil {
+		return nil, fmt.Errorf("could not unmarshal world state data to type Farm")
+	}
+	if farm.AssetType != "farm" {
+		return nil, notFoundError("the farm %s does not exist", farmID)
+	}
+	return &farm, nil
+}
+
+// RegisterField adds a field with its GeoJSON boundary to a farm (only by
+// farmer). The client measures the area from the boundary and passes it in
+// hundredths of a hectare, so every peer stores the same integer.
+func (c *RiceContract) RegisterField(ctx contractapi.TransactionContextInterface, fieldID string, farmID string, name string, boundary string, areaCentiHa int64, cropSeason string) (string, error) {
+	clientOrgID, _ := ctx.GetClientIdentity().GetMSPID()
+	if clientOrgID != "Org1MSP" {
+		return "", forbiddenError("Only Org1MSP (Farmer) can register fields")
+	}
+
+	existing, err := ctx.GetStub().GetState(fieldID)
+	if err != nil {
+		return "", fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if existing != nil {
+		return "", conflictError("the field %s already exists", fieldID)
+	}
+	if _, err := c.ReadFarm(ctx, farmID); err != nil {
+		return "", err
+	}
+
+	polygon, err := parseGeoJSONPolygon(boundary)
+	if err != nil {
+		return "", err
+	}
+	if areaCentiHa <= 0 || areaCentiHa > maxCultivatedAreaCentiHa {
+		return "", invalidArgumentError("areaCentiHa of field %s must be between 1 and %d", fieldID, maxCultivatedAreaCentiHa)
+	}
+
+	field := Field{
+		AssetType:   "field",
+		FieldID:     fieldID,
+		FarmID:      farmID,
+		Name:        name,
+		Boundary:    polygon,
+		AreaCentiHa: areaCentiHa,
+		CropSeason:  cropSeason,
+	}
+	bytes, _ := json.Marshal(field)
+	return fmt.Sprintf("field %v registered, %v", fieldID, formatCentiHa(areaCentiHa)), ctx.GetStub().PutState(fieldID, bytes)
+}
+
+// ReadField retrieves a field from world state
+func (c *RiceContract) ReadField(ctx contractapi.TransactionContextInterface, fieldID string) (*Field, error) {
+	bytes, err := ctx.GetStub().GetState(fieldID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if bytes == nil {
+		return nil, notFoundError("the field %s does not exist", fieldID)
+	}
+
+	var field Field
+	err = json.Unmarshal(bytes, &field)
+	if err != nil {
+		return nil, fmt.Errorf("could not unmarshal world state data to type Field")
+	}
+	if field.AssetType != "field" {
+		return nil, notFoundError("the field %s does not exist", fieldID)
+	}
+	return &field, nil
+}
+
+// StartCropSeason moves a field on to a new crop season (only by farmer)
+func (c *RiceContract) StartCropSeason(ctx contractapi.TransactionContextInterface, fieldID string, cropSeason string) (string, error) {
+	clientOrgID, _ := ctx.GetClientIdentity().GetMSPID()
+	if clientOrgID != "Org1MSP" {
+		return "", forbiddenError("Only Org1MSP (Farmer) can update fields")
+	}
+
+	field, err := c.ReadField(ctx, fieldID)
+	if err != nil {
+		return "", err
+	}
+	if cropSeason == "" || cropSeason == field.CropSeason {
+		return "", conflictError("field %v is already in season %q", fieldID, field.CropSeason)
+	}
+	field.CropSeason = cropSeason
+	bytes, _ := json.Marshal(field)
+	return fmt.Sprintf("field %v started season %v", fieldID, cropSeason), ctx.GetStub().PutState(fieldID, bytes)
+}
+
+// GetFields returns the fields of a farm, or every field when farmID is empty
+func (c *RiceContract) GetFields(ctx contractapi.TransactionContextInterface, farmID string) ([]*Field, error) {
+	queryString := `{"selector":{"assetType":"field"}}`
+	if farmID != "" {
+		queryString = richQuery(map[string]interface{}{"assetType": "field", "farmID": farmID})
+	}
+	resultsIterator, err := ctx.GetStub().GetQueryResult(queryString)
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	var fields []*Field
+	for resultsIterator.HasNext() {
+		queryResult, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		var field Field
+		err = json.Unmarshal(queryResult.Value, &field)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, &field)
+	}
+	return fields, nil
+}
+
+// Helper: check a harvest against the field it came from and add it to the
+// field's season total. Batches already harvested from the field this season
+// count towards the limit.
+func (c *RiceContract) recordHarvest(ctx contractapi.TransactionContextInterface, field *Field, variety string, quantityInKg int) error {
+	if quantityInKg <= 0 {
+		return invalidArgumentError("quantityInKg must be positive")
+	}
+	maxYield, ok := varietyMaxYieldKgPerHa[strings.ToLower(variety)]
+	if !ok {
+		maxYield = defaultMaxYieldKgPerHa
+	}
+
+	harvestKey, err := ctx.GetStub().CreateCompositeKey(fieldHarvestPrefix, []string{field.FieldID, field.CropSeason})
+	if err != nil {
+		return err
+	}
+	harvested, err := readAmount(ctx, harvestKey)
+	if err != nil {
+		return err
+	}
+	harvested += int64(quantityInKg)
+
+	limit := field.AreaCentiHa * maxYield / 100
+	if harvested > limit {
+		return invalidArgumentError("%d kg of %v from field %v (%v) in season %v exceeds the plausible %d kg",
+			harvested, variety, field.FieldID, formatCentiHa(field.AreaCentiHa), field.CropSeason, limit)
+	}
+	return ctx.GetStub().PutState(harvestKey, []byte(strconv.FormatInt(harvested, 10)))
+}
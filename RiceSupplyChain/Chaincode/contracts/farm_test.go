@@ -0,0 +1,58 @@
+package contracts
+
+import (
+	"testing"
+
+	"ricetypes"
+)
+
+func TestRegisterField(t *testing.T) {
+	h := newContractHarness(t)
+	h.invoke("Org1MSP", "RegisterFarm", []string{"FARM1", "Green Acres", "Ravi", "Punjab"}, nil)
+	boundary := `{"type":"Polygon","coordinates":[[[75.0,30.0],[75.01,30.0],[75.01,30.01],[75.0,30.01],[75.0,30.0]]]}`
+	field := func(fieldID string, farmID string, boundary string, areaCentiHa string) []string {
+		return []string{fieldID, farmID, "North", boundary, areaCentiHa, "2024-kharif"}
+	}
+
+	h.reject("Org2MSP", "RegisterField", field("FIELD1", "FARM1", boundary, "10707"), nil, ricetypes.ErrCodeForbidden)
+	h.reject("Org1MSP", "RegisterField", field("FIELD1", "FARM9", boundary, "10707"), nil, ricetypes.ErrCodeNotFound)
+	h.reject("Org1MSP", "RegisterField", field("FIELD1", "FARM1", boundary, "0"), nil, ricetypes.ErrCodeInvalidArgument)
+	h.reject("Org1MSP", "RegisterField", field("FIELD1", "FARM1", boundary, "10000001"), nil, ricetypes.ErrCodeInvalidArgument)
+	h.reject("Org1MSP", "RegisterField", field("FIELD1", "FARM1", `{"type":"Point","coordinates":[75.0,30.0]}`, "10707"), nil, ricetypes.ErrCodeInvalidArgument)
+	h.reject("Org1MSP", "RegisterField", field("FIELD1", "FARM1", `{"type":"Polygon","coordinates":[[[75.0,30.0],[75.01,30.0],[75.01,30.01],[75.0,30.01]]]}`, "10707"), nil, ricetypes.ErrCodeInvalidArgument)
+	h.reject("Org1MSP", "RegisterField", field("FIELD1", "FARM1", `{"type":"Polygon","coordinates":[[[75.0,30.0],[75.01,30.0],[75.01,30.01],[75.0,30.01],[75.0,30.02]]]}`, "10707"), nil, ricetypes.ErrCodeInvalidArgument)
+
+	h.invoke("Org1MSP", "RegisterField", field("FIELD1", "FARM1", boundary, "10707"), nil)
+	h.reject("Org1MSP", "RegisterField", field("FIELD1", "FARM1", boundary, "10707"), nil, ricetypes.ErrCodeConflict)
+
+	var fields []*Field
+	h.read("Org3MSP", "GetFields", []string{"FARM1"}, &fields)
+	if len(fields) != 1 || fields[0].AreaCentiHa != 10707 || len(fields[0].Boundary.Coordinates[0]) != 5 {
+		t.Errorf("fields of FARM1 %+v", fields)
+	}
+}
+
+func TestHarvestIsCappedByFieldYield(t *testing.T) {
+	h := newContractHarness(t)
+	h.harvest("B1", 600000)
+	batch := func(batchID string, variety string, quantityInKg int, farmerName string) *ricetypes.CreateRiceBatchArgs {
+		return &ricetypes.CreateRiceBatchArgs{BatchID: batchID, Variety: variety, HarvestDate: "2024-10-21", QuantityInKg: quantityInKg, FarmerName: farmerName, FieldID: "FIELD1"}
+	}
+
+	h.reject("Org1MSP", "CreateRiceBatch", batch("B2", "Basmati", 0, "Ravi").Args(), nil, ricetypes.ErrCodeInvalidArgument)
+	h.reject("Org1MSP", "CreateRiceBatch", batch("B2", "Basmati", 100, "Anil").Args(), nil, ricetypes.ErrCodeInvalidArgument)
+	// 107.07 ha of basmati at 6 t/ha allows 642420 kg in the season
+	h.reject("Org1MSP", "CreateRiceBatch", batch("B2", "Basmati", 42421, "Ravi").Args(), nil, ricetypes.ErrCodeInvalidArgument)
+	h.submit("Org1MSP", batch("B2", "Basmati", 42420, "Ravi"))
+	h.reject("Org1MSP", "CreateRiceBatch", batch("B3", "Basmati", 1, "Ravi").Args(), nil, ricetypes.ErrCodeInvalidArgument)
+	// Unlisted varieties allow 10 t/ha against the same season total
+	h.submit("Org1MSP", batch("B3", "Kalanamak", 428280, "Ravi"))
+	h.reject("Org1MSP", "CreateRiceBatch", batch("B4", "Kalanamak", 1, "Ravi").Args(), nil, ricetypes.ErrCodeInvalidArgument)
+
+	h.reject("Org1MSP", "StartCropSeason", []string{"FIELD1", "2024-kharif"}, nil, ricetypes.ErrCodeConflict)
+	h.invoke("Org1MSP", "StartCropSeason", []string{"FIELD1", "2025-rabi"}, nil)
+	h.submit("Org1MSP", batch("B4", "Basmati", 642420, "Ravi"))
+	if season := h.batch("B4").CropSeason; season != "2025-rabi" {
+		t.Errorf("batch harvested in season %q, want 2025-rabi", season)
+	}
+}
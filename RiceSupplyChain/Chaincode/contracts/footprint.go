@@ -0,0 +1,405 @@
+package contracts
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/bits"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+
+	"ricetypes"
+)
+
+// Footprints are kept in grams of CO2e and litres of water so peers agree
+// on integer values. Each asset that records emissions of its own has a
+// FootprintRecord under "FP-<assetID>"; GetFootprint adds what an asset
+// inherits from the assets it was made from, allocated by mass:
+//
+//	packaging run     <- share of its batch (split)
+//	retail unit       <- share of its packaging run (split)
+//	export consignment <- every lot it groups (merge)
+//
+// Factors follow the IPCC 2019 Refinement (rice CH4, Vol 4 Ch 5; N2O,
+// Vol 4 Ch 11) with AR6 100-year GWPs.
+const (
+	methaneBaselineGPerHaDay = 1190 // kg CH4/ha/day x 1000, continuously flooded
+	defaultSeasonDays        = 120
+	maxSeasonDays            = 1000
+	maxCultivatedAreaCentiHa = 10000000 // 100,000 ha
+	methaneGWP               = 27
+	// Direct N2O from N applied to flooded rice: 0.004 kg N2O-N/kg N * 44/28 * 273
+	fertiliserNGPerKg      = 1716
+	defaultGridGPerKWh     = 716 // Indian grid average
+	footprintAllocationMax = 4   // batch -> run -> unit, consignment -> batch
+)
+
+// Caps on the other inputs keep every product below in range of int64
+const (
+	maxFarmInputQuantity = 1000000000000 // m3 of water, kg of N or kg CO2e
+	maxEnergyKWh         = 1000000000000
+	maxGridGPerKWh       = 10000
+	maxTransportKm       = 100000
+)
+
+// Water regime scaling factors for methane, per mille
+var fieldEmissionsMethods = map[string]int64{
+	"continuous-flooding": 1000,
+	"single-drainage":     710,
+	"awd":                 550,
+	"rainfed":             540,
+	"measured":            0,
+}
+
+// Transport emission factors in grams CO2e per tonne-km
+var transportModes = map[string]int64{
+	"road":            105,
+	"rail":            28,
+	"sea":             16,
+	"inland-waterway": 31,
+}
+
+type (
+	FarmInputs          = ricetypes.FarmInputs
+	FootprintEntry      = ricetypes.FootprintEntry
+	FootprintRecord     = ricetypes.FootprintRecord
+	Footprint           = ricetypes.Footprint
+	FootprintAllocation = ricetypes.FootprintAllocation
+)
+
+func footprintKey(assetID string) string {
+	return "FP-" + assetID
+}
+
+// Helper: farm inputs travel as transient data alongside CreateRiceBatch
+func farmInputsFromTransient(transientData map[string][]byte) (*FarmInputs, error) {
+	method := string(transientData[ricetypes.TransientFieldEmissionsMethod])
+	water := string(transientData[ricetypes.TransientWaterUsageM3])
+	fertiliser := string(transientData[ricetypes.TransientFertiliserNKg])
+	if method == "" && water == "" && fertiliser == "" {
+		return nil, nil
+	}
+	if _, ok := fieldEmissionsMethods[method]; !ok {
+		return nil, invalidArgumentError("unknown fieldEmissionsMethod %q", method)
+	}
+
+	inputs := &FarmInputs{FieldEmissionsMethod: method}
+	var err error
+	if inputs.WaterUsageM3, err = transientInt64(transientData, ricetypes.TransientWaterUsageM3); err != nil {
+		return nil, err
+	}
+	if inputs.FertiliserNKg, err = transientInt64(transientData, ricetypes.TransientFertiliserNKg); err != nil {
+		return nil, err
+	}
+	if inputs.SeasonDays, err = transientInt64(transientData, ricetypes.TransientSeasonDays); err != nil {
+		return nil, err
+	}
+	if inputs.SeasonDays == 0 {
+		inputs.SeasonDays = defaultSeasonDays
+	}
+	if inputs.CultivatedAreaCentiHa, err = transientInt64(transientData, ricetypes.TransientCultivatedAreaCentiHa); err != nil {
+		return nil, err
+	}
+	if inputs.WaterUsageM3 < 0 || inputs.FertiliserNKg < 0 || inputs.CultivatedAreaCentiHa < 0 || inputs.SeasonDays < 0 {
+		return nil, invalidArgumentError("farm inputs cannot be negative")
+	}
+	if inputs.WaterUsageM3 > maxFarmInputQuantity || inputs.FertiliserNKg > maxFarmInputQuantity {
+		return nil, invalidArgumentError("waterUsageM3 and fertiliserNKg cannot exceed %d", maxFarmInputQuantity)
+	}
+	// Keeps the methane product below in range of int64
+	if inputs.CultivatedAreaCentiHa > maxCultivatedAreaCentiHa || inputs.SeasonDays > maxSeasonDays {
+		return nil, invalidArgumentError("cultivatedAreaCentiHa cannot exceed %d and seasonDays %d", maxCultivatedAreaCentiHa, maxSeasonDays)
+	}
+	if method != "measured" && inputs.CultivatedAreaCentiHa == 0 {
+		return nil, invalidArgumentError("cultivatedAreaCentiHa is required for the %v method", method)
+	}
+	return inputs, nil
+}
+
+// Helper: record the farm stage footprint of a new batch
+func (c *RiceContract) recordFarmFootprint(ctx contractapi.TransactionContextInterface, batchID string, inputs *FarmInputs, measuredKgCO2e int64) error {
+	clientOrgID, _ := ctx.GetClientIdentity().GetMSPID()
+	now := txTimestamp(ctx)
+
+	fieldGrams := measuredKgCO2e * 1000
+	if inputs.FieldEmissionsMethod != "measured" {
+		// Area is in hundredths of a hectare and the regime factor per mille,
+		// so divide by 100 * 1000, rounding half up
+		scaled := inputs.CultivatedAreaCentiHa * methaneBaselineGPerHaDay * inputs.SeasonDays *
+			fieldEmissionsMethods[inputs.FieldEmissionsMethod] * methaneGWP
+		fieldGrams = (scaled + 50000) / 100000
+	}
+
+	record := FootprintRecord{
+		AssetType:  "footprint",
+		AssetID:    batchID,
+		FarmInputs: inputs,
+		Entries: []*FootprintEntry{
+			{
+				Stage:       "farm",
+				Description: fmt.Sprintf("field methane (%v)", inputs.FieldEmissionsMethod),
+				CO2eGrams:   fieldGrams,
+				WaterLitres: inputs.WaterUsageM3 * 1000,
+				RecordedBy:  clientOrgID,
+				RecordedAt:  now,
+			},
+			{
+				Stage:       "farm",
+				Description: fmt.Sprintf("fertiliser N2O (%d kg N)", inputs.FertiliserNKg),
+				CO2eGrams:   inputs.FertiliserNKg * fertiliserNGPerKg,
+				RecordedBy:  clientOrgID,
+				RecordedAt:  now,
+			},
+		},
+	}
+	bytes, _ := json.Marshal(record)
+	return ctx.GetStub().PutState(footprintKey(batchID), bytes)
+}
+
+func (c *RiceContract) readFootprintRecord(ctx contractapi.TransactionContextInterface, assetID string) (*FootprintRecord, error) {
+	bytes, err := ctx.GetStub().GetState(footprintKey(assetID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	record := &FootprintRecord{AssetType: "footprint", AssetID: assetID, Entries: []*FootprintEntry{}}
+	if bytes != nil {
+		if err := json.Unmarshal(bytes, record); err != nil {
+			return nil, err
+		}
+	}
+	return record, nil
+}
+
+func (c *RiceContract) addFootprintEntry(ctx contractapi.TransactionContextInterface, assetID string, entry *FootprintEntry) error {
+	record, err := c.readFootprintRecord(ctx, assetID)
+	if err != nil {
+		return err
+	}
+	record.Entries = append(record.Entries, entry)
+	bytes, _ := json.Marshal(record)
+	return ctx.GetStub().PutState(footprintKey(assetID), bytes)
+}
+
+// RecordMillingEnergy adds the emissions of the electricity used to mill a batch (only by miller)
+func (c *RiceContract) RecordMillingEnergy(ctx contractapi.TransactionContextInterface, batchID string, energyKWh int64, gridFactorGPerKWh int64) (string, error) {
+	clientOrgID, _ := ctx.GetClientIdentity().GetMSPID()
+	if clientOrgID != "Org2MSP" {
+		return "", forbiddenError("Only Org2MSP (Miller) can record milling energy")
+	}
+
+	batch, err := c.ReadRiceBatch(ctx, batchID)
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(batch.Status, "Assigned to Miller") {
+		return "", conflictError("batch %v has not been milled, status: %v", batchID, batch.Status)
+	}
+	if energyKWh <= 0 || gridFactorGPerKWh < 0 {
+		return "", invalidArgumentError("energy must be positive and grid factor cannot be negative")
+	}
+	if energyKWh > maxEnergyKWh || gridFactorGPerKWh > maxGridGPerKWh {
+		return "", invalidArgumentError("energy cannot exceed %d kWh and grid factor %d g/kWh", maxEnergyKWh, maxGridGPerKWh)
+	}
+	if gridFactorGPerKWh == 0 {
+		gridFactorGPerKWh = defaultGridGPerKWh
+	}
+
+	grams := energyKWh * gridFactorGPerKWh
+	err = c.addFootprintEntry(ctx, batchID, &FootprintEntry{
+		Stage:       "milling",
+		Description: fmt.Sprintf("%d kWh at %d g/kWh", energyKWh, gridFactorGPerKWh),
+		CO2eGrams:   grams,
+		RecordedBy:  clientOrgID,
+		RecordedAt:  txTimestamp(ctx),
+	})
+	return fmt.Sprintf("milling energy recorded for %v: %d g CO2e", batchID, grams), err
+}
+
+// RecordTransportEmissions adds a transport leg for a batch, packaging run or consignment
+func (c *RiceContract) RecordTransportEmissions(ctx contractapi.TransactionContextInterface, assetID string, distanceKm int64, mode string) (string, error) {
+	clientOrgID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return "", err
+	}
+	if !canShip(clientOrgID) {
+		return "", forbiddenError("user under following MSPID: %v can't record transport", clientOrgID)
+	}
+
+	factor, ok := transportModes[mode]
+	if !ok {
+		return "", invalidArgumentError("unknown transport mode %q", mode)
+	}
+	if distanceKm <= 0 || distanceKm > maxTransportKm {
+		return "", invalidArgumentError("distance must be between 1 and %d km", maxTransportKm)
+	}
+	kind, mass, err := c.footprintMass(ctx, assetID)
+	if err != nil {
+		return "", err
+	}
+	if kind == "retailUnit" {
+		return "", invalidArgumentError("transport is recorded against the packaging run, not a single unit")
+	}
+	if int64(mass) > math.MaxInt64/(distanceKm*factor) {
+		return "", invalidArgumentError("transport of %d kg over %d km is out of range", mass, distanceKm)
+	}
+
+	grams := distanceKm * int64(mass) * factor / 1000
+	err = c.addFootprintEntry(ctx, assetID, &FootprintEntry{
+		Stage:       "transport",
+		Description: fmt.Sprintf("%d km by %v, %d kg", distanceKm, mode, mass),
+		CO2eGrams:   grams,
+		RecordedBy:  clientOrgID,
+		RecordedAt:  txTimestamp(ctx),
+	})
+	return fmt.Sprintf("transport recorded for %v: %d g CO2e", assetID, grams), err
+}
+
+// Helper: resolve what kind of asset an ID names and its mass
+func (c *RiceContract) footprintMass(ctx contractapi.TransactionContextInterface, assetID string) (string, int, error) {
+	bytes, err := ctx.GetStub().GetState(assetID)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if bytes == nil {
+		// Retail serials have no key of their own
+		if separator := strings.LastIndex(assetID, "-"); separator > 0 {
+			if run, err := c.ReadPackagingRun(ctx, assetID[:separator]); err == nil {
+				return "retailUnit", run.PackSizeKg, nil
+			}
+		}
+		return "", 0, notFoundError("the asset %s does not exist", assetID)
+	}
+
+	var asset struct {
+		AssetType string `json:"assetType"`
+	}
+	if err := json.Unmarshal(bytes, &asset); err != nil {
+		return "", 0, invalidArgumentError("asset %s has no footprint", assetID)
+	}
+	switch asset.AssetType {
+	case "riceBatch":
+		var batch RiceBatch
+		if err := json.Unmarshal(bytes, &batch); err != nil {
+			return "", 0, fmt.Errorf("could not unmarshal world state data to type RiceBatch")
+		}
+		return asset.AssetType, batch.QuantityInKg, nil
+	case "packagingRun":
+		var run PackagingRun
+		if err := json.Unmarshal(bytes, &run); err != nil {
+			return "", 0, fmt.Errorf("could not unmarshal world state data to type PackagingRun")
+		}
+		return asset.AssetType, run.PackSizeKg * run.UnitCount, nil
+	case "exportConsignment":
+		var consignment ExportConsignment
+		if err := json.Unmarshal(bytes, &consignment); err != nil {
+			return "", 0, fmt.Errorf("could not unmarshal world state data to type ExportConsignment")
+		}
+		mass := 0
+		for _, lot := range consignment.Lots {
+			mass += lot.QuantityInKg
+		}
+		return asset.AssetType, mass, nil
+	}
+	return "", 0, invalidArgumentError("asset %s of type %q has no footprint", assetID, asset.AssetType)
+}
+
+// GetFootprint returns the carbon and water footprint of a batch, packaging run, retail unit or consignment
+func (c *RiceContract) GetFootprint(ctx contractapi.TransactionContextInterface, assetID string) (*Footprint, error) {
+	return c.footprint(ctx, assetID, 0)
+}
+
+func (c *RiceContract) footprint(ctx contractapi.TransactionContextInterface, assetID string, depth int) (*Footprint, error) {
+	if depth > footprintAllocationMax {
+		return nil, fmt.Errorf("footprint of %s is nested too deeply", assetID)
+	}
+	kind, mass, err := c.footprintMass(ctx, assetID)
+	if err != nil {
+		return nil, err
+	}
+
+	footprint := &Footprint{
+		AssetID:     assetID,
+		Kind:        kind,
+		MassInKg:    mass,
+		ByStage:     map[string]int64{},
+		Entries:     []*FootprintEntry{},
+		Allocations: []*FootprintAllocation{},
+	}
+
+	// Sources this asset was split from or merged out of, with the mass taken from each
+	type source struct {
+		id   string
+		mass int
+	}
+	var sources []source
+	switch kind {
+	case "retailUnit":
+		separator := strings.LastIndex(assetID, "-")
+		sources = append(sources, source{assetID[:separator], mass})
+	case "packagingRun":
+		run, err := c.ReadPackagingRun(ctx, assetID)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, source{run.BatchID, mass})
+	case "exportConsignment":
+		consignment, err := c.ReadExportConsignment(ctx, assetID)
+		if err != nil {
+			return nil, err
+		}
+		for _, lot := range consignment.Lots {
+			sources = append(sources, source{lot.BatchID, lot.QuantityInKg})
+		}
+	}
+
+	for _, s := range sources {
+		parent, err := c.footprint(ctx, s.id, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		if parent.MassInKg == 0 {
+			continue
+		}
+		allocation := &FootprintAllocation{
+			SourceID:       s.id,
+			MassInKg:       s.mass,
+			SourceMassInKg: parent.MassInKg,
+			CO2eGrams:      allocate(parent.CO2eGrams, s.mass, parent.MassInKg),
+			WaterLitres:    allocate(parent.WaterLitres, s.mass, parent.MassInKg),
+		}
+		footprint.Allocations = append(footprint.Allocations, allocation)
+		footprint.CO2eGrams += allocation.CO2eGrams
+		footprint.WaterLitres += allocation.WaterLitres
+		for stage, grams := range parent.ByStage {
+			footprint.ByStage[stage] += allocate(grams, s.mass, parent.MassInKg)
+		}
+	}
+
+	if kind != "retailUnit" {
+		record, err := c.readFootprintRecord(ctx, assetID)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range record.Entries {
+			footprint.Entries = append(footprint.Entries, entry)
+			footprint.CO2eGrams += entry.CO2eGrams
+			footprint.WaterLitres += entry.WaterLitres
+			footprint.ByStage[entry.Stage] += entry.CO2eGrams
+		}
+	}
+
+	if mass > 0 {
+		footprint.CO2eGramsPerKg = footprint.CO2eGrams / int64(mass)
+	}
+	return footprint, nil
+}
+
+// Helper: the share mass/sourceMass of total, rounded down. The product is
+// taken in 128 bits since footprints of large batches times their mass can
+// exceed int64; mass never exceeds sourceMass, so the share fits.
+func allocate(total int64, mass int, sourceMass int) int64 {
+	hi, lo := bits.Mul64(uint64(total), uint64(mass))
+	share, _ := bits.Div64(hi, lo, uint64(sourceMass))
+	return int64(share)
+}
@@ -0,0 +1,107 @@
+package contracts
+
+import (
+	"testing"
+
+	"ricetypes"
+)
+
+func farmBatch(batchID string) *ricetypes.CreateRiceBatchArgs {
+	return &ricetypes.CreateRiceBatchArgs{
+		BatchID:               batchID,
+		Variety:               "Basmati",
+		HarvestDate:           "2024-10-20",
+		QuantityInKg:          1000,
+		FarmerName:            "Ravi",
+		FieldID:               "FIELD1",
+		FieldEmissionsMethod:  "awd",
+		CultivatedAreaCentiHa: 100,
+		SeasonDays:            100,
+		WaterUsageM3:          10,
+		FertiliserNKg:         10,
+	}
+}
+
+func TestFarmInputsAreValidated(t *testing.T) {
+	h := newContractHarness(t)
+	h.harvest("B0", 100)
+	args := farmBatch("B1")
+	for key, value := range map[string]string{
+		ricetypes.TransientWaterUsageM3:          "ten",
+		ricetypes.TransientFertiliserNKg:         "1e3",
+		ricetypes.TransientFieldEmissionsMethod:  "terraced",
+		ricetypes.TransientCultivatedAreaCentiHa: "-1",
+		ricetypes.TransientSeasonDays:            "1001",
+	} {
+		transient := args.Transient()
+		transient[key] = []byte(value)
+		h.reject("Org1MSP", "CreateRiceBatch", args.Args(), transient, ricetypes.ErrCodeInvalidArgument)
+	}
+	args.WaterUsageM3 = maxFarmInputQuantity + 1
+	h.reject("Org1MSP", "CreateRiceBatch", args.Args(), args.Transient(), ricetypes.ErrCodeInvalidArgument)
+
+	measured := farmBatch("B1")
+	measured.FieldEmissionsMethod = "measured"
+	measured.FieldEmissionsKgCO2e = -1
+	h.reject("Org1MSP", "CreateRiceBatch", measured.Args(), measured.Transient(), ricetypes.ErrCodeInvalidArgument)
+	measured.FieldEmissionsKgCO2e = 500
+	h.submit("Org1MSP", measured)
+
+	var footprint Footprint
+	h.read("Org3MSP", "GetFootprint", []string{"B1"}, &footprint)
+	if footprint.CO2eGrams != 500000+17160 || footprint.WaterLitres != 10000 {
+		t.Errorf("measured farm footprint %d g CO2e, %d l water", footprint.CO2eGrams, footprint.WaterLitres)
+	}
+}
+
+func TestFootprintIsAllocatedByMass(t *testing.T) {
+	h := newContractHarness(t)
+	h.harvest("B0", 100)
+	h.submit("Org1MSP", farmBatch("B1"))
+
+	h.reject("Org2MSP", "RecordMillingEnergy", []string{"B1", "100", "0"}, nil, ricetypes.ErrCodeConflict)
+	h.mill("B1", "O1", 1000, ricetypes.SaleTerms{})
+	h.reject("Org1MSP", "RecordMillingEnergy", []string{"B1", "100", "0"}, nil, ricetypes.ErrCodeForbidden)
+	h.reject("Org2MSP", "RecordMillingEnergy", []string{"B1", "0", "0"}, nil, ricetypes.ErrCodeInvalidArgument)
+	h.reject("Org2MSP", "RecordMillingEnergy", []string{"B1", "1000000000001", "0"}, nil, ricetypes.ErrCodeInvalidArgument)
+	h.reject("Org2MSP", "RecordMillingEnergy", []string{"B1", "100", "10001"}, nil, ricetypes.ErrCodeInvalidArgument)
+	h.invoke("Org2MSP", "RecordMillingEnergy", []string{"B1", "100", "0"}, nil)
+
+	h.invoke("Org2MSP", "PackageBatch", []string{"R1", "B1", "5", "100", "2025-01-10", "2026-01-10"}, nil)
+	h.reject("Org1MSP", "RecordTransportEmissions", []string{"R1", "100", "road"}, nil, ricetypes.ErrCodeForbidden)
+	h.reject("Org2MSP", "RecordTransportEmissions", []string{"R1", "100", "air"}, nil, ricetypes.ErrCodeInvalidArgument)
+	h.reject("Org2MSP", "RecordTransportEmissions", []string{"R1", "0", "road"}, nil, ricetypes.ErrCodeInvalidArgument)
+	h.reject("Org2MSP", "RecordTransportEmissions", []string{"R1", "100001", "road"}, nil, ricetypes.ErrCodeInvalidArgument)
+	h.reject("Org2MSP", "RecordTransportEmissions", []string{"R1-000001", "100", "road"}, nil, ricetypes.ErrCodeInvalidArgument)
+	h.reject("Org2MSP", "RecordTransportEmissions", []string{"FIELD1", "100", "road"}, nil, ricetypes.ErrCodeInvalidArgument)
+	h.reject("Org2MSP", "RecordTransportEmissions", []string{"R9", "100", "road"}, nil, ricetypes.ErrCodeNotFound)
+	h.invoke("Org2MSP", "RecordTransportEmissions", []string{"R1", "100", "road"}, nil)
+
+	// Field methane 100 centi-ha * 1190 * 100 days * 550 per mille * 27 / 100000,
+	// fertiliser 10 kg N * 1716 and milling 100 kWh * 716
+	batchGrams := int64(1767150 + 17160 + 71600)
+	var batch Footprint
+	h.read("Org3MSP", "GetFootprint", []string{"B1"}, &batch)
+	if batch.CO2eGrams != batchGrams || batch.ByStage["milling"] != 71600 || batch.WaterLitres != 10000 {
+		t.Errorf("batch footprint %+v", batch)
+	}
+
+	var run Footprint
+	h.read("Org3MSP", "GetFootprint", []string{"R1"}, &run)
+	runGrams := batchGrams/2 + 5250
+	if run.CO2eGrams != runGrams || len(run.Allocations) != 1 || run.Allocations[0].MassInKg != 500 || run.WaterLitres != 5000 {
+		t.Errorf("run footprint %+v", run)
+	}
+
+	var unit Footprint
+	h.read("Org3MSP", "GetFootprint", []string{"R1-000001"}, &unit)
+	if unit.Kind != "retailUnit" || unit.CO2eGrams != runGrams*5/500 || unit.CO2eGramsPerKg != runGrams*5/500/5 {
+		t.Errorf("unit footprint %+v", unit)
+	}
+}
+
+func TestAllocateAvoidsOverflow(t *testing.T) {
+	if share := allocate(1<<62, 3, 4); share != 3<<60 {
+		t.Errorf("allocate(2^62, 3, 4) = %d", share)
+	}
+}
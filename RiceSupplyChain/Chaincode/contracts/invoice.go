@@ -0,0 +1,281 @@
+package contracts
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+
+	"ricetypes"
+)
+
+type (
+	Invoice       = ricetypes.Invoice
+	InvoiceStatus = ricetypes.InvoiceStatus
+	Payment       = ricetypes.Payment
+)
+
+// Helper: private collection shared by the payer and payee of an invoice
+func invoiceCollection(payer string, payee string) (string, error) {
+	switch {
+	case payer == "Org2MSP" && payee == "Org1MSP":
+		return "FarmerMillerInvoiceCollection", nil
+	case payer == "Org3MSP" && payee == "Org2MSP":
+		return "MillerRetailerInvoiceCollection", nil
+	}
+	return "", invalidArgumentError("no invoice collection is shared by %v and %v", payer, payee)
+}
+
+// issueInvoice computes the invoice totals, keeps them in the collection of payer and
+// payee and publishes only the invoice status
+func (c *RiceContract) issueInvoice(ctx contractapi.TransactionContextInterface, invoiceID string, batchID string, orderID string, payer string, payee string, quantityInKg int, unitPrice int64, taxRateBps int64, currency string) (*Invoice, error) {
+	if currency == "" {
+		return nil, invalidArgumentError("currency is required for priced invoice %s", invoiceID)
+	}
+	if taxRateBps < 0 {
+		return nil, invalidArgumentError("tax rate cannot be negative")
+	}
+	collection, err := invoiceCollection(payer, payee)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := ctx.GetStub().GetState(invoiceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if existing != nil {
+		return nil, conflictError("the invoice %s already exists", invoiceID)
+	}
+
+	subtotal := int64(quantityInKg) * unitPrice
+	// Round half up to the nearest minor unit
+	tax := (subtotal*taxRateBps + 5000) / 10000
+
+	invoice := Invoice{
+		AssetType:    "invoice",
+		InvoiceID:    invoiceID,
+		BatchID:      batchID,
+		OrderID:      orderID,
+		Payer:        payer,
+		Payee:        payee,
+		QuantityInKg: quantityInKg,
+		UnitPrice:    unitPrice,
+		Subtotal:     subtotal,
+		TaxRateBps:   taxRateBps,
+		TaxAmount:    tax,
+		Total:        subtotal + tax,
+		Currency:     currency,
+		Status:       "Issued",
+		IssuedAt:     txTimestamp(ctx),
+		Payments:     []*Payment{},
+	}
+	return &invoice, putInvoice(ctx, collection, &invoice)
+}
+
+// putInvoice writes the priced invoice to its private collection and mirrors its status publicly
+func putInvoice(ctx contractapi.TransactionContextInterface, collection string, invoice *Invoice) error {
+	bytes, _ := json.Marshal(invoice)
+	err := ctx.GetStub().PutPrivateData(collection, invoice.InvoiceID, bytes)
+	if err != nil {
+		return err
+	}
+	return putInvoiceStatus(ctx, &InvoiceStatus{
+		AssetType:  "invoiceStatus",
+		InvoiceID:  invoice.InvoiceID,
+		BatchID:    invoice.BatchID,
+		OrderID:    invoice.OrderID,
+		Payer:      invoice.Payer,
+		Payee:      invoice.Payee,
+		Collection: collection,
+		Status:     invoice.Status,
+		IssuedAt:   invoice.IssuedAt,
+	})
+}
+
+func putInvoiceStatus(ctx contractapi.TransactionContextInterface, status *InvoiceStatus) error {
+	bytes, _ := json.Marshal(status)
+	return ctx.GetStub().PutState(status.InvoiceID, bytes)
+}
+
+// ReadInvoiceStatus retrieves the public status of an invoice
+func (c *RiceContract) ReadInvoiceStatus(ctx contractapi.TransactionContextInterface, invoiceID string) (*InvoiceStatus, error) {
+	bytes, err := ctx.GetStub().GetState(invoiceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if bytes == nil {
+		return nil, notFoundError("the invoice %s does not exist", invoiceID)
+	}
+
+	var status InvoiceStatus
+	err = json.Unmarshal(bytes, &status)
+	if err != nil {
+		return nil, fmt.Errorf("could not unmarshal world state data to type InvoiceStatus")
+	}
+	if status.AssetType != "invoiceStatus" {
+		return nil, notFoundError("the invoice %s does not exist", invoiceID)
+	}
+	return &status, nil
+}
+
+// ReadInvoice retrieves a priced invoice, readable only by its payer and payee
+func (c *RiceContract) ReadInvoice(ctx contractapi.TransactionContextInterface, invoiceID string) (*Invoice, error) {
+	status, err := c.ReadInvoiceStatus(ctx, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	bytes, err := ctx.GetStub().GetPrivateData(status.Collection, invoiceID)
+	if err != nil || bytes == nil {
+		return nil, notFoundError("the invoice %s does not exist or cannot be read", invoiceID)
+	}
+
+	var invoice Invoice
+	err = json.Unmarshal(bytes, &invoice)
+	if err != nil {
+		return nil, fmt.Errorf("could not unmarshal private data to type Invoice")
+	}
+	if invoice.AssetType != "invoice" {
+		return nil, notFoundError("the invoice %s does not exist", invoiceID)
+	}
+	// Escrow settlement runs as the retailer, which cannot write the private
+	// invoice, so the public status is authoritative
+	invoice.Status = status.Status
+	if status.Status == "Paid" {
+		invoice.AmountPaid = invoice.Total
+	}
+	return &invoice, nil
+}
+
+// RecordPayment lets the payer declare a payment against an invoice
+func (c *RiceContract) RecordPayment(ctx contractapi.TransactionContextInterface, invoiceID string, paymentRef string, amount int64) (string, error) {
+	clientOrgID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return "", err
+	}
+
+	invoice, err := c.ReadInvoice(ctx, invoiceID)
+	if err != nil {
+		return "", err
+	}
+	if clientOrgID != invoice.Payer {
+		return "", forbiddenError("only the payer %v can record payments on invoice %v", invoice.Payer, invoiceID)
+	}
+	if amount <= 0 {
+		return "", invalidArgumentError("payment amount must be positive")
+	}
+	if invoice.Status == "Paid" || invoice.Status == "Cancelled" {
+		return "", conflictError("invoice %v is already %v", invoiceID, invoice.Status)
+	}
+	escrowed, err := escrowedInvoice(ctx, invoice)
+	if err != nil {
+		return "", err
+	}
+	if escrowed {
+		return "", conflictError("invoice %v is paid from escrow when delivery is accepted", invoiceID)
+	}
+
+	var recorded int64
+	for _, payment := range invoice.Payments {
+		if payment.PaymentRef == paymentRef {
+			return "", conflictError("payment %v already recorded on invoice %v", paymentRef, invoiceID)
+		}
+		recorded += payment.Amount
+	}
+	if recorded+amount > invoice.Total {
+		return "", invalidArgumentError("payment of %d exceeds outstanding amount %d", amount, invoice.Total-recorded)
+	}
+
+	invoice.Payments = append(invoice.Payments, &Payment{
+		PaymentRef: paymentRef,
+		Amount:     amount,
+		RecordedAt: txTimestamp(ctx),
+	})
+
+	collection, _ := invoiceCollection(invoice.Payer, invoice.Payee)
+	return fmt.Sprintf("payment %v recorded on invoice %v", paymentRef, invoiceID), putInvoice(ctx, collection, invoice)
+}
+
+// ConfirmPayment lets the payee acknowledge receipt of a recorded payment
+func (c *RiceContract) ConfirmPayment(ctx contractapi.TransactionContextInterface, invoiceID string, paymentRef string) (string, error) {
+	clientOrgID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return "", err
+	}
+
+	invoice, err := c.ReadInvoice(ctx, invoiceID)
+	if err != nil {
+		return "", err
+	}
+	if clientOrgID != invoice.Payee {
+		return "", forbiddenError("only the payee %v can confirm payments on invoice %v", invoice.Payee, invoiceID)
+	}
+
+	var payment *Payment
+	for _, p := range invoice.Payments {
+		if p.PaymentRef == paymentRef {
+			payment = p
+		}
+	}
+	if payment == nil {
+		return "", notFoundError("payment %v not found on invoice %v", paymentRef, invoiceID)
+	}
+	if payment.Confirmed {
+		return "", conflictError("payment %v already confirmed", paymentRef)
+	}
+
+	payment.Confirmed = true
+	payment.ConfirmedAt = txTimestamp(ctx)
+	invoice.AmountPaid += payment.Amount
+	if invoice.AmountPaid >= invoice.Total {
+		invoice.Status = "Paid"
+	} else {
+		invoice.Status = "PartiallyPaid"
+	}
+
+	collection, _ := invoiceCollection(invoice.Payer, invoice.Payee)
+	return fmt.Sprintf("payment %v confirmed, invoice %v is %v", paymentRef, invoiceID, invoice.Status), putInvoice(ctx, collection, invoice)
+}
+
+// GetOutstandingReceivables returns the unpaid invoices owed to the caller's org
+func (c *RiceContract) GetOutstandingReceivables(ctx contractapi.TransactionContextInterface) ([]*Invoice, error) {
+	clientOrgID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return nil, err
+	}
+
+	queryString := richQuery(map[string]interface{}{
+		"assetType": "invoiceStatus",
+		"payee":     clientOrgID,
+		"status":    map[string]interface{}{"$nin": []string{"Paid", "Cancelled"}},
+	})
+	resultsIterator, err := ctx.GetStub().GetQueryResult(queryString)
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	var invoices []*Invoice
+	for resultsIterator.HasNext() {
+		queryResult, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		invoice, err := c.ReadInvoice(ctx, queryResult.Key)
+		if err != nil {
+			return nil, err
+		}
+		invoices = append(invoices, invoice)
+	}
+	return invoices, nil
+}
+
+// Helper: transaction timestamp, identical on every endorsing peer
+func txTimestamp(ctx contractapi.TransactionContextInterface) string {
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return ""
+	}
+	return timestamp.AsTime().UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,101 @@
+package contracts
+
+import (
+	"encoding/json"
+	"testing"
+
+	"ricetypes"
+)
+
+func TestInvoicePricesStayInTheCollection(t *testing.T) {
+	h := newContractHarness(t)
+	h.harvest("B1", 1000)
+	h.mill("B1", "O1", 600, ricetypes.SaleTerms{UnitPrice: 100, Currency: "INR", TaxRateBps: 500})
+
+	var public map[string]interface{}
+	if err := json.Unmarshal(h.stub.state["INV-B1-O1"], &public); err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range []string{"quantityInKg", "unitPrice", "subtotal", "taxAmount", "total", "payments"} {
+		if _, ok := public[field]; ok {
+			t.Errorf("public invoice status carries %s: %v", field, public)
+		}
+	}
+
+	var invoice Invoice
+	h.read("Org1MSP", "ReadInvoice", []string{"INV-B1-O1"}, &invoice)
+	if invoice.Subtotal != 60000 || invoice.TaxAmount != 3000 || invoice.Total != 63000 || invoice.Status != "Issued" {
+		t.Errorf("invoice totals %d + %d = %d, status %s", invoice.Subtotal, invoice.TaxAmount, invoice.Total, invoice.Status)
+	}
+
+	h.reject("Org3MSP", "ReadInvoice", []string{"INV-B1-O1"}, nil, ricetypes.ErrCodeNotFound)
+	var status InvoiceStatus
+	h.read("Org3MSP", "ReadInvoiceStatus", []string{"INV-B1-O1"}, &status)
+	if status.Payer != "Org2MSP" || status.Payee != "Org1MSP" || status.Status != "Issued" {
+		t.Errorf("invoice status %+v", status)
+	}
+
+	h.reject("Org1MSP", "ReadInvoice", []string{"B1"}, nil, ricetypes.ErrCodeNotFound)
+	h.reject("Org1MSP", "ReadRiceBatch", []string{"INV-B1-O1"}, nil, ricetypes.ErrCodeNotFound)
+}
+
+func TestInvoicePayments(t *testing.T) {
+	h := newContractHarness(t)
+	h.harvest("B1", 1000)
+	h.mill("B1", "O1", 600, ricetypes.SaleTerms{UnitPrice: 100, Currency: "INR", TaxRateBps: 500})
+
+	var receivables []*Invoice
+	h.read("Org1MSP", "GetOutstandingReceivables", nil, &receivables)
+	if len(receivables) != 1 || receivables[0].InvoiceID != "INV-B1-O1" {
+		t.Fatalf("receivables before payment: %+v", receivables)
+	}
+
+	h.reject("Org1MSP", "RecordPayment", []string{"INV-B1-O1", "P1", "30000"}, nil, ricetypes.ErrCodeForbidden)
+	h.reject("Org2MSP", "RecordPayment", []string{"INV-B1-O1", "P1", "0"}, nil, ricetypes.ErrCodeInvalidArgument)
+	h.reject("Org2MSP", "RecordPayment", []string{"INV-B1-O1", "P1", "63001"}, nil, ricetypes.ErrCodeInvalidArgument)
+
+	h.invoke("Org2MSP", "RecordPayment", []string{"INV-B1-O1", "P1", "30000"}, nil)
+	h.reject("Org2MSP", "RecordPayment", []string{"INV-B1-O1", "P1", "1000"}, nil, ricetypes.ErrCodeConflict)
+	h.reject("Org2MSP", "ConfirmPayment", []string{"INV-B1-O1", "P1"}, nil, ricetypes.ErrCodeForbidden)
+	h.invoke("Org1MSP", "ConfirmPayment", []string{"INV-B1-O1", "P1"}, nil)
+	h.reject("Org1MSP", "ConfirmPayment", []string{"INV-B1-O1", "P1"}, nil, ricetypes.ErrCodeConflict)
+
+	var invoice Invoice
+	h.read("Org1MSP", "ReadInvoice", []string{"INV-B1-O1"}, &invoice)
+	if invoice.Status != "PartiallyPaid" || invoice.AmountPaid != 30000 {
+		t.Errorf("after first payment: %s, paid %d", invoice.Status, invoice.AmountPaid)
+	}
+
+	h.invoke("Org2MSP", "RecordPayment", []string{"INV-B1-O1", "P2", "33000"}, nil)
+	h.invoke("Org1MSP", "ConfirmPayment", []string{"INV-B1-O1", "P2"}, nil)
+	var status InvoiceStatus
+	h.read("Org3MSP", "ReadInvoiceStatus", []string{"INV-B1-O1"}, &status)
+	if status.Status != "Paid" {
+		t.Errorf("public status after full payment: %s", status.Status)
+	}
+	h.reject("Org2MSP", "RecordPayment", []string{"INV-B1-O1", "P3", "1"}, nil, ricetypes.ErrCodeConflict)
+
+	receivables = nil
+	h.read("Org1MSP", "GetOutstandingReceivables", nil, &receivables)
+	if len(receivables) != 0 {
+		t.Errorf("receivables after payment: %+v", receivables)
+	}
+}
+
+func TestInvoiceRejectsDuplicatesAndMissingCurrency(t *testing.T) {
+	h := newContractHarness(t)
+	h.harvest("B1", 1000)
+	h.submit("Org2MSP", &ricetypes.CreateProcessingOrderArgs{
+		OrderID: "O1", Variety: "Basmati", MillerName: "Mill Co", QuantityInKg: 600,
+		SaleTerms: ricetypes.SaleTerms{UnitPrice: 100, TaxRateBps: 500},
+	})
+	h.reject("Org2MSP", "MatchProcessingOrder", []string{"B1", "O1"}, nil, ricetypes.ErrCodeInvalidArgument)
+
+	dispatch := &ricetypes.DispatchToRetailerArgs{
+		BatchID:      "B1",
+		RetailerName: "Fresh Mart",
+		SaleTerms:    ricetypes.SaleTerms{UnitPrice: 120, Currency: "INR"},
+	}
+	h.submit("Org3MSP", dispatch)
+	h.reject("Org3MSP", dispatch.Function(), dispatch.Args(), dispatch.Transient(), ricetypes.ErrCodeConflict)
+}
@@ -0,0 +1,488 @@
+package contracts
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/v2/shim"
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+	"github.com/hyperledger/fabric-protos-go-apiv2/ledger/queryresult"
+	"github.com/hyperledger/fabric-protos-go-apiv2/msp"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"ricetypes"
+)
+
+// mockStub keeps world state, private data and events in memory. Methods the
+// contract does not use panic through the nil embedded interface.
+type mockStub struct {
+	shim.ChaincodeStubInterface
+	function  string
+	args      []string
+	creator   []byte
+	transient map[string][]byte
+	state     map[string][]byte
+	private   map[string]map[string][]byte
+	events    map[string][]byte
+	history   map[string][]*queryresult.KeyModification
+	// members of each collection in collections_config.json, enforced as memberOnlyRead
+	collections map[string][]string
+}
+
+func newMockStub() *mockStub {
+	return &mockStub{
+		state:       make(map[string][]byte),
+		private:     make(map[string]map[string][]byte),
+		events:      make(map[string][]byte),
+		history:     make(map[string][]*queryresult.KeyModification),
+		collections: make(map[string][]string),
+	}
+}
+
+// snapshot returns a function that puts the ledger back as it was, so a
+// failed transaction leaves no writes behind as on a peer
+func (s *mockStub) snapshot() func() {
+	state := copyValues(s.state)
+	private := make(map[string]map[string][]byte, len(s.private))
+	for collection, values := range s.private {
+		private[collection] = copyValues(values)
+	}
+	events := copyValues(s.events)
+	history := make(map[string][]*queryresult.KeyModification, len(s.history))
+	for key, modifications := range s.history {
+		history[key] = modifications
+	}
+	return func() {
+		s.state, s.private, s.events, s.history = state, private, events, history
+	}
+}
+
+func copyValues(values map[string][]byte) map[string][]byte {
+	copied := make(map[string][]byte, len(values))
+	for key, value := range values {
+		copied[key] = value
+	}
+	return copied
+}
+
+func (s *mockStub) GetFunctionAndParameters() (string, []string) { return s.function, s.args }
+func (s *mockStub) GetCreator() ([]byte, error)                  { return s.creator, nil }
+func (s *mockStub) GetTransient() (map[string][]byte, error)     { return s.transient, nil }
+func (s *mockStub) GetTxID() string                              { return "tx1" }
+
+func (s *mockStub) GetTxTimestamp() (*timestamppb.Timestamp, error) {
+	return timestamppb.New(time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)), nil
+}
+
+func (s *mockStub) GetState(key string) ([]byte, error) { return s.state[key], nil }
+
+func (s *mockStub) PutState(key string, value []byte) error {
+	s.state[key] = value
+	s.recordHistory(key, value, false)
+	return nil
+}
+
+func (s *mockStub) DelState(key string) error {
+	delete(s.state, key)
+	s.recordHistory(key, nil, true)
+	return nil
+}
+
+func (s *mockStub) recordHistory(key string, value []byte, isDelete bool) {
+	timestamp, _ := s.GetTxTimestamp()
+	s.history[key] = append(s.history[key], &queryresult.KeyModification{
+		TxId:      s.GetTxID(),
+		Value:     value,
+		Timestamp: timestamp,
+		IsDelete:  isDelete,
+	})
+}
+
+// GetHistoryForKey returns the writes to key, newest first like the peer
+func (s *mockStub) GetHistoryForKey(key string) (shim.HistoryQueryIteratorInterface, error) {
+	iterator := &mockHistoryIterator{}
+	for i := len(s.history[key]) - 1; i >= 0; i-- {
+		iterator.results = append(iterator.results, s.history[key][i])
+	}
+	return iterator, nil
+}
+
+// checkCollection fails like the peer does for unknown collections and, when
+// reading, for callers outside the collection
+func (s *mockStub) checkCollection(collection string, read bool) error {
+	members, ok := s.collections[collection]
+	if !ok {
+		return fmt.Errorf("collection %s is not defined", collection)
+	}
+	if !read {
+		return nil
+	}
+	var identity msp.SerializedIdentity
+	if err := proto.Unmarshal(s.creator, &identity); err != nil {
+		return err
+	}
+	for _, member := range members {
+		if member == identity.Mspid {
+			return nil
+		}
+	}
+	return fmt.Errorf("tx creator does not have read access permission on privatedata in collection %s", collection)
+}
+
+func (s *mockStub) GetPrivateData(collection string, key string) ([]byte, error) {
+	if err := s.checkCollection(collection, true); err != nil {
+		return nil, err
+	}
+	return s.private[collection][key], nil
+}
+
+func (s *mockStub) PutPrivateData(collection string, key string, value []byte) error {
+	if err := s.checkCollection(collection, false); err != nil {
+		return err
+	}
+	if s.private[collection] == nil {
+		s.private[collection] = make(map[string][]byte)
+	}
+	s.private[collection][key] = value
+	return nil
+}
+
+func (s *mockStub) GetPrivateDataHash(collection string, key string) ([]byte, error) {
+	if err := s.checkCollection(collection, false); err != nil {
+		return nil, err
+	}
+	value, ok := s.private[collection][key]
+	if !ok {
+		return nil, nil
+	}
+	hash := sha256.Sum256(value)
+	return hash[:], nil
+}
+
+func (s *mockStub) DelPrivateData(collection string, key string) error {
+	if err := s.checkCollection(collection, false); err != nil {
+		return err
+	}
+	delete(s.private[collection], key)
+	return nil
+}
+
+func (s *mockStub) SetEvent(name string, payload []byte) error {
+	s.events[name] = payload
+	return nil
+}
+
+// GetQueryResult evaluates the equality, $ne and $nin clauses the contract's
+// selectors use. Sort is ignored and results come back in key order.
+func (s *mockStub) GetQueryResult(query string) (shim.StateQueryIteratorInterface, error) {
+	var parsed struct {
+		Selector map[string]interface{} `json:"selector"`
+	}
+	if err := json.Unmarshal([]byte(query), &parsed); err != nil {
+		return nil, fmt.Errorf("invalid query %s: %v", query, err)
+	}
+	iterator := &mockIterator{}
+	for key, value := range s.state {
+		var document map[string]interface{}
+		if json.Unmarshal(value, &document) == nil && selectorMatches(document, parsed.Selector) {
+			iterator.results = append(iterator.results, &queryresult.KV{Key: key, Value: value})
+		}
+	}
+	sort.Slice(iterator.results, func(i, j int) bool { return iterator.results[i].Key < iterator.results[j].Key })
+	return iterator, nil
+}
+
+func selectorMatches(document map[string]interface{}, selector map[string]interface{}) bool {
+	for field, want := range selector {
+		got, present := document[field]
+		operators, ok := want.(map[string]interface{})
+		if !ok {
+			if !present || !reflect.DeepEqual(got, want) {
+				return false
+			}
+			continue
+		}
+		if ne, ok := operators["$ne"]; ok && reflect.DeepEqual(got, ne) {
+			return false
+		}
+		if nin, ok := operators["$nin"].([]interface{}); ok {
+			for _, excluded := range nin {
+				if reflect.DeepEqual(got, excluded) {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+func (s *mockStub) CreateCompositeKey(objectType string, attributes []string) (string, error) {
+	return "\x00" + objectType + "\x00" + strings.Join(append(attributes, ""), "\x00"), nil
+}
+
+func (s *mockStub) SplitCompositeKey(key string) (string, []string, error) {
+	parts := strings.Split(strings.TrimSuffix(strings.TrimPrefix(key, "\x00"), "\x00"), "\x00")
+	return parts[0], parts[1:], nil
+}
+
+func (s *mockStub) GetStateByPartialCompositeKey(objectType string, attributes []string) (shim.StateQueryIteratorInterface, error) {
+	prefix, _ := s.CreateCompositeKey(objectType, attributes)
+	iterator := &mockIterator{}
+	for key, value := range s.state {
+		if strings.HasPrefix(key, prefix) {
+			iterator.results = append(iterator.results, &queryresult.KV{Key: key, Value: value})
+		}
+	}
+	sort.Slice(iterator.results, func(i, j int) bool { return iterator.results[i].Key < iterator.results[j].Key })
+	return iterator, nil
+}
+
+type mockIterator struct {
+	results []*queryresult.KV
+}
+
+func (i *mockIterator) HasNext() bool { return len(i.results) > 0 }
+func (i *mockIterator) Close() error  { return nil }
+
+func (i *mockIterator) Next() (*queryresult.KV, error) {
+	next := i.results[0]
+	i.results = i.results[1:]
+	return next, nil
+}
+
+type mockHistoryIterator struct {
+	results []*queryresult.KeyModification
+}
+
+func (i *mockHistoryIterator) HasNext() bool { return len(i.results) > 0 }
+func (i *mockHistoryIterator) Close() error  { return nil }
+
+func (i *mockHistoryIterator) Next() (*queryresult.KeyModification, error) {
+	next := i.results[0]
+	i.results = i.results[1:]
+	return next, nil
+}
+
+// creatorFor serializes a throwaway certificate as an identity of mspID,
+// carrying attrs the way Fabric CA embeds them
+func creatorFor(t *testing.T, mspID string, attrs map[string]string) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "user1", Organization: []string{mspID}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	if len(attrs) > 0 {
+		value, _ := json.Marshal(map[string]interface{}{"attrs": attrs})
+		template.ExtraExtensions = []pkix.Extension{{Id: asn1.ObjectIdentifier{1, 2, 3, 4, 5, 6, 7, 8, 1}, Value: value}}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	creator, err := proto.Marshal(&msp.SerializedIdentity{
+		Mspid:   mspID,
+		IdBytes: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return creator
+}
+
+// loadCollections reads collection membership from the deployed collections config
+func loadCollections(t *testing.T) map[string][]string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("..", "collections_config.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var configs []struct {
+		Name   string `json:"name"`
+		Policy string `json:"policy"`
+	}
+	if err := json.Unmarshal(data, &configs); err != nil {
+		t.Fatal(err)
+	}
+	member := regexp.MustCompile(`'(\w+)\.member'`)
+	collections := make(map[string][]string)
+	for _, config := range configs {
+		for _, match := range member.FindAllStringSubmatch(config.Policy, -1) {
+			collections[config.Name] = append(collections[config.Name], match[1])
+		}
+	}
+	return collections
+}
+
+type contractHarness struct {
+	t         *testing.T
+	chaincode *contractapi.ContractChaincode
+	stub      *mockStub
+	creators  map[string][]byte
+}
+
+// Building the contract metadata takes most of a second, so tests share one
+// chaincode; all state lives in each harness's stub
+var (
+	sharedChaincode     *contractapi.ContractChaincode
+	sharedChaincodeErr  error
+	sharedChaincodeOnce sync.Once
+)
+
+func newContractHarness(t *testing.T) *contractHarness {
+	sharedChaincodeOnce.Do(func() {
+		sharedChaincode, sharedChaincodeErr = contractapi.NewChaincode(&RiceContract{})
+	})
+	chaincode, err := sharedChaincode, sharedChaincodeErr
+	if err != nil {
+		t.Fatal(err)
+	}
+	stub := newMockStub()
+	stub.collections = loadCollections(t)
+	return &contractHarness{
+		t:         t,
+		chaincode: chaincode,
+		stub:      stub,
+		creators: map[string][]byte{
+			"Org1MSP": creatorFor(t, "Org1MSP", nil),
+			"Org2MSP": creatorFor(t, "Org2MSP", nil),
+			"Org3MSP": creatorFor(t, "Org3MSP", nil),
+		},
+	}
+}
+
+// identity registers a caller with certificate attributes under name
+func (h *contractHarness) identity(name string, mspID string, attrs map[string]string) {
+	h.creators[name] = creatorFor(h.t, mspID, attrs)
+}
+
+func (h *contractHarness) call(caller string, function string, args []string, transient map[string][]byte) *peer.Response {
+	h.t.Helper()
+	creator, ok := h.creators[caller]
+	if !ok {
+		h.t.Fatalf("unknown caller %s", caller)
+	}
+	h.stub.function, h.stub.args, h.stub.transient = function, args, transient
+	h.stub.creator = creator
+	restore := h.stub.snapshot()
+	response := h.chaincode.Invoke(h.stub)
+	if response.Status != shim.OK {
+		restore()
+	}
+	return response
+}
+
+// invoke runs a transaction that must succeed and returns its payload
+func (h *contractHarness) invoke(caller string, function string, args []string, transient map[string][]byte) []byte {
+	h.t.Helper()
+	response := h.call(caller, function, args, transient)
+	if response.Status != shim.OK {
+		h.t.Fatalf("%s as %s: %s", function, caller, response.Message)
+	}
+	return response.Payload
+}
+
+// reject runs a transaction that must fail with the given error code
+func (h *contractHarness) reject(caller string, function string, args []string, transient map[string][]byte, code string) {
+	h.t.Helper()
+	response := h.call(caller, function, args, transient)
+	if response.Status == shim.OK {
+		h.t.Fatalf("%s as %s succeeded, want %s", function, caller, code)
+	}
+	if !strings.HasPrefix(response.Message, code+": ") {
+		h.t.Errorf("%s as %s: message %q does not start with %s", function, caller, response.Message, code)
+	}
+}
+
+// read invokes an evaluate-style transaction and decodes its payload into v;
+// an empty payload, returned for nil results, leaves v untouched
+func (h *contractHarness) read(caller string, function string, args []string, v interface{}) {
+	h.t.Helper()
+	payload := h.invoke(caller, function, args, nil)
+	if len(payload) == 0 {
+		return
+	}
+	if err := json.Unmarshal(payload, v); err != nil {
+		h.t.Fatalf("%s: %v", function, err)
+	}
+}
+
+func (h *contractHarness) submit(caller string, args ricetypes.TransactionArgs) {
+	h.t.Helper()
+	h.invoke(caller, args.Function(), args.Args(), args.Transient())
+}
+
+func (h *contractHarness) batch(batchID string) *RiceBatch {
+	h.t.Helper()
+	var batch RiceBatch
+	if err := json.Unmarshal(h.stub.state[batchID], &batch); err != nil {
+		h.t.Fatalf("batch %s: %v", batchID, err)
+	}
+	return &batch
+}
+
+func (h *contractHarness) event(name string) BatchEvent {
+	h.t.Helper()
+	var event BatchEvent
+	if err := json.Unmarshal(h.stub.events[name], &event); err != nil {
+		h.t.Fatalf("event %s: %v", name, err)
+	}
+	return event
+}
+
+// harvest registers FARM1 and FIELD1 on first use and records a Basmati batch
+// grown on the field
+func (h *contractHarness) harvest(batchID string, quantityInKg int) {
+	h.t.Helper()
+	if h.stub.state["FIELD1"] == nil {
+		h.invoke("Org1MSP", "RegisterFarm", []string{"FARM1", "Green Acres", "Ravi", "Punjab"}, nil)
+		boundary := `{"type":"Polygon","coordinates":[[[75.0,30.0],[75.01,30.0],[75.01,30.01],[75.0,30.01],[75.0,30.0]]]}`
+		h.invoke("Org1MSP", "RegisterField", []string{"FIELD1", "FARM1", "North", boundary, "10707", "2024-kharif"}, nil)
+	}
+	h.submit("Org1MSP", &ricetypes.CreateRiceBatchArgs{
+		BatchID:      batchID,
+		Variety:      "Basmati",
+		HarvestDate:  "2024-10-20",
+		QuantityInKg: quantityInKg,
+		FarmerName:   "Ravi",
+		FieldID:      "FIELD1",
+	})
+}
+
+// mill prices an order for the batch and matches it, raising INV-<batch>-<order>
+func (h *contractHarness) mill(batchID string, orderID string, quantityInKg int, terms ricetypes.SaleTerms) {
+	h.t.Helper()
+	h.submit("Org2MSP", &ricetypes.CreateProcessingOrderArgs{
+		OrderID:      orderID,
+		Variety:      "Basmati",
+		MillerName:   "Mill Co",
+		QuantityInKg: quantityInKg,
+		SaleTerms:    terms,
+	})
+	h.submit("Org2MSP", &ricetypes.MatchProcessingOrderArgs{BatchID: batchID, OrderID: orderID})
+}
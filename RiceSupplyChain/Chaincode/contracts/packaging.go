@@ -0,0 +1,197 @@
+package contracts
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+
+	"ricetypes"
+)
+
+type (
+	PackagingRun         = ricetypes.PackagingRun
+	RetailUnitProvenance = ricetypes.RetailUnitProvenance
+)
+
+const serialDigits = 6
+
+func retailSerial(runID string, unit int) string {
+	return fmt.Sprintf("%s-%0*d", runID, serialDigits, unit)
+}
+
+// PackageBatch packs a milled batch into serialised retail units (only by miller)
+func (c *RiceContract) PackageBatch(ctx contractapi.TransactionContextInterface, runID string, batchID string, packSizeKg int, unitCount int, packDate string, bestBefore string) (string, error) {
+	clientOrgID, _ := ctx.GetClientIdentity().GetMSPID()
+	if clientOrgID != "Org2MSP" {
+		return "", forbiddenError("Only Org2MSP (Miller) can package batches")
+	}
+
+	if strings.Contains(runID, "-") || runID == "" {
+		return "", invalidArgumentError("run ID %q must be non-empty and cannot contain '-'", runID)
+	}
+	existing, err := ctx.GetStub().GetState(runID)
+	if err != nil {
+		return "", fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if existing != nil {
+		return "", conflictError("the packaging run %s already exists", runID)
+	}
+
+	batch, err := c.ReadRiceBatch(ctx, batchID)
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(batch.Status, "Assigned to Miller") {
+		return "", invalidArgumentError("batch %v must be milled before packaging, status: %v", batchID, batch.Status)
+	}
+	if packSizeKg <= 0 || unitCount <= 0 {
+		return "", invalidArgumentError("pack size and unit count must be positive")
+	}
+	if unitCount >= 1000000 {
+		return "", invalidArgumentError("a packaging run is limited to 999999 units")
+	}
+
+	// The packed total lives on the batch, so concurrent runs conflict on it
+	left := batch.QuantityInKg - batch.PackedInKg
+	if packSizeKg > left || packSizeKg*unitCount > left {
+		return "", conflictError("batch %v has %d kg left to pack, run needs %d kg", batchID, left, packSizeKg*unitCount)
+	}
+
+	pack, err := time.Parse("2006-01-02", packDate)
+	if err != nil {
+		return "", invalidArgumentError("packDate must be YYYY-MM-DD: %v", err)
+	}
+	best, err := time.Parse("2006-01-02", bestBefore)
+	if err != nil {
+		return "", invalidArgumentError("bestBefore must be YYYY-MM-DD: %v", err)
+	}
+	if !best.After(pack) {
+		return "", invalidArgumentError("bestBefore must be after packDate")
+	}
+
+	run := PackagingRun{
+		AssetType:   "packagingRun",
+		RunID:       runID,
+		BatchID:     batchID,
+		PackSizeKg:  packSizeKg,
+		UnitCount:   unitCount,
+		FirstSerial: retailSerial(runID, 1),
+		LastSerial:  retailSerial(runID, unitCount),
+		PackDate:    packDate,
+		BestBefore:  bestBefore,
+		PackedBy:    clientOrgID,
+	}
+	batch.PackedInKg += packSizeKg * unitCount
+	bytes, _ := json.Marshal(batch)
+	err = ctx.GetStub().PutState(batchID, bytes)
+	if err != nil {
+		return "", err
+	}
+
+	bytes, _ = json.Marshal(run)
+	return fmt.Sprintf("packaged %d units %v to %v", unitCount, run.FirstSerial, run.LastSerial), ctx.GetStub().PutState(runID, bytes)
+}
+
+// ReadPackagingRun retrieves a packaging run from world state
+func (c *RiceContract) ReadPackagingRun(ctx contractapi.TransactionContextInterface, runID string) (*PackagingRun, error) {
+	bytes, err := ctx.GetStub().GetState(runID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if bytes == nil {
+		return nil, notFoundError("the packaging run %s does not exist", runID)
+	}
+
+	var run PackagingRun
+	err = json.Unmarshal(bytes, &run)
+	if err != nil {
+		return nil, fmt.Errorf("could not unmarshal world state data to type PackagingRun")
+	}
+	if run.AssetType != "packagingRun" {
+		return nil, notFoundError("the packaging run %s does not exist", runID)
+	}
+	return &run, nil
+}
+
+// GetPackagingRuns returns the packaging runs made from a batch
+func (c *RiceContract) GetPackagingRuns(ctx contractapi.TransactionContextInterface, batchID string) ([]*PackagingRun, error) {
+	queryString := richQuery(map[string]interface{}{"assetType": "packagingRun", "batchID": batchID})
+	resultsIterator, err := ctx.GetStub().GetQueryResult(queryString)
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	var runs []*PackagingRun
+	for resultsIterator.HasNext() {
+		queryResult, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		var run PackagingRun
+		err = json.Unmarshal(queryResult.Value, &run)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, &run)
+	}
+	return runs, nil
+}
+
+// VerifyRetailUnit resolves a retail serial to its packaging run and full batch provenance
+func (c *RiceContract) VerifyRetailUnit(ctx contractapi.TransactionContextInterface, serial string) (*RetailUnitProvenance, error) {
+	separator := strings.LastIndex(serial, "-")
+	if separator <= 0 {
+		return nil, invalidArgumentError("serial %s is not a valid retail serial", serial)
+	}
+	unit, err := strconv.Atoi(serial[separator+1:])
+	if err != nil || len(serial[separator+1:]) != serialDigits {
+		return nil, invalidArgumentError("serial %s is not a valid retail serial", serial)
+	}
+
+	run, err := c.ReadPackagingRun(ctx, serial[:separator])
+	if err != nil {
+		return nil, notFoundError("serial %s is not registered", serial)
+	}
+	if unit < 1 || unit > run.UnitCount {
+		return nil, notFoundError("serial %s is not registered", serial)
+	}
+
+	batch, err := c.ReadRiceBatch(ctx, run.BatchID)
+	if err != nil {
+		return nil, err
+	}
+	history, err := c.GetRiceBatchHistory(ctx, run.BatchID)
+	if err != nil {
+		return nil, err
+	}
+	storage, err := c.GetStorageHistory(ctx, run.BatchID)
+	if err != nil {
+		return nil, err
+	}
+	shipments, err := c.GetShipmentsByBatch(ctx, run.BatchID)
+	if err != nil {
+		return nil, err
+	}
+
+	expired := false
+	if now, err := ctx.GetStub().GetTxTimestamp(); err == nil {
+		best, _ := time.Parse("2006-01-02", run.BestBefore)
+		expired = now.AsTime().After(best.AddDate(0, 0, 1))
+	}
+
+	// Batches never stored or shipped return [] rather than null, which the contract schema rejects
+	return &RetailUnitProvenance{
+		Serial:    serial,
+		Expired:   expired,
+		Run:       run,
+		Batch:     batch,
+		History:   history,
+		Storage:   append([]*StorageRecord{}, storage...),
+		Shipments: append([]*Shipment{}, shipments...),
+	}, nil
+}
@@ -0,0 +1,66 @@
+package contracts
+
+import (
+	"testing"
+
+	"ricetypes"
+)
+
+func TestPackagingIsCappedByTheMilledBatch(t *testing.T) {
+	h := newContractHarness(t)
+	h.harvest("B1", 1000)
+	run := func(runID string, packSizeKg string, unitCount string, packDate string, bestBefore string) []string {
+		return []string{runID, "B1", packSizeKg, unitCount, packDate, bestBefore}
+	}
+	h.reject("Org2MSP", "PackageBatch", run("R1", "1", "400", "2025-01-10", "2026-01-10"), nil, ricetypes.ErrCodeInvalidArgument)
+
+	h.mill("B1", "O1", 1000, ricetypes.SaleTerms{})
+	h.reject("Org1MSP", "PackageBatch", run("R1", "1", "400", "2025-01-10", "2026-01-10"), nil, ricetypes.ErrCodeForbidden)
+	h.reject("Org2MSP", "PackageBatch", run("R-1", "1", "400", "2025-01-10", "2026-01-10"), nil, ricetypes.ErrCodeInvalidArgument)
+	h.reject("Org2MSP", "PackageBatch", run("R1", "0", "400", "2025-01-10", "2026-01-10"), nil, ricetypes.ErrCodeInvalidArgument)
+	h.reject("Org2MSP", "PackageBatch", run("R1", "5", "201", "2025-01-10", "2026-01-10"), nil, ricetypes.ErrCodeConflict)
+	h.reject("Org2MSP", "PackageBatch", run("R1", "1", "400", "10/01/2025", "2026-01-10"), nil, ricetypes.ErrCodeInvalidArgument)
+	h.reject("Org2MSP", "PackageBatch", run("R1", "1", "400", "2025-01-10", "2025-01-10"), nil, ricetypes.ErrCodeInvalidArgument)
+
+	h.invoke("Org2MSP", "PackageBatch", run("R1", "1", "400", "2025-01-10", "2026-01-10"), nil)
+	h.reject("Org2MSP", "PackageBatch", run("R1", "1", "400", "2025-01-10", "2026-01-10"), nil, ricetypes.ErrCodeConflict)
+	h.invoke("Org2MSP", "PackageBatch", run("R2", "5", "120", "2024-12-01", "2025-01-13"), nil)
+	h.reject("Org2MSP", "PackageBatch", run("R3", "1", "1", "2025-01-10", "2026-01-10"), nil, ricetypes.ErrCodeConflict)
+	if packed := h.batch("B1").PackedInKg; packed != 1000 {
+		t.Errorf("batch packed %d kg, want 1000", packed)
+	}
+
+	var runs []*PackagingRun
+	h.read("Org1MSP", "GetPackagingRuns", []string{"B1"}, &runs)
+	if len(runs) != 2 || runs[0].LastSerial != "R1-000400" || runs[1].FirstSerial != "R2-000001" {
+		t.Errorf("packaging runs %+v", runs)
+	}
+}
+
+func TestVerifyRetailUnit(t *testing.T) {
+	h := newContractHarness(t)
+	h.harvest("B1", 1000)
+	h.mill("B1", "O1", 1000, ricetypes.SaleTerms{})
+	h.invoke("Org2MSP", "PackageBatch", []string{"R1", "B1", "1", "400", "2025-01-10", "2026-01-10"}, nil)
+	h.invoke("Org2MSP", "PackageBatch", []string{"R2", "B1", "5", "120", "2024-12-01", "2025-01-13"}, nil)
+
+	var unit RetailUnitProvenance
+	h.read("Org3MSP", "VerifyRetailUnit", []string{"R1-000400"}, &unit)
+	if unit.Expired || unit.Run.RunID != "R1" || unit.Batch.BatchID != "B1" || len(unit.History) == 0 {
+		t.Errorf("provenance of R1-000400: %+v", unit)
+	}
+	if unit.History[0].Record.Status != "Assigned to Miller Mill Co" {
+		t.Errorf("latest history entry %+v", unit.History[0].Record)
+	}
+
+	var expired RetailUnitProvenance
+	h.read("Org3MSP", "VerifyRetailUnit", []string{"R2-000001"}, &expired)
+	if !expired.Expired {
+		t.Errorf("R2 past its best-before date is not expired")
+	}
+
+	h.reject("Org3MSP", "VerifyRetailUnit", []string{"R1-000401"}, nil, ricetypes.ErrCodeNotFound)
+	h.reject("Org3MSP", "VerifyRetailUnit", []string{"R9-000001"}, nil, ricetypes.ErrCodeNotFound)
+	h.reject("Org3MSP", "VerifyRetailUnit", []string{"R1-1"}, nil, ricetypes.ErrCodeInvalidArgument)
+	h.reject("Org3MSP", "VerifyRetailUnit", []string{"R1000001"}, nil, ricetypes.ErrCodeInvalidArgument)
+}
@@ -3,10 +3,13 @@ package contracts
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/hyperledger/fabric-chaincode-go/v2/shim"
 	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+
+	"ricetypes"
 )
 
 // RiceContract contract for managing CRUD for Rice Batches and Processing Orders
@@ -14,30 +17,14 @@ type RiceContract struct {
 	contractapi.Contract
 }
 
-type RiceBatch struct {
-	AssetType    string `json:"assetType"`
-	BatchID      string `json:"batchID"`
-	Variety      string `json:"variety"`
-	HarvestDate  string `json:"harvestDate"`
-	QuantityInKg int    `json:"quantityInKg"`
-	ProducedBy   string `json:"producedBy"`
-	Status       string `json:"status"`
-}
-
-type ProcessingOrder struct {
-	AssetType    string `json:"assetType"`
-	OrderID      string `json:"orderID"`
-	Variety      string `json:"variety"`
-	MillerName   string `json:"millerName"`
-	QuantityInKg int    `json:"quantityInKg"`
-}
-
-type HistoryQueryResult struct {
-	Record    *RiceBatch `json:"record"`
-	TxId      string     `json:"txId"`
-	Timestamp string     `json:"timestamp"`
-	IsDelete  bool       `json:"isDelete"`
-}
+// Ledger types live in ricetypes, shared with the frontend that decodes them
+type (
+	RiceBatch          = ricetypes.RiceBatch
+	BatchFlag          = ricetypes.BatchFlag
+	ProcessingOrder    = ricetypes.ProcessingOrder
+	HistoryQueryResult = ricetypes.HistoryQueryResult
+	BatchEvent         = ricetypes.BatchEvent
+)
 
 func getCollectionName() string {
 	return "ProcessingOrderCollection"
@@ -52,8 +39,8 @@ func (c *RiceContract) RiceBatchExists(ctx contractapi.TransactionContextInterfa
 	return data != nil, nil
 }
 
-// CreateRiceBatch creates a new rice batch (only by farmer)
-func (c *RiceContract) CreateRiceBatch(ctx contractapi.TransactionContextInterface, batchID string, variety string, harvestDate string, quantityInKg int, farmerName string) (string, error) {
+// CreateRiceBatch creates a new rice batch harvested from a registered field (only by farmer)
+func (c *RiceContract) CreateRiceBatch(ctx contractapi.TransactionContextInterface, batchID string, variety string, harvestDate string, quantityInKg int, farmerName string, fieldID string) (string, error) {
 	clientOrgID, err := ctx.GetClientIdentity().GetMSPID()
 	if err != nil {
 		return "", err
@@ -64,7 +51,23 @@ func (c *RiceContract) CreateRiceBatch(ctx contractapi.TransactionContextInterfa
 		if err != nil {
 			return "", err
 		} else if exists {
-			return "", fmt.Errorf("the batch %s already exists", batchID)
+			return "", conflictError("the batch %s already exists", batchID)
+		}
+
+		field, err := c.ReadField(ctx, fieldID)
+		if err != nil {
+			return "", err
+		}
+		farm, err := c.ReadFarm(ctx, field.FarmID)
+		if err != nil {
+			return "", err
+		}
+		if farm.Owner != farmerName {
+			return "", invalidArgumentError("field %v belongs to %v, not %v", fieldID, farm.Owner, farmerName)
+		}
+		err = c.recordHarvest(ctx, field, variety, quantityInKg)
+		if err != nil {
+			return "", err
 		}
 
 		rice := RiceBatch{
@@ -74,13 +77,49 @@ func (c *RiceContract) CreateRiceBatch(ctx contractapi.TransactionContextInterfa
 			HarvestDate:  harvestDate,
 			QuantityInKg: quantityInKg,
 			ProducedBy:   farmerName,
+			FarmerName:   farmerName,
+			FieldID:      fieldID,
+			CropSeason:   field.CropSeason,
 			Status:       "Harvested",
 		}
-		bytes, _ := json.Marshal(rice)
 
-		return fmt.Sprintf("successfully added rice batch %v", batchID), ctx.GetStub().PutState(batchID, bytes)
+		// Inherit the farmer's certifications that cover the harvest date
+		rice.Certifications, err = c.activeCertificationIDs(ctx, farmerName, harvestDate)
+		if err != nil {
+			return "", err
+		}
+
+		// Optional farm inputs for footprint accounting arrive as transient data
+		transientData, _ := ctx.GetStub().GetTransient()
+		farmInputs, err := farmInputsFromTransient(transientData)
+		if err != nil {
+			return "", err
+		}
+		if farmInputs != nil {
+			measured, err := transientInt64(transientData, ricetypes.TransientFieldEmissionsKgCO2e)
+			if err != nil {
+				return "", err
+			}
+			if measured < 0 || measured > maxFarmInputQuantity {
+				return "", invalidArgumentError("fieldEmissionsKgCO2e must be between 0 and %d", maxFarmInputQuantity)
+			}
+			err = c.recordFarmFootprint(ctx, batchID, farmInputs, measured)
+			if err != nil {
+				return "", err
+			}
+		}
+		err = indexBatchCertifications(ctx, &rice)
+		if err != nil {
+			return "", err
+		}
+		bytes, _ := json.Marshal(rice)
+		err = ctx.GetStub().PutState(batchID, bytes)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("successfully added rice batch %v", batchID), setBatchEvent(ctx, ricetypes.EventBatchCreated, &rice, "")
 	} else {
-		return "", fmt.Errorf("user under following MSPID: %v can't perform this action", clientOrgID)
+		return "", forbiddenError("user under following MSPID: %v can't perform this action", clientOrgID)
 	}
 }
 
@@ -91,7 +130,7 @@ func (c *RiceContract) ReadRiceBatch(ctx contractapi.TransactionContextInterface
 		return nil, fmt.Errorf("failed to read from world state: %v", err)
 	}
 	if bytes == nil {
-		return nil, fmt.Errorf("the rice batch %s does not exist", batchID)
+		return nil, notFoundError("the rice batch %s does not exist", batchID)
 	}
 
 	var batch RiceBatch
@@ -99,6 +138,9 @@ func (c *RiceContract) ReadRiceBatch(ctx contractapi.TransactionContextInterface
 	if err != nil {
 		return nil, fmt.Errorf("could not unmarshal world state data to type RiceBatch")
 	}
+	if batch.AssetType != "riceBatch" {
+		return nil, notFoundError("the rice batch %s does not exist", batchID)
+	}
 	return &batch, nil
 }
 
@@ -113,15 +155,80 @@ func (c *RiceContract) DeleteRiceBatch(ctx contractapi.TransactionContextInterfa
 		if err != nil {
 			return "", fmt.Errorf("Could not read from world state. %s", err)
 		} else if !exists {
-			return "", fmt.Errorf("The asset %s does not exist", batchID)
+			return "", notFoundError("The asset %s does not exist", batchID)
 		}
 		err = ctx.GetStub().DelState(batchID)
 		return fmt.Sprintf("Rice batch %v deleted", batchID), err
 	} else {
-		return "", fmt.Errorf("User under following MSP:%v cannot perform deletion", clientOrgID)
+		return "", forbiddenError("User under following MSP:%v cannot perform deletion", clientOrgID)
 	}
 }
 
+// monitorMSP is the org whose alert rule engine flags batches
+const monitorMSP = "Org1MSP"
+
+// FlagBatch attaches a rule breach to a batch; the same rule flags a batch only once.
+// Only the alert rule engine flags batches: it signs as a monitorMSP identity
+// enrolled with the attribute role=monitor.
+func (c *RiceContract) FlagBatch(ctx contractapi.TransactionContextInterface, batchID string, ruleID string, reason string) (string, error) {
+	clientOrgID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return "", err
+	}
+	if clientOrgID != monitorMSP || ctx.GetClientIdentity().AssertAttributeValue("role", "monitor") != nil {
+		return "", forbiddenError("only %v identities with role=monitor can flag batches", monitorMSP)
+	}
+
+	batch, err := c.ReadRiceBatch(ctx, batchID)
+	if err != nil {
+		return "", err
+	}
+	for _, flag := range batch.Flags {
+		if flag.RuleID == ruleID {
+			return fmt.Sprintf("batch %v already flagged by rule %v", batchID, ruleID), nil
+		}
+	}
+
+	batch.Flags = append(batch.Flags, &BatchFlag{
+		RuleID:    ruleID,
+		Reason:    reason,
+		FlaggedAt: txTimestamp(ctx),
+		FlaggedBy: clientOrgID,
+	})
+	bytes, _ := json.Marshal(batch)
+	err = ctx.GetStub().PutState(batchID, bytes)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("batch %v flagged by rule %v", batchID, ruleID), setBatchEvent(ctx, ricetypes.EventBatchFlagged, batch, reason)
+}
+
+// setBatchEvent emits a lifecycle event for batch with the caller as the actor
+func setBatchEvent(ctx contractapi.TransactionContextInterface, name string, batch *RiceBatch, reason string) error {
+	actor, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return err
+	}
+	payload, _ := json.Marshal(BatchEvent{
+		BatchID:   batch.BatchID,
+		Status:    batch.Status,
+		Reason:    reason,
+		ActorMSP:  actor,
+		Timestamp: txTimestamp(ctx),
+	})
+	return ctx.GetStub().SetEvent(name, payload)
+}
+
+// Helper: builds a CouchDB query with json.Marshal so caller-supplied values cannot alter the selector
+func richQuery(selector map[string]interface{}, sort ...map[string]string) string {
+	query := map[string]interface{}{"selector": selector}
+	if len(sort) > 0 {
+		query["sort"] = sort
+	}
+	bytes, _ := json.Marshal(query)
+	return string(bytes)
+}
+
 // GetAllRiceBatches retrieves all rice batches
 func (c *RiceContract) GetAllRiceBatches(ctx contractapi.TransactionContextInterface) ([]*RiceBatch, error) {
 	queryString := `{"selector":{"assetType":"riceBatch"}, "sort":[{ "batchID": "desc"}]}`
@@ -146,6 +253,10 @@ func riceBatchIterator(resultsIterator shim.StateQueryIteratorInterface) ([]*Ric
 		if err != nil {
 			return nil, err
 		}
+		// Range queries also return other asset types stored under plain keys
+		if batch.AssetType != "riceBatch" {
+			continue
+		}
 		batches = append(batches, &batch)
 	}
 	return batches, nil
@@ -191,25 +302,37 @@ func (c *RiceContract) GetRiceBatchHistory(ctx contractapi.TransactionContextInt
 func (c *RiceContract) CreateProcessingOrder(ctx contractapi.TransactionContextInterface, orderID string) (string, error) {
 	clientOrgID, _ := ctx.GetClientIdentity().GetMSPID()
 	if clientOrgID != "Org2MSP" {
-		return "", fmt.Errorf("Only Org2MSP (Miller) can create order")
+		return "", forbiddenError("Only Org2MSP (Miller) can create order")
 	}
 
 	exists, _ := c.ProcessingOrderExists(ctx, orderID)
 	if exists {
-		return "", fmt.Errorf("Order ID already exists")
+		return "", conflictError("Order ID already exists")
 	}
 
 	transientData, _ := ctx.GetStub().GetTransient()
 	if len(transientData) == 0 {
-		return "", fmt.Errorf("Provide transient fields: variety, millerName, quantityInKg")
+		return "", invalidArgumentError("Provide transient fields: variety, millerName, quantityInKg")
+	}
+
+	unitPrice, err := transientInt64(transientData, ricetypes.TransientUnitPrice)
+	if err != nil {
+		return "", err
+	}
+	taxRateBps, err := transientInt64(transientData, ricetypes.TransientTaxRateBps)
+	if err != nil {
+		return "", err
 	}
 
 	order := &ProcessingOrder{
 		AssetType:    "processingOrder",
 		OrderID:      orderID,
-		Variety:      string(transientData["variety"]),
-		MillerName:   string(transientData["millerName"]),
-		QuantityInKg: parseInt(string(transientData["quantityInKg"])),
+		Variety:      string(transientData[ricetypes.TransientVariety]),
+		MillerName:   string(transientData[ricetypes.TransientMillerName]),
+		QuantityInKg: parseInt(string(transientData[ricetypes.TransientQuantityInKg])),
+		UnitPrice:    unitPrice,
+		Currency:     string(transientData[ricetypes.TransientCurrency]),
+		TaxRateBps:   taxRateBps,
 	}
 
 	bytes, _ := json.Marshal(order)
@@ -226,15 +349,20 @@ func (c *RiceContract) ProcessingOrderExists(ctx contractapi.TransactionContextI
 func (c *RiceContract) ReadProcessingOrder(ctx contractapi.TransactionContextInterface, orderID string) (*ProcessingOrder, error) {
 	bytes, err := ctx.GetStub().GetPrivateData(getCollectionName(), orderID)
 	if err != nil || bytes == nil {
-		return nil, fmt.Errorf("Order does not exist or cannot be read")
+		return nil, notFoundError("Order does not exist or cannot be read")
 	}
 	var order ProcessingOrder
 	err = json.Unmarshal(bytes, &order)
 	return &order, err
 }
 
-// MatchProcessingOrder with rice batch
+// MatchProcessingOrder with rice batch (only by miller, whose tokens the escrow locks)
 func (c *RiceContract) MatchProcessingOrder(ctx contractapi.TransactionContextInterface, batchID string, orderID string) (string, error) {
+	clientOrgID, _ := ctx.GetClientIdentity().GetMSPID()
+	if clientOrgID != "Org2MSP" {
+		return "", forbiddenError("Only Org2MSP (Miller) can match processing orders")
+	}
+
 	batch, err := c.ReadRiceBatch(ctx, batchID)
 	if err != nil {
 		return "", err
@@ -249,11 +377,28 @@ func (c *RiceContract) MatchProcessingOrder(ctx contractapi.TransactionContextIn
 		batch.Status = fmt.Sprintf("Assigned to Miller %v", order.MillerName)
 		bytes, _ := json.Marshal(batch)
 
+		if order.UnitPrice > 0 {
+			invoiceID := fmt.Sprintf("INV-%s-%s", batchID, orderID)
+			invoice, err := c.issueInvoice(ctx, invoiceID, batchID, orderID, "Org2MSP", "Org1MSP",
+				order.QuantityInKg, order.UnitPrice, order.TaxRateBps, order.Currency)
+			if err != nil {
+				return "", err
+			}
+			// Miller's payment is held until the retailer accepts delivery
+			err = c.lockEscrow(ctx, batchID, invoice)
+			if err != nil {
+				return "", err
+			}
+		}
+
 		ctx.GetStub().DelPrivateData(getCollectionName(), orderID)
 		err = ctx.GetStub().PutState(batchID, bytes)
-		return fmt.Sprintf("Order %v fulfilled by batch %v", orderID, batchID), err
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Order %v fulfilled by batch %v", orderID, batchID), setBatchEvent(ctx, ricetypes.EventBatchMilled, batch, "")
 	} else {
-		return "", fmt.Errorf("Variety or quantity mismatch for order pairing")
+		return "", invalidArgumentError("Variety or quantity mismatch for order pairing")
 	}
 }
 
@@ -261,7 +406,7 @@ func (c *RiceContract) MatchProcessingOrder(ctx contractapi.TransactionContextIn
 func (c *RiceContract) DispatchToRetailer(ctx contractapi.TransactionContextInterface, batchID string, retailer string) (string, error) {
 	clientOrgID, _ := ctx.GetClientIdentity().GetMSPID()
 	if clientOrgID != "Org3MSP" {
-		return "", fmt.Errorf("Only Org3MSP (Retailer) can dispatch batch")
+		return "", forbiddenError("Only Org3MSP (Retailer) can dispatch batch")
 	}
 
 	batch, err := c.ReadRiceBatch(ctx, batchID)
@@ -271,9 +416,31 @@ func (c *RiceContract) DispatchToRetailer(ctx contractapi.TransactionContextInte
 	batch.Status = fmt.Sprintf("Dispatched to %v", retailer)
 	batch.ProducedBy = retailer
 
+	// Optional sale terms travel as transient data so the price is not part of the proposal args
+	transientData, _ := ctx.GetStub().GetTransient()
+	unitPrice, err := transientInt64(transientData, ricetypes.TransientUnitPrice)
+	if err != nil {
+		return "", err
+	}
+	taxRateBps, err := transientInt64(transientData, ricetypes.TransientTaxRateBps)
+	if err != nil {
+		return "", err
+	}
+	if unitPrice > 0 {
+		invoiceID := fmt.Sprintf("INV-%s-DISPATCH", batchID)
+		_, err = c.issueInvoice(ctx, invoiceID, batchID, "", "Org3MSP", "Org2MSP",
+			batch.QuantityInKg, unitPrice, taxRateBps, string(transientData[ricetypes.TransientCurrency]))
+		if err != nil {
+			return "", err
+		}
+	}
+
 	bytes, _ := json.Marshal(batch)
 	err = ctx.GetStub().PutState(batchID, bytes)
-	return fmt.Sprintf("Batch %v dispatched to %v", batchID, retailer), err
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Batch %v dispatched to %v", batchID, retailer), setBatchEvent(ctx, ricetypes.EventBatchDispatched, batch, "")
 }
 
 // GetRiceBatchByRange retrieves rice batches within a key range
@@ -314,7 +481,7 @@ func (c *RiceContract) GetMatchingOrders(ctx contractapi.TransactionContextInter
 	if err != nil {
 		return nil, fmt.Errorf("Could not check batch existence: %s", err)
 	} else if !exists {
-		return nil, fmt.Errorf("Batch %s does not exist", batchID)
+		return nil, notFoundError("Batch %s does not exist", batchID)
 	}
 
 	batch, err := c.ReadRiceBatch(ctx, batchID)
@@ -322,12 +489,7 @@ func (c *RiceContract) GetMatchingOrders(ctx contractapi.TransactionContextInter
 		return nil, fmt.Errorf("Error reading batch: %v", err)
 	}
 
-	queryString := fmt.Sprintf(`{
-		"selector": {
-			"assetType": "processingOrder",
-			"variety": "%s"
-		}
-	}`, batch.Variety)
+	queryString := richQuery(map[string]interface{}{"assetType": "processingOrder", "variety": batch.Variety})
 
 	resultsIterator, err := ctx.GetStub().GetPrivateDataQueryResult(getCollectionName(), queryString)
 	if err != nil {
@@ -362,10 +524,24 @@ func (c *RiceContract) GetProcessingOrdersByRange(ctx contractapi.TransactionCon
 	return processingOrderIterator(resultsIterator)
 }
 
-
 // Helper: Parse integer
 func parseInt(s string) int {
 	var i int
 	fmt.Sscanf(s, "%d", &i)
 	return i
-}
\ No newline at end of file
+}
+
+// Helper: optional 64-bit integer from transient data, used for amounts in
+// minor units. A missing value is zero; a malformed one is an error, so a
+// price such as "45.00" is refused instead of recorded as 45 or unpriced.
+func transientInt64(transientData map[string][]byte, key string) (int64, error) {
+	value := string(transientData[key])
+	if value == "" {
+		return 0, nil
+	}
+	i, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, invalidArgumentError("%v must be a whole number, got %q", key, value)
+	}
+	return i, nil
+}
@@ -0,0 +1,51 @@
+package contracts
+
+import (
+	"encoding/json"
+	"testing"
+
+	"ricetypes"
+)
+
+func TestRichQueryEscapesArguments(t *testing.T) {
+	injected := `B1"},"assetType":{"$ne":"`
+	query := richQuery(map[string]interface{}{"assetType": "shipment", "batchID": injected}, map[string]string{"date": "asc"})
+
+	var parsed struct {
+		Selector map[string]interface{} `json:"selector"`
+		Sort     []map[string]string    `json:"sort"`
+	}
+	if err := json.Unmarshal([]byte(query), &parsed); err != nil {
+		t.Fatalf("query %s: %v", query, err)
+	}
+	if parsed.Selector["assetType"] != "shipment" || parsed.Selector["batchID"] != injected || len(parsed.Selector) != 2 {
+		t.Errorf("selector %v altered by argument", parsed.Selector)
+	}
+	if len(parsed.Sort) != 1 || parsed.Sort[0]["date"] != "asc" {
+		t.Errorf("sort %v", parsed.Sort)
+	}
+}
+
+func TestFlagBatchNeedsTheFarmerOrgMonitor(t *testing.T) {
+	h := newContractHarness(t)
+	h.harvest("B1", 1000)
+	h.identity("monitor", "Org1MSP", map[string]string{"role": "monitor"})
+	h.identity("foreign monitor", "Org2MSP", map[string]string{"role": "monitor"})
+	h.identity("certifier", "Org1MSP", map[string]string{"role": "certifier"})
+
+	flag := []string{"B1", "moisture-high", "Moisture above 14% for 6 hours"}
+	h.reject("Org1MSP", "FlagBatch", flag, nil, ricetypes.ErrCodeForbidden)
+	h.reject("certifier", "FlagBatch", flag, nil, ricetypes.ErrCodeForbidden)
+	h.reject("foreign monitor", "FlagBatch", flag, nil, ricetypes.ErrCodeForbidden)
+	h.reject("monitor", "FlagBatch", []string{"B9", "moisture-high", ""}, nil, ricetypes.ErrCodeNotFound)
+
+	h.invoke("monitor", "FlagBatch", flag, nil)
+	h.invoke("monitor", "FlagBatch", flag, nil)
+	flags := h.batch("B1").Flags
+	if len(flags) != 1 || flags[0].RuleID != "moisture-high" || flags[0].FlaggedBy != "Org1MSP" {
+		t.Errorf("flags %+v", flags)
+	}
+	if event := h.event(ricetypes.EventBatchFlagged); event.Reason != flag[2] {
+		t.Errorf("BatchFlagged event %+v", event)
+	}
+}
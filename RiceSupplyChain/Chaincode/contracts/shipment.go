@@ -0,0 +1,282 @@
+package contracts
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+
+	"ricetypes"
+)
+
+type (
+	Shipment    = ricetypes.Shipment
+	Checkpoint  = ricetypes.Checkpoint
+	Discrepancy = ricetypes.Discrepancy
+)
+
+// Helper: shipments are started and updated by the miller or the retailer
+func canShip(clientOrgID string) bool {
+	return clientOrgID == "Org2MSP" || clientOrgID == "Org3MSP"
+}
+
+// StartShipment puts a dispatched batch in transit
+func (c *RiceContract) StartShipment(ctx contractapi.TransactionContextInterface, shipmentID string, batchID string, carrier string, vehicleID string, origin string, destination string, departureTime string, expectedArrival string, sealNumbers []string, quantityInKg int) (string, error) {
+	clientOrgID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return "", err
+	}
+	if !canShip(clientOrgID) {
+		return "", forbiddenError("user under following MSPID: %v can't start shipments", clientOrgID)
+	}
+
+	existing, err := ctx.GetStub().GetState(shipmentID)
+	if err != nil {
+		return "", fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if existing != nil {
+		return "", conflictError("the shipment %s already exists", shipmentID)
+	}
+
+	batch, err := c.ReadRiceBatch(ctx, batchID)
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(batch.Status, "Dispatched") {
+		return "", invalidArgumentError("batch %v must be dispatched before shipping, status: %v", batchID, batch.Status)
+	}
+	unshipped := batch.QuantityInKg - batch.ShippedInKg
+	if quantityInKg <= 0 || quantityInKg > unshipped {
+		return "", invalidArgumentError("shipped quantity %d must be between 1 and the unshipped quantity %d of batch %v", quantityInKg, unshipped, batchID)
+	}
+
+	if departureTime == "" {
+		departureTime = txTimestamp(ctx)
+	}
+	if _, err := time.Parse(time.RFC3339, departureTime); err != nil {
+		return "", invalidArgumentError("departureTime must be RFC3339: %v", err)
+	}
+	if _, err := time.Parse(time.RFC3339, expectedArrival); err != nil {
+		return "", invalidArgumentError("expectedArrival must be RFC3339: %v", err)
+	}
+
+	shipment := Shipment{
+		AssetType:              "shipment",
+		ShipmentID:             shipmentID,
+		BatchID:                batchID,
+		Carrier:                carrier,
+		VehicleID:              vehicleID,
+		Origin:                 origin,
+		Destination:            destination,
+		DepartureTime:          departureTime,
+		ExpectedArrival:        expectedArrival,
+		SealNumbers:            sealNumbers,
+		DispatchedQuantityInKg: quantityInKg,
+		Checkpoints:            []*Checkpoint{},
+		Status:                 "InTransit",
+		StartedBy:              clientOrgID,
+	}
+	batch.ShippedInKg += quantityInKg
+	bytes, _ := json.Marshal(batch)
+	err = ctx.GetStub().PutState(batchID, bytes)
+	if err != nil {
+		return "", err
+	}
+
+	bytes, _ = json.Marshal(shipment)
+	return fmt.Sprintf("shipment %v started for batch %v", shipmentID, batchID), ctx.GetStub().PutState(shipmentID, bytes)
+}
+
+// ReadShipment retrieves a shipment from world state
+func (c *RiceContract) ReadShipment(ctx contractapi.TransactionContextInterface, shipmentID string) (*Shipment, error) {
+	bytes, err := ctx.GetStub().GetState(shipmentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if bytes == nil {
+		return nil, notFoundError("the shipment %s does not exist", shipmentID)
+	}
+
+	var shipment Shipment
+	err = json.Unmarshal(bytes, &shipment)
+	if err != nil {
+		return nil, fmt.Errorf("could not unmarshal world state data to type Shipment")
+	}
+	if shipment.AssetType != "shipment" {
+		return nil, notFoundError("the shipment %s does not exist", shipmentID)
+	}
+	return &shipment, nil
+}
+
+// AddCheckpoint appends a location report to a shipment in transit
+func (c *RiceContract) AddCheckpoint(ctx contractapi.TransactionContextInterface, shipmentID string, location string, note string) (string, error) {
+	clientOrgID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return "", err
+	}
+	if !canShip(clientOrgID) {
+		return "", forbiddenError("user under following MSPID: %v can't update shipments", clientOrgID)
+	}
+
+	shipment, err := c.ReadShipment(ctx, shipmentID)
+	if err != nil {
+		return "", err
+	}
+	if shipment.Status != "InTransit" {
+		return "", conflictError("shipment %v is no longer in transit", shipmentID)
+	}
+
+	shipment.Checkpoints = append(shipment.Checkpoints, &Checkpoint{
+		Location:   location,
+		Note:       note,
+		Timestamp:  txTimestamp(ctx),
+		RecordedBy: clientOrgID,
+	})
+	bytes, _ := json.Marshal(shipment)
+	return fmt.Sprintf("checkpoint %v recorded for shipment %v", location, shipmentID), ctx.GetStub().PutState(shipmentID, bytes)
+}
+
+// ConfirmDelivery closes a shipment (only by retailer). When the delivered
+// quantity and seals match the dispatch the quantity counts towards the
+// batch, and once every kilogram has arrived this way the batch is accepted
+// and its escrow released. Otherwise a discrepancy is recorded and the batch
+// stays pending an explicit AcceptDelivery or RejectDelivery.
+func (c *RiceContract) ConfirmDelivery(ctx contractapi.TransactionContextInterface, shipmentID string, deliveredQuantityInKg int, actualArrival string, sealNumbers []string) (string, error) {
+	clientOrgID, _ := ctx.GetClientIdentity().GetMSPID()
+	if clientOrgID != "Org3MSP" {
+		return "", forbiddenError("Only Org3MSP (Retailer) can confirm delivery")
+	}
+
+	shipment, err := c.ReadShipment(ctx, shipmentID)
+	if err != nil {
+		return "", err
+	}
+	if shipment.Status != "InTransit" {
+		return "", conflictError("shipment %v is no longer in transit", shipmentID)
+	}
+	if deliveredQuantityInKg < 0 {
+		return "", invalidArgumentError("delivered quantity cannot be negative")
+	}
+
+	if actualArrival == "" {
+		actualArrival = txTimestamp(ctx)
+	}
+	if _, err := time.Parse(time.RFC3339, actualArrival); err != nil {
+		return "", invalidArgumentError("actualArrival must be RFC3339: %v", err)
+	}
+
+	shipment.ActualArrival = actualArrival
+	shipment.DeliveredQuantityInKg = deliveredQuantityInKg
+	shipment.Status = "Delivered"
+
+	sealsIntact := sameSeals(shipment.SealNumbers, sealNumbers)
+	message := fmt.Sprintf("shipment %v delivered", shipmentID)
+	if deliveredQuantityInKg != shipment.DispatchedQuantityInKg || !sealsIntact {
+		discrepancy := Discrepancy{
+			AssetType:              "discrepancy",
+			DiscrepancyID:          "DSC-" + shipmentID,
+			ShipmentID:             shipmentID,
+			BatchID:                shipment.BatchID,
+			DispatchedQuantityInKg: shipment.DispatchedQuantityInKg,
+			DeliveredQuantityInKg:  deliveredQuantityInKg,
+			DifferenceInKg:         deliveredQuantityInKg - shipment.DispatchedQuantityInKg,
+			SealsIntact:            sealsIntact,
+			RecordedAt:             txTimestamp(ctx),
+			RecordedBy:             clientOrgID,
+		}
+		bytes, _ := json.Marshal(discrepancy)
+		err = ctx.GetStub().PutState(discrepancy.DiscrepancyID, bytes)
+		if err != nil {
+			return "", err
+		}
+		shipment.Status = "DeliveredWithDiscrepancy"
+		shipment.DiscrepancyID = discrepancy.DiscrepancyID
+		message = fmt.Sprintf("shipment %v delivered with discrepancy %v", shipmentID, discrepancy.DiscrepancyID)
+	} else {
+		batch, err := c.ReadRiceBatch(ctx, shipment.BatchID)
+		if err != nil {
+			return "", err
+		}
+		batch.DeliveredInKg += deliveredQuantityInKg
+		if batch.DeliveredInKg >= batch.QuantityInKg {
+			err = c.acceptDelivery(ctx, batch)
+		} else {
+			bytes, _ := json.Marshal(batch)
+			err = ctx.GetStub().PutState(batch.BatchID, bytes)
+			message = fmt.Sprintf("shipment %v delivered, %d of %d kg of batch %v received", shipmentID, batch.DeliveredInKg, batch.QuantityInKg, batch.BatchID)
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+
+	bytes, _ := json.Marshal(shipment)
+	return message, ctx.GetStub().PutState(shipmentID, bytes)
+}
+
+// ReadDiscrepancy retrieves a delivery discrepancy record
+func (c *RiceContract) ReadDiscrepancy(ctx contractapi.TransactionContextInterface, discrepancyID string) (*Discrepancy, error) {
+	bytes, err := ctx.GetStub().GetState(discrepancyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if bytes == nil {
+		return nil, notFoundError("the discrepancy %s does not exist", discrepancyID)
+	}
+
+	var discrepancy Discrepancy
+	err = json.Unmarshal(bytes, &discrepancy)
+	if err != nil {
+		return nil, fmt.Errorf("could not unmarshal world state data to type Discrepancy")
+	}
+	if discrepancy.AssetType != "discrepancy" {
+		return nil, notFoundError("the discrepancy %s does not exist", discrepancyID)
+	}
+	return &discrepancy, nil
+}
+
+// GetShipmentsByBatch returns every shipment recorded for a batch
+func (c *RiceContract) GetShipmentsByBatch(ctx contractapi.TransactionContextInterface, batchID string) ([]*Shipment, error) {
+	queryString := richQuery(map[string]interface{}{"assetType": "shipment", "batchID": batchID})
+	resultsIterator, err := ctx.GetStub().GetQueryResult(queryString)
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	var shipments []*Shipment
+	for resultsIterator.HasNext() {
+		queryResult, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		var shipment Shipment
+		err = json.Unmarshal(queryResult.Value, &shipment)
+		if err != nil {
+			return nil, err
+		}
+		shipments = append(shipments, &shipment)
+	}
+	return shipments, nil
+}
+
+// Helper: seals presented on arrival must match the ones applied at departure,
+// including how often each appears
+func sameSeals(applied []string, presented []string) bool {
+	if len(applied) != len(presented) {
+		return false
+	}
+	counts := make(map[string]int)
+	for _, seal := range applied {
+		counts[seal]++
+	}
+	for _, seal := range presented {
+		if counts[seal] == 0 {
+			return false
+		}
+		counts[seal]--
+	}
+	return true
+}
@@ -0,0 +1,114 @@
+package contracts
+
+import (
+	"strconv"
+	"testing"
+
+	"ricetypes"
+)
+
+// shipmentArgs are StartShipment arguments sealed with S-1 and S-2
+func shipmentArgs(shipmentID string, batchID string, quantityInKg int) []string {
+	return []string{shipmentID, batchID, "RoadCo", "PB-10-1234", "Karnal", "Delhi",
+		"2025-01-15T06:00:00Z", "2025-01-16T06:00:00Z", `["S-1","S-2"]`, strconv.Itoa(quantityInKg)}
+}
+
+// dispatched harvests a batch and dispatches it unpriced
+func (h *contractHarness) dispatched(batchID string, quantityInKg int) {
+	h.t.Helper()
+	h.harvest(batchID, quantityInKg)
+	h.submit("Org3MSP", &ricetypes.DispatchToRetailerArgs{BatchID: batchID, RetailerName: "Fresh Mart"})
+}
+
+func TestPartialShipmentsCapAtBatchQuantity(t *testing.T) {
+	h := newContractHarness(t)
+	h.harvest("B0", 500)
+	h.reject("Org2MSP", "StartShipment", shipmentArgs("SH0", "B0", 500), nil, ricetypes.ErrCodeInvalidArgument)
+
+	h.dispatched("B1", 1000)
+	h.reject("Org1MSP", "StartShipment", shipmentArgs("SH1", "B1", 600), nil, ricetypes.ErrCodeForbidden)
+	h.invoke("Org2MSP", "StartShipment", shipmentArgs("SH1", "B1", 600), nil)
+	h.reject("Org2MSP", "StartShipment", shipmentArgs("SH1", "B1", 100), nil, ricetypes.ErrCodeConflict)
+	h.reject("Org2MSP", "StartShipment", shipmentArgs("SH2", "B1", 401), nil, ricetypes.ErrCodeInvalidArgument)
+	h.reject("Org2MSP", "StartShipment", shipmentArgs("SH2", "B1", 0), nil, ricetypes.ErrCodeInvalidArgument)
+
+	badArrival := shipmentArgs("SH2", "B1", 400)
+	badArrival[7] = "tomorrow"
+	h.reject("Org2MSP", "StartShipment", badArrival, nil, ricetypes.ErrCodeInvalidArgument)
+
+	h.invoke("Org3MSP", "StartShipment", shipmentArgs("SH2", "B1", 400), nil)
+	h.reject("Org2MSP", "StartShipment", shipmentArgs("SH3", "B1", 1), nil, ricetypes.ErrCodeInvalidArgument)
+	if shipped := h.batch("B1").ShippedInKg; shipped != 1000 {
+		t.Errorf("batch shipped %d kg, want 1000", shipped)
+	}
+
+	var shipments []*Shipment
+	h.read("Org1MSP", "GetShipmentsByBatch", []string{"B1"}, &shipments)
+	if len(shipments) != 2 || shipments[0].ShipmentID != "SH1" || shipments[1].ShipmentID != "SH2" {
+		t.Errorf("shipments of B1: %+v", shipments)
+	}
+}
+
+func TestConfirmedShipmentsDeliverTheBatch(t *testing.T) {
+	h := escrowed(t, "100000")
+	h.submit("Org3MSP", &ricetypes.DispatchToRetailerArgs{BatchID: "B1", RetailerName: "Fresh Mart"})
+	h.invoke("Org2MSP", "StartShipment", shipmentArgs("SH1", "B1", 600), nil)
+	h.invoke("Org2MSP", "StartShipment", shipmentArgs("SH2", "B1", 400), nil)
+
+	h.reject("Org1MSP", "AddCheckpoint", []string{"SH1", "Panipat", ""}, nil, ricetypes.ErrCodeForbidden)
+	h.invoke("Org2MSP", "AddCheckpoint", []string{"SH1", "Panipat", "on time"}, nil)
+
+	seals := `["S-2","S-1"]`
+	h.reject("Org2MSP", "ConfirmDelivery", []string{"SH1", "600", "", seals}, nil, ricetypes.ErrCodeForbidden)
+	h.invoke("Org3MSP", "ConfirmDelivery", []string{"SH1", "600", "", seals}, nil)
+	if batch := h.batch("B1"); batch.DeliveredInKg != 600 || batch.Status != "Dispatched to Fresh Mart" {
+		t.Errorf("after first shipment: %d kg delivered, status %q", batch.DeliveredInKg, batch.Status)
+	}
+	if got := h.escrowStatus("B1"); got != "Locked" {
+		t.Errorf("escrow %s before the batch fully arrived", got)
+	}
+	h.reject("Org3MSP", "ConfirmDelivery", []string{"SH1", "600", "", seals}, nil, ricetypes.ErrCodeConflict)
+	h.reject("Org2MSP", "AddCheckpoint", []string{"SH1", "Delhi", ""}, nil, ricetypes.ErrCodeConflict)
+
+	h.invoke("Org3MSP", "ConfirmDelivery", []string{"SH2", "400", "2025-01-16T05:00:00Z", seals}, nil)
+	if status := h.batch("B1").Status; status != "Delivered" {
+		t.Errorf("batch %q after every shipment arrived", status)
+	}
+	if got := h.escrowStatus("B1"); got != "Released" {
+		t.Errorf("escrow %s after the batch fully arrived", got)
+	}
+
+	var shipment Shipment
+	h.read("Org1MSP", "ReadShipment", []string{"SH1"}, &shipment)
+	if shipment.Status != "Delivered" || len(shipment.Checkpoints) != 1 || shipment.Checkpoints[0].RecordedBy != "Org2MSP" {
+		t.Errorf("shipment %+v", shipment)
+	}
+}
+
+func TestMismatchedDeliveryRecordsDiscrepancy(t *testing.T) {
+	h := escrowed(t, "100000")
+	h.submit("Org3MSP", &ricetypes.DispatchToRetailerArgs{BatchID: "B1", RetailerName: "Fresh Mart"})
+	h.invoke("Org2MSP", "StartShipment", shipmentArgs("SH1", "B1", 600), nil)
+	h.invoke("Org2MSP", "StartShipment", shipmentArgs("SH2", "B1", 400), nil)
+
+	h.reject("Org3MSP", "ConfirmDelivery", []string{"SH1", "-1", "", `["S-1","S-2"]`}, nil, ricetypes.ErrCodeInvalidArgument)
+	h.invoke("Org3MSP", "ConfirmDelivery", []string{"SH1", "590", "", `["S-1","S-2"]`}, nil)
+	h.invoke("Org3MSP", "ConfirmDelivery", []string{"SH2", "400", "", `["S-1","S-9"]`}, nil)
+
+	var short Discrepancy
+	h.read("Org1MSP", "ReadDiscrepancy", []string{"DSC-SH1"}, &short)
+	if short.DifferenceInKg != -10 || !short.SealsIntact {
+		t.Errorf("short delivery discrepancy %+v", short)
+	}
+	var tampered Discrepancy
+	h.read("Org1MSP", "ReadDiscrepancy", []string{"DSC-SH2"}, &tampered)
+	if tampered.DifferenceInKg != 0 || tampered.SealsIntact {
+		t.Errorf("broken seal discrepancy %+v", tampered)
+	}
+	if batch := h.batch("B1"); batch.DeliveredInKg != 0 || batch.Status != "Dispatched to Fresh Mart" {
+		t.Errorf("batch counted %d kg with discrepancies, status %q", batch.DeliveredInKg, batch.Status)
+	}
+	if got := h.escrowStatus("B1"); got != "Locked" {
+		t.Errorf("escrow %s settled despite discrepancies", got)
+	}
+}
@@ -0,0 +1,104 @@
+package contracts
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+
+	"ricetypes"
+)
+
+type TelemetryAnchor = ricetypes.TelemetryAnchor
+
+// AnchorTelemetry records the Merkle root of a window of sensor readings for a batch
+func (c *RiceContract) AnchorTelemetry(ctx contractapi.TransactionContextInterface, anchorID string, batchID string, merkleRoot string, readingCount int, firstReading string, lastReading string) (string, error) {
+	clientOrgID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return "", err
+	}
+
+	exists, err := c.RiceBatchExists(ctx, batchID)
+	if err != nil {
+		return "", err
+	} else if !exists {
+		return "", notFoundError("the rice batch %s does not exist", batchID)
+	}
+
+	existing, err := ctx.GetStub().GetState(anchorID)
+	if err != nil {
+		return "", fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if existing != nil {
+		return "", conflictError("the telemetry anchor %s already exists", anchorID)
+	}
+
+	root, err := hex.DecodeString(merkleRoot)
+	if err != nil || len(root) != 32 {
+		return "", invalidArgumentError("merkleRoot must be a hex encoded SHA-256 hash")
+	}
+	if readingCount <= 0 {
+		return "", invalidArgumentError("readingCount must be positive")
+	}
+
+	anchor := TelemetryAnchor{
+		AssetType:    "telemetryAnchor",
+		AnchorID:     anchorID,
+		BatchID:      batchID,
+		MerkleRoot:   merkleRoot,
+		ReadingCount: readingCount,
+		FirstReading: firstReading,
+		LastReading:  lastReading,
+		AnchoredAt:   txTimestamp(ctx),
+		AnchoredBy:   clientOrgID,
+	}
+	bytes, _ := json.Marshal(anchor)
+	return fmt.Sprintf("telemetry anchor %v recorded for batch %v", anchorID, batchID), ctx.GetStub().PutState(anchorID, bytes)
+}
+
+// ReadTelemetryAnchor retrieves a telemetry anchor from world state
+func (c *RiceContract) ReadTelemetryAnchor(ctx contractapi.TransactionContextInterface, anchorID string) (*TelemetryAnchor, error) {
+	bytes, err := ctx.GetStub().GetState(anchorID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if bytes == nil {
+		return nil, notFoundError("the telemetry anchor %s does not exist", anchorID)
+	}
+
+	var anchor TelemetryAnchor
+	err = json.Unmarshal(bytes, &anchor)
+	if err != nil {
+		return nil, fmt.Errorf("could not unmarshal world state data to type TelemetryAnchor")
+	}
+	if anchor.AssetType != "telemetryAnchor" {
+		return nil, notFoundError("the telemetry anchor %s does not exist", anchorID)
+	}
+	return &anchor, nil
+}
+
+// GetTelemetryAnchors returns all telemetry anchors recorded for a batch
+func (c *RiceContract) GetTelemetryAnchors(ctx contractapi.TransactionContextInterface, batchID string) ([]*TelemetryAnchor, error) {
+	queryString := richQuery(map[string]interface{}{"assetType": "telemetryAnchor", "batchID": batchID})
+	resultsIterator, err := ctx.GetStub().GetQueryResult(queryString)
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	var anchors []*TelemetryAnchor
+	for resultsIterator.HasNext() {
+		queryResult, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		var anchor TelemetryAnchor
+		err = json.Unmarshal(queryResult.Value, &anchor)
+		if err != nil {
+			return nil, err
+		}
+		anchors = append(anchors, &anchor)
+	}
+	return anchors, nil
+}
@@ -0,0 +1,40 @@
+package contracts
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"ricetypes"
+)
+
+func TestAnchorTelemetry(t *testing.T) {
+	h := newContractHarness(t)
+	h.harvest("B1", 1000)
+	root := sha256.Sum256([]byte("readings"))
+	merkleRoot := hex.EncodeToString(root[:])
+	window := []string{"2025-01-14T00:00:00Z", "2025-01-14T23:55:00Z"}
+
+	anchor := func(anchorID string, batchID string, merkleRoot string, readingCount string) []string {
+		return append([]string{anchorID, batchID, merkleRoot, readingCount}, window...)
+	}
+	h.reject("Org2MSP", "AnchorTelemetry", anchor("TA1", "B9", merkleRoot, "288"), nil, ricetypes.ErrCodeNotFound)
+	h.reject("Org2MSP", "AnchorTelemetry", anchor("TA1", "B1", "abcd", "288"), nil, ricetypes.ErrCodeInvalidArgument)
+	h.reject("Org2MSP", "AnchorTelemetry", anchor("TA1", "B1", merkleRoot, "0"), nil, ricetypes.ErrCodeInvalidArgument)
+	h.invoke("Org2MSP", "AnchorTelemetry", anchor("TA1", "B1", merkleRoot, "288"), nil)
+	h.reject("Org2MSP", "AnchorTelemetry", anchor("TA1", "B1", merkleRoot, "288"), nil, ricetypes.ErrCodeConflict)
+	h.invoke("Org3MSP", "AnchorTelemetry", anchor("TA2", "B1", merkleRoot, "12"), nil)
+
+	var stored TelemetryAnchor
+	h.read("Org1MSP", "ReadTelemetryAnchor", []string{"TA1"}, &stored)
+	if stored.MerkleRoot != merkleRoot || stored.ReadingCount != 288 || stored.AnchoredBy != "Org2MSP" || stored.AnchoredAt != "2025-01-15T00:00:00Z" {
+		t.Errorf("anchor %+v", stored)
+	}
+	h.reject("Org1MSP", "ReadTelemetryAnchor", []string{"B1"}, nil, ricetypes.ErrCodeNotFound)
+
+	var anchors []*TelemetryAnchor
+	h.read("Org1MSP", "GetTelemetryAnchors", []string{"B1"}, &anchors)
+	if len(anchors) != 2 || anchors[0].AnchorID != "TA1" || anchors[1].AnchoredBy != "Org3MSP" {
+		t.Errorf("anchors of B1: %+v", anchors)
+	}
+}
@@ -0,0 +1,237 @@
+package contracts
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+
+	"ricetypes"
+)
+
+// Token accounts are org MSP IDs. One token unit equals one minor unit of
+// the currency used on invoices, so escrow amounts map 1:1 to invoice totals.
+
+// tokenAdminMSP is the only org that may initialize the token and so choose
+// the minter. It is the org that deploys the chaincode.
+const tokenAdminMSP = "Org1MSP"
+
+const tokenInfoKey = "tokenInfo"
+const balancePrefix = "balance"
+const allowancePrefix = "allowance"
+
+type TokenInfo = ricetypes.TokenInfo
+
+// InitializeToken sets token metadata and the central-bank org; it can run only once (only by the token admin org)
+func (c *RiceContract) InitializeToken(ctx contractapi.TransactionContextInterface, name string, symbol string, decimals int, minterMSP string) (string, error) {
+	clientOrgID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return "", err
+	}
+	if clientOrgID != tokenAdminMSP {
+		return "", forbiddenError("user under following MSPID: %v can't initialize the token", clientOrgID)
+	}
+
+	existing, err := ctx.GetStub().GetState(tokenInfoKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if existing != nil {
+		return "", conflictError("token is already initialized")
+	}
+	if name == "" || symbol == "" || minterMSP == "" {
+		return "", invalidArgumentError("token name, symbol and minter MSP are required")
+	}
+
+	info := TokenInfo{
+		AssetType: "tokenInfo",
+		Name:      name,
+		Symbol:    symbol,
+		Decimals:  decimals,
+		MinterMSP: minterMSP,
+	}
+	bytes, _ := json.Marshal(info)
+	return fmt.Sprintf("token %v initialized with minter %v", symbol, minterMSP), ctx.GetStub().PutState(tokenInfoKey, bytes)
+}
+
+// GetTokenInfo returns token metadata and total supply
+func (c *RiceContract) GetTokenInfo(ctx contractapi.TransactionContextInterface) (*TokenInfo, error) {
+	bytes, err := ctx.GetStub().GetState(tokenInfoKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if bytes == nil {
+		return nil, conflictError("token is not initialized")
+	}
+
+	var info TokenInfo
+	err = json.Unmarshal(bytes, &info)
+	if err != nil {
+		return nil, fmt.Errorf("could not unmarshal world state data to type TokenInfo")
+	}
+	return &info, nil
+}
+
+// Mint creates new tokens for a recipient (only by the central-bank org)
+func (c *RiceContract) Mint(ctx contractapi.TransactionContextInterface, recipient string, amount int64) (string, error) {
+	clientOrgID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return "", err
+	}
+
+	info, err := c.GetTokenInfo(ctx)
+	if err != nil {
+		return "", err
+	}
+	if clientOrgID != info.MinterMSP {
+		return "", forbiddenError("user under following MSPID: %v can't mint tokens", clientOrgID)
+	}
+	if amount <= 0 {
+		return "", invalidArgumentError("mint amount must be positive")
+	}
+	if info.TotalSupply+amount < info.TotalSupply {
+		return "", conflictError("mint would overflow total supply")
+	}
+
+	err = addBalance(ctx, recipient, amount)
+	if err != nil {
+		return "", err
+	}
+	info.TotalSupply += amount
+	bytes, _ := json.Marshal(info)
+	return fmt.Sprintf("minted %d %v to %v", amount, info.Symbol, recipient), ctx.GetStub().PutState(tokenInfoKey, bytes)
+}
+
+// Transfer moves tokens from the caller's org to a recipient org
+func (c *RiceContract) Transfer(ctx contractapi.TransactionContextInterface, recipient string, amount int64) (string, error) {
+	clientOrgID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return "", err
+	}
+
+	err = transferTokens(ctx, clientOrgID, recipient, amount)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("transferred %d from %v to %v", amount, clientOrgID, recipient), nil
+}
+
+// BalanceOf returns the token balance of an org
+func (c *RiceContract) BalanceOf(ctx contractapi.TransactionContextInterface, account string) (int64, error) {
+	return readBalance(ctx, account)
+}
+
+// Approve allows a spender org to transfer up to amount from the caller's balance
+func (c *RiceContract) Approve(ctx contractapi.TransactionContextInterface, spender string, amount int64) (string, error) {
+	clientOrgID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return "", err
+	}
+	if amount < 0 {
+		return "", invalidArgumentError("allowance cannot be negative")
+	}
+
+	allowanceKey, err := ctx.GetStub().CreateCompositeKey(allowancePrefix, []string{clientOrgID, spender})
+	if err != nil {
+		return "", err
+	}
+	err = ctx.GetStub().PutState(allowanceKey, []byte(strconv.FormatInt(amount, 10)))
+	return fmt.Sprintf("%v approved %v to spend %d", clientOrgID, spender, amount), err
+}
+
+// Allowance returns how much a spender may still transfer from an owner
+func (c *RiceContract) Allowance(ctx contractapi.TransactionContextInterface, owner string, spender string) (int64, error) {
+	allowanceKey, err := ctx.GetStub().CreateCompositeKey(allowancePrefix, []string{owner, spender})
+	if err != nil {
+		return 0, err
+	}
+	return readAmount(ctx, allowanceKey)
+}
+
+// TransferFrom moves tokens on behalf of an owner using the caller's allowance
+func (c *RiceContract) TransferFrom(ctx contractapi.TransactionContextInterface, from string, to string, amount int64) (string, error) {
+	clientOrgID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return "", err
+	}
+
+	allowanceKey, err := ctx.GetStub().CreateCompositeKey(allowancePrefix, []string{from, clientOrgID})
+	if err != nil {
+		return "", err
+	}
+	allowance, err := readAmount(ctx, allowanceKey)
+	if err != nil {
+		return "", err
+	}
+	if allowance < amount {
+		return "", conflictError("allowance of %v for %v is insufficient", from, clientOrgID)
+	}
+
+	err = transferTokens(ctx, from, to, amount)
+	if err != nil {
+		return "", err
+	}
+	err = ctx.GetStub().PutState(allowanceKey, []byte(strconv.FormatInt(allowance-amount, 10)))
+	return fmt.Sprintf("transferred %d from %v to %v", amount, from, to), err
+}
+
+// Helper: move tokens between two accounts
+func transferTokens(ctx contractapi.TransactionContextInterface, from string, to string, amount int64) error {
+	if amount <= 0 {
+		return invalidArgumentError("transfer amount must be positive")
+	}
+	if from == to {
+		return invalidArgumentError("cannot transfer to the same account")
+	}
+	err := addBalance(ctx, from, -amount)
+	if err != nil {
+		return err
+	}
+	return addBalance(ctx, to, amount)
+}
+
+// Helper: adjust an account balance, refusing to go negative or overflow
+func addBalance(ctx contractapi.TransactionContextInterface, account string, delta int64) error {
+	if account == "" {
+		return invalidArgumentError("account is required")
+	}
+	balance, err := readBalance(ctx, account)
+	if err != nil {
+		return err
+	}
+	updated := balance + delta
+	if updated < 0 {
+		return conflictError("insufficient balance for %v: have %d, need %d", account, balance, -delta)
+	}
+	if delta > 0 && updated < balance {
+		return conflictError("balance overflow for %v", account)
+	}
+
+	balanceKey, err := ctx.GetStub().CreateCompositeKey(balancePrefix, []string{account})
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(balanceKey, []byte(strconv.FormatInt(updated, 10)))
+}
+
+// Helper: balance of an account, zero when never credited
+func readBalance(ctx contractapi.TransactionContextInterface, account string) (int64, error) {
+	balanceKey, err := ctx.GetStub().CreateCompositeKey(balancePrefix, []string{account})
+	if err != nil {
+		return 0, err
+	}
+	return readAmount(ctx, balanceKey)
+}
+
+// Helper: read an integer amount stored under key
+func readAmount(ctx contractapi.TransactionContextInterface, key string) (int64, error) {
+	bytes, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if bytes == nil {
+		return 0, nil
+	}
+	return strconv.ParseInt(string(bytes), 10, 64)
+}
@@ -0,0 +1,303 @@
+package contracts
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/v2/shim"
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+
+	"ricetypes"
+)
+
+type (
+	Warehouse          = ricetypes.Warehouse
+	StorageRecord      = ricetypes.StorageRecord
+	WarehouseInventory = ricetypes.WarehouseInventory
+)
+
+// Which batches are stored and how full each warehouse is are kept under
+// their own keys rather than derived from rich queries, which are not
+// re-checked at commit. Concurrent StoreBatch calls then conflict on these
+// keys and only one commits.
+const storagePrefix = "storage"
+const warehouseUsagePrefix = "warehouseUsage"
+
+// RegisterWarehouse creates a warehouse operated by the caller's org
+func (c *RiceContract) RegisterWarehouse(ctx contractapi.TransactionContextInterface, warehouseID string, name string, location string, capacityInKg int) (string, error) {
+	clientOrgID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return "", err
+	}
+
+	existing, err := ctx.GetStub().GetState(warehouseID)
+	if err != nil {
+		return "", fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if existing != nil {
+		return "", conflictError("the warehouse %s already exists", warehouseID)
+	}
+	if capacityInKg < 0 {
+		return "", invalidArgumentError("capacity cannot be negative")
+	}
+
+	warehouse := Warehouse{
+		AssetType:    "warehouse",
+		WarehouseID:  warehouseID,
+		Name:         name,
+		Location:     location,
+		CapacityInKg: capacityInKg,
+		Operator:     clientOrgID,
+	}
+	bytes, _ := json.Marshal(warehouse)
+	return fmt.Sprintf("warehouse %v registered", warehouseID), ctx.GetStub().PutState(warehouseID, bytes)
+}
+
+// ReadWarehouse retrieves a warehouse from world state
+func (c *RiceContract) ReadWarehouse(ctx contractapi.TransactionContextInterface, warehouseID string) (*Warehouse, error) {
+	bytes, err := ctx.GetStub().GetState(warehouseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if bytes == nil {
+		return nil, notFoundError("the warehouse %s does not exist", warehouseID)
+	}
+
+	var warehouse Warehouse
+	err = json.Unmarshal(bytes, &warehouse)
+	if err != nil {
+		return nil, fmt.Errorf("could not unmarshal world state data to type Warehouse")
+	}
+	if warehouse.AssetType != "warehouse" {
+		return nil, notFoundError("the warehouse %s does not exist", warehouseID)
+	}
+	return &warehouse, nil
+}
+
+// StoreBatch records a batch entering a warehouse bin (only by the warehouse operator)
+func (c *RiceContract) StoreBatch(ctx contractapi.TransactionContextInterface, storageID string, warehouseID string, batchID string, bin string, weightInKg int, entryDate string) (string, error) {
+	clientOrgID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return "", err
+	}
+
+	warehouse, err := c.ReadWarehouse(ctx, warehouseID)
+	if err != nil {
+		return "", err
+	}
+	if clientOrgID != warehouse.Operator {
+		return "", forbiddenError("only the operator %v can store batches in warehouse %v", warehouse.Operator, warehouseID)
+	}
+
+	existing, err := ctx.GetStub().GetState(storageID)
+	if err != nil {
+		return "", fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if existing != nil {
+		return "", conflictError("the storage record %s already exists", storageID)
+	}
+
+	batch, err := c.ReadRiceBatch(ctx, batchID)
+	if err != nil {
+		return "", err
+	}
+	if weightInKg <= 0 {
+		return "", invalidArgumentError("weight in must be positive")
+	}
+
+	batchStorageKey, err := ctx.GetStub().CreateCompositeKey(storagePrefix, []string{batchID})
+	if err != nil {
+		return "", err
+	}
+	storedIn, err := ctx.GetStub().GetState(batchStorageKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if storedIn != nil {
+		return "", conflictError("batch %v is already stored under storage record %v", batchID, string(storedIn))
+	}
+
+	usageKey, err := ctx.GetStub().CreateCompositeKey(warehouseUsagePrefix, []string{warehouseID})
+	if err != nil {
+		return "", err
+	}
+	used, err := readAmount(ctx, usageKey)
+	if err != nil {
+		return "", err
+	}
+	if warehouse.CapacityInKg > 0 && used+int64(weightInKg) > int64(warehouse.CapacityInKg) {
+		return "", conflictError("warehouse %v has %d kg free, cannot store %d kg", warehouseID, int64(warehouse.CapacityInKg)-used, weightInKg)
+	}
+
+	if entryDate == "" {
+		entryDate = txTimestamp(ctx)
+	}
+	if _, err := time.Parse(time.RFC3339, entryDate); err != nil {
+		return "", invalidArgumentError("entryDate must be RFC3339: %v", err)
+	}
+
+	record := StorageRecord{
+		AssetType:   "storageRecord",
+		StorageID:   storageID,
+		WarehouseID: warehouseID,
+		BatchID:     batchID,
+		Variety:     batch.Variety,
+		Bin:         bin,
+		EntryDate:   entryDate,
+		WeightInKg:  weightInKg,
+		Status:      "Stored",
+	}
+	err = ctx.GetStub().PutState(batchStorageKey, []byte(storageID))
+	if err != nil {
+		return "", err
+	}
+	err = ctx.GetStub().PutState(usageKey, []byte(strconv.FormatInt(used+int64(weightInKg), 10)))
+	if err != nil {
+		return "", err
+	}
+
+	bytes, _ := json.Marshal(record)
+	return fmt.Sprintf("batch %v stored in warehouse %v bin %v", batchID, warehouseID, bin), ctx.GetStub().PutState(storageID, bytes)
+}
+
+// ReleaseBatch records a batch leaving a warehouse and its shrinkage (only by the warehouse operator)
+func (c *RiceContract) ReleaseBatch(ctx contractapi.TransactionContextInterface, storageID string, weightOutKg int, exitDate string) (string, error) {
+	clientOrgID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return "", err
+	}
+
+	record, err := c.ReadStorageRecord(ctx, storageID)
+	if err != nil {
+		return "", err
+	}
+	if record.Status != "Stored" {
+		return "", conflictError("storage record %v has already been released", storageID)
+	}
+	warehouse, err := c.ReadWarehouse(ctx, record.WarehouseID)
+	if err != nil {
+		return "", err
+	}
+	if clientOrgID != warehouse.Operator {
+		return "", forbiddenError("only the operator %v can release batches from warehouse %v", warehouse.Operator, record.WarehouseID)
+	}
+	if weightOutKg < 0 || weightOutKg > record.WeightInKg {
+		return "", invalidArgumentError("weight out %d must be between 0 and weight in %d", weightOutKg, record.WeightInKg)
+	}
+
+	if exitDate == "" {
+		exitDate = txTimestamp(ctx)
+	}
+	if _, err := time.Parse(time.RFC3339, exitDate); err != nil {
+		return "", invalidArgumentError("exitDate must be RFC3339: %v", err)
+	}
+
+	batchStorageKey, err := ctx.GetStub().CreateCompositeKey(storagePrefix, []string{record.BatchID})
+	if err != nil {
+		return "", err
+	}
+	err = ctx.GetStub().DelState(batchStorageKey)
+	if err != nil {
+		return "", err
+	}
+	usageKey, err := ctx.GetStub().CreateCompositeKey(warehouseUsagePrefix, []string{record.WarehouseID})
+	if err != nil {
+		return "", err
+	}
+	used, err := readAmount(ctx, usageKey)
+	if err != nil {
+		return "", err
+	}
+	used -= int64(record.WeightInKg)
+	// Records stored before the usage counter existed were never counted
+	if used < 0 {
+		used = 0
+	}
+	err = ctx.GetStub().PutState(usageKey, []byte(strconv.FormatInt(used, 10)))
+	if err != nil {
+		return "", err
+	}
+
+	record.ExitDate = exitDate
+	record.WeightOutKg = weightOutKg
+	record.ShrinkageInKg = record.WeightInKg - weightOutKg
+	record.Status = "Released"
+
+	bytes, _ := json.Marshal(record)
+	return fmt.Sprintf("batch %v released from warehouse %v with %d kg shrinkage", record.BatchID, record.WarehouseID, record.ShrinkageInKg), ctx.GetStub().PutState(storageID, bytes)
+}
+
+// ReadStorageRecord retrieves a storage record from world state
+func (c *RiceContract) ReadStorageRecord(ctx contractapi.TransactionContextInterface, storageID string) (*StorageRecord, error) {
+	bytes, err := ctx.GetStub().GetState(storageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if bytes == nil {
+		return nil, notFoundError("the storage record %s does not exist", storageID)
+	}
+
+	var record StorageRecord
+	err = json.Unmarshal(bytes, &record)
+	if err != nil {
+		return nil, fmt.Errorf("could not unmarshal world state data to type StorageRecord")
+	}
+	if record.AssetType != "storageRecord" {
+		return nil, notFoundError("the storage record %s does not exist", storageID)
+	}
+	return &record, nil
+}
+
+// GetWarehouseInventory returns the batches currently stored in a warehouse and stock by variety
+func (c *RiceContract) GetWarehouseInventory(ctx contractapi.TransactionContextInterface, warehouseID string) (*WarehouseInventory, error) {
+	records, err := c.storageRecords(ctx, richQuery(map[string]interface{}{"assetType": "storageRecord", "warehouseID": warehouseID, "status": "Stored"}))
+	if err != nil {
+		return nil, err
+	}
+
+	inventory := WarehouseInventory{
+		WarehouseID: warehouseID,
+		ByVariety:   make(map[string]int),
+		// An empty warehouse returns [] rather than null, which the contract schema rejects
+		Records: append([]*StorageRecord{}, records...),
+	}
+	for _, record := range records {
+		inventory.TotalInKg += record.WeightInKg
+		inventory.ByVariety[record.Variety] += record.WeightInKg
+	}
+	return &inventory, nil
+}
+
+// GetStorageHistory returns every storage record for a batch
+func (c *RiceContract) GetStorageHistory(ctx contractapi.TransactionContextInterface, batchID string) ([]*StorageRecord, error) {
+	return c.storageRecords(ctx, richQuery(map[string]interface{}{"assetType": "storageRecord", "batchID": batchID}))
+}
+
+func (c *RiceContract) storageRecords(ctx contractapi.TransactionContextInterface, queryString string) ([]*StorageRecord, error) {
+	resultsIterator, err := ctx.GetStub().GetQueryResult(queryString)
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+	return storageRecordIterator(resultsIterator)
+}
+
+// Iterator function for storage records
+func storageRecordIterator(resultsIterator shim.StateQueryIteratorInterface) ([]*StorageRecord, error) {
+	var records []*StorageRecord
+	for resultsIterator.HasNext() {
+		queryResult, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		var record StorageRecord
+		err = json.Unmarshal(queryResult.Value, &record)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, &record)
+	}
+	return records, nil
+}
@@ -0,0 +1,56 @@
+package contracts
+
+import (
+	"testing"
+
+	"ricetypes"
+)
+
+func TestWarehouseCapacityAndShrinkage(t *testing.T) {
+	h := newContractHarness(t)
+	h.harvest("B1", 600)
+	h.harvest("B2", 500)
+	h.reject("Org2MSP", "RegisterWarehouse", []string{"WH1", "Karnal Silo", "Karnal", "-1"}, nil, ricetypes.ErrCodeInvalidArgument)
+	h.invoke("Org2MSP", "RegisterWarehouse", []string{"WH1", "Karnal Silo", "Karnal", "1000"}, nil)
+	h.reject("Org2MSP", "RegisterWarehouse", []string{"WH1", "Karnal Silo", "Karnal", "1000"}, nil, ricetypes.ErrCodeConflict)
+
+	var empty WarehouseInventory
+	h.read("Org1MSP", "GetWarehouseInventory", []string{"WH1"}, &empty)
+	if empty.TotalInKg != 0 || len(empty.Records) != 0 {
+		t.Errorf("inventory of an empty warehouse: %+v", empty)
+	}
+
+	h.reject("Org1MSP", "StoreBatch", []string{"ST1", "WH1", "B1", "A1", "600", ""}, nil, ricetypes.ErrCodeForbidden)
+	h.reject("Org2MSP", "StoreBatch", []string{"ST1", "WH1", "B9", "A1", "600", ""}, nil, ricetypes.ErrCodeNotFound)
+	h.reject("Org2MSP", "StoreBatch", []string{"ST1", "WH1", "B1", "A1", "0", ""}, nil, ricetypes.ErrCodeInvalidArgument)
+	h.reject("Org2MSP", "StoreBatch", []string{"ST1", "WH1", "B1", "A1", "600", "yesterday"}, nil, ricetypes.ErrCodeInvalidArgument)
+	h.invoke("Org2MSP", "StoreBatch", []string{"ST1", "WH1", "B1", "A1", "600", "2025-01-10T08:00:00Z"}, nil)
+	h.reject("Org2MSP", "StoreBatch", []string{"ST2", "WH1", "B1", "A2", "100", ""}, nil, ricetypes.ErrCodeConflict)
+	h.reject("Org2MSP", "StoreBatch", []string{"ST2", "WH1", "B2", "A2", "500", ""}, nil, ricetypes.ErrCodeConflict)
+
+	var inventory WarehouseInventory
+	h.read("Org1MSP", "GetWarehouseInventory", []string{"WH1"}, &inventory)
+	if inventory.TotalInKg != 600 || inventory.ByVariety["Basmati"] != 600 || len(inventory.Records) != 1 {
+		t.Errorf("inventory after storing B1: %+v", inventory)
+	}
+
+	h.reject("Org3MSP", "ReleaseBatch", []string{"ST1", "590", ""}, nil, ricetypes.ErrCodeForbidden)
+	h.reject("Org2MSP", "ReleaseBatch", []string{"ST1", "601", ""}, nil, ricetypes.ErrCodeInvalidArgument)
+	h.invoke("Org2MSP", "ReleaseBatch", []string{"ST1", "590", "2025-01-14T08:00:00Z"}, nil)
+	h.reject("Org2MSP", "ReleaseBatch", []string{"ST1", "590", ""}, nil, ricetypes.ErrCodeConflict)
+
+	var record StorageRecord
+	h.read("Org1MSP", "ReadStorageRecord", []string{"ST1"}, &record)
+	if record.Status != "Released" || record.ShrinkageInKg != 10 {
+		t.Errorf("released record %+v", record)
+	}
+
+	// Releasing B1 frees its capacity and lets it be stored again
+	h.invoke("Org2MSP", "StoreBatch", []string{"ST2", "WH1", "B2", "A2", "500", ""}, nil)
+	h.invoke("Org2MSP", "StoreBatch", []string{"ST3", "WH1", "B1", "A1", "500", ""}, nil)
+	var history []*StorageRecord
+	h.read("Org1MSP", "GetStorageHistory", []string{"B1"}, &history)
+	if len(history) != 2 {
+		t.Errorf("storage history of B1: %+v", history)
+	}
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/gin-gonic/gin"
+
+	"rice-frontend-app/apiclient"
+	"ricetypes"
+)
+
+// The versioned API under /api/v1 is described by openapi.yaml. The document
+// is served at /api/openapi.json, every /api/v1 request is validated against
+// it before a handler runs, and the apiclient package is generated from it,
+// so handlers answer with the same types other teams decode. Responses wrap
+// their payload in "data"; errors use the usual {"error": {...}} body.
+
+const apiV1Prefix = "/api/v1"
+
+//go:embed openapi.yaml
+var openAPISpec []byte
+
+func init() {
+	// Keep validation messages to the field and the rule it broke
+	openapi3.SchemaErrorDetailsDisabled = true
+}
+
+func loadOpenAPI() (*openapi3.T, routers.Router, error) {
+	doc, err := openapi3.NewLoader().LoadFromData(openAPISpec)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse openapi.yaml: %w", err)
+	}
+	if err := doc.Validate(openapi3.NewLoader().Context); err != nil {
+		return nil, nil, fmt.Errorf("invalid openapi.yaml: %w", err)
+	}
+	router, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		return nil, nil, err
+	}
+	return doc, router, nil
+}
+
+// validateRequest rejects requests that do not match the OpenAPI document.
+// Authentication has already run, so security requirements are not rechecked.
+func validateRequest(spec routers.Router) gin.HandlerFunc {
+	options := &openapi3filter.Options{AuthenticationFunc: openapi3filter.NoopAuthenticationFunc}
+	return func(c *gin.Context) {
+		route, pathParams, err := spec.FindRoute(c.Request)
+		if err != nil {
+			// Only reached when a handler is registered that the document does not describe
+			respondError(c, newAPIError(ErrCodeInternal, "%s %s is missing from openapi.yaml", c.Request.Method, c.FullPath()))
+			c.Abort()
+			return
+		}
+		err = openapi3filter.ValidateRequest(c.Request.Context(), &openapi3filter.RequestValidationInput{
+			Request:    c.Request,
+			PathParams: pathParams,
+			Route:      route,
+			Options:    options,
+		})
+		if err != nil {
+			respondError(c, newAPIError(ErrCodeInvalidArgument, "%s", validationMessage(err)))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// Shorten kin-openapi errors to where the request is wrong and why
+func validationMessage(err error) string {
+	var requestErr *openapi3filter.RequestError
+	if !errors.As(err, &requestErr) {
+		return err.Error()
+	}
+	where := "request body"
+	if requestErr.Parameter != nil {
+		where = fmt.Sprintf("%s parameter %s", requestErr.Parameter.In, requestErr.Parameter.Name)
+	}
+	var schemaErr *openapi3.SchemaError
+	if errors.As(requestErr.Err, &schemaErr) {
+		if pointer := schemaErr.JSONPointer(); len(pointer) > 0 {
+			where += " field " + strings.Join(pointer, ".")
+		}
+		return where + ": " + schemaErr.Reason
+	}
+	if requestErr.Err != nil {
+		return where + ": " + requestErr.Err.Error()
+	}
+	return where + ": " + requestErr.Reason
+}
+
+// The v1 request bodies map onto the ricetypes argument structs the contract
+// functions are pinned against; absent optional values are sent as zero.
+func intValue(value *int) int64 {
+	if value == nil {
+		return 0
+	}
+	return int64(*value)
+}
+
+func saleTerms(terms *apiclient.SaleTerms) ricetypes.SaleTerms {
+	if terms == nil {
+		return ricetypes.SaleTerms{}
+	}
+	sale := ricetypes.SaleTerms{UnitPrice: terms.UnitPrice, Currency: string(terms.Currency)}
+	if terms.TaxRateBps != nil {
+		sale.TaxRateBps = *terms.TaxRateBps
+	}
+	return sale
+}
+
+func respondTransaction(c *gin.Context, result *TransactionResult) {
+	c.JSON(http.StatusOK, apiclient.TransactionResponse{Data: apiclient.TransactionResult{
+		TransactionID: result.TransactionID,
+		BlockNumber:   result.BlockNumber,
+		Status:        result.Status,
+		Result:        &result.Result,
+	}})
+}
+
+func registerAPIRoutes(router *gin.Engine, doc *openapi3.T, spec routers.Router) {
+	specJSON, err := doc.MarshalJSON()
+	if err != nil {
+		panic(err)
+	}
+	router.GET("/api/openapi.json", func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json", specJSON)
+	})
+
+	v1 := router.Group(apiV1Prefix, validateRequest(spec))
+
+	v1.GET("/batches", func(c *gin.Context) {
+		start, end := c.Query("start"), c.Query("end")
+		batches := []apiclient.RiceBatch{}
+		var err error
+		if start != "" || end != "" {
+			err = evaluateJSON(callerFrom(c), "GetRiceBatchByRange", &batches, start, end)
+		} else {
+			err = evaluateJSON(callerFrom(c), "GetAllRiceBatches", &batches)
+		}
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		if batches == nil {
+			batches = []apiclient.RiceBatch{}
+		}
+		c.JSON(http.StatusOK, apiclient.BatchListResponse{Data: batches})
+	})
+
+	v1.POST("/batches", func(c *gin.Context) {
+		var req apiclient.CreateBatchRequest
+		if err := c.BindJSON(&req); err != nil {
+			respondError(c, newAPIError(ErrCodeInvalidArgument, "%v", err))
+			return
+		}
+		args := &ricetypes.CreateRiceBatchArgs{
+			BatchID:      req.BatchID,
+			Variety:      req.Variety,
+			HarvestDate:  req.HarvestDate,
+			QuantityInKg: req.QuantityInKg,
+			FarmerName:   req.FarmerName,
+			FieldID:      req.FieldID,
+		}
+		if inputs := req.FarmInputs; inputs != nil {
+			args.FieldEmissionsMethod = string(inputs.FieldEmissionsMethod)
+			args.WaterUsageM3 = intValue(inputs.WaterUsageM3)
+			args.FertiliserNKg = intValue(inputs.FertiliserNKg)
+			args.SeasonDays = intValue(inputs.SeasonDays)
+			args.FieldEmissionsKgCO2e = intValue(inputs.FieldEmissionsKgCO2e)
+			if inputs.CultivatedAreaHa != nil {
+				args.CultivatedAreaCentiHa = int64(math.Round(*inputs.CultivatedAreaHa * 100))
+			}
+		}
+		result, err := submitArgs(callerFrom(c), args)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		respondTransaction(c, result)
+	})
+
+	v1.GET("/batches/:batchID", func(c *gin.Context) {
+		var batch apiclient.RiceBatch
+		if err := evaluateJSON(callerFrom(c), "ReadRiceBatch", &batch, c.Param("batchID")); err != nil {
+			respondError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, apiclient.BatchResponse{Data: batch})
+	})
+
+	v1.GET("/batches/:batchID/history", func(c *gin.Context) {
+		history := []apiclient.HistoryRecord{}
+		if err := evaluateJSON(callerFrom(c), "GetRiceBatchHistory", &history, c.Param("batchID")); err != nil {
+			respondError(c, err)
+			return
+		}
+		if history == nil {
+			history = []apiclient.HistoryRecord{}
+		}
+		c.JSON(http.StatusOK, apiclient.BatchHistoryResponse{Data: history})
+	})
+
+	v1.POST("/batches/:batchID/dispatch", func(c *gin.Context) {
+		var req apiclient.DispatchRequest
+		if err := c.BindJSON(&req); err != nil {
+			respondError(c, newAPIError(ErrCodeInvalidArgument, "%v", err))
+			return
+		}
+		result, err := submitArgs(callerFrom(c), &ricetypes.DispatchToRetailerArgs{
+			BatchID:      c.Param("batchID"),
+			RetailerName: req.RetailerName,
+			SaleTerms:    saleTerms(req.Terms),
+		})
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		respondTransaction(c, result)
+	})
+
+	v1.POST("/orders", func(c *gin.Context) {
+		var req apiclient.CreateOrderRequest
+		if err := c.BindJSON(&req); err != nil {
+			respondError(c, newAPIError(ErrCodeInvalidArgument, "%v", err))
+			return
+		}
+		result, err := submitArgs(callerFrom(c), &ricetypes.CreateProcessingOrderArgs{
+			OrderID:      req.OrderID,
+			Variety:      req.Variety,
+			MillerName:   req.MillerName,
+			QuantityInKg: req.QuantityInKg,
+			SaleTerms:    saleTerms(req.Terms),
+		})
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		respondTransaction(c, result)
+	})
+
+	v1.POST("/orders/:orderID/match", func(c *gin.Context) {
+		var req apiclient.MatchOrderRequest
+		if err := c.BindJSON(&req); err != nil {
+			respondError(c, newAPIError(ErrCodeInvalidArgument, "%v", err))
+			return
+		}
+		result, err := submitArgs(callerFrom(c), &ricetypes.MatchProcessingOrderArgs{BatchID: req.BatchID, OrderID: c.Param("orderID")})
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		respondTransaction(c, result)
+	})
+
+	v1.GET("/units/:serial", func(c *gin.Context) {
+		var provenance apiclient.RetailUnitProvenance
+		if err := evaluateJSON(callerFrom(c), "VerifyRetailUnit", &provenance, c.Param("serial")); err != nil {
+			respondError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, apiclient.RetailUnitResponse{Data: provenance})
+	})
+
+	v1.GET("/footprints/:assetID", func(c *gin.Context) {
+		var footprint apiclient.Footprint
+		if err := evaluateJSON(callerFrom(c), "GetFootprint", &footprint, c.Param("assetID")); err != nil {
+			respondError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, apiclient.FootprintResponse{Data: footprint})
+	})
+}
@@ -0,0 +1,1862 @@
+// Package apiclient provides primitives to interact with the openapi HTTP API.
+//
+// Code generated by github.com/oapi-codegen/oapi-codegen/v2 version v2.4.1 DO NOT EDIT.
+package apiclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/oapi-codegen/runtime"
+)
+
+const (
+	BearerAuthScopes    = "bearerAuth.Scopes"
+	SessionCookieScopes = "sessionCookie.Scopes"
+)
+
+// Defines values for APIErrorCode.
+const (
+	BADLEDGERRESPONSE   APIErrorCode = "BAD_LEDGER_RESPONSE"
+	COMMITFAILED        APIErrorCode = "COMMIT_FAILED"
+	COMMITSTATUSUNKNOWN APIErrorCode = "COMMIT_STATUS_UNKNOWN"
+	CONFIGURATIONERROR  APIErrorCode = "CONFIGURATION_ERROR"
+	CONFLICT            APIErrorCode = "CONFLICT"
+	ENDORSEMENTFAILED   APIErrorCode = "ENDORSEMENT_FAILED"
+	FORBIDDEN           APIErrorCode = "FORBIDDEN"
+	GATEWAYTIMEOUT      APIErrorCode = "GATEWAY_TIMEOUT"
+	GATEWAYUNAVAILABLE  APIErrorCode = "GATEWAY_UNAVAILABLE"
+	INTERNAL            APIErrorCode = "INTERNAL"
+	INVALIDARGUMENT     APIErrorCode = "INVALID_ARGUMENT"
+	MVCCCONFLICT        APIErrorCode = "MVCC_CONFLICT"
+	NOTFOUND            APIErrorCode = "NOT_FOUND"
+	SUBMITFAILED        APIErrorCode = "SUBMIT_FAILED"
+	UNAUTHENTICATED     APIErrorCode = "UNAUTHENTICATED"
+)
+
+// Defines values for FarmInputsFieldEmissionsMethod.
+const (
+	Awd                FarmInputsFieldEmissionsMethod = "awd"
+	ContinuousFlooding FarmInputsFieldEmissionsMethod = "continuous-flooding"
+	Measured           FarmInputsFieldEmissionsMethod = "measured"
+	Rainfed            FarmInputsFieldEmissionsMethod = "rainfed"
+	SingleDrainage     FarmInputsFieldEmissionsMethod = "single-drainage"
+)
+
+// APIError defines model for APIError.
+type APIError struct {
+	// Code Stable error code; the HTTP status is derived from it
+	Code          APIErrorCode   `json:"code"`
+	Details       *[]ErrorDetail `json:"details,omitempty"`
+	Message       string         `json:"message"`
+	TransactionID *string        `json:"transactionID,omitempty"`
+}
+
+// APIErrorCode Stable error code; the HTTP status is derived from it
+type APIErrorCode string
+
+// AssetID defines model for AssetID.
+type AssetID = string
+
+// BatchFlag defines model for BatchFlag.
+type BatchFlag struct {
+	FlaggedAt string `json:"flaggedAt"`
+	FlaggedBy string `json:"flaggedBy"`
+	Reason    string `json:"reason"`
+	RuleID    string `json:"ruleID"`
+}
+
+// BatchHistoryResponse defines model for BatchHistoryResponse.
+type BatchHistoryResponse struct {
+	Data []HistoryRecord `json:"data"`
+}
+
+// BatchListResponse defines model for BatchListResponse.
+type BatchListResponse struct {
+	Data []RiceBatch `json:"data"`
+}
+
+// BatchResponse defines model for BatchResponse.
+type BatchResponse struct {
+	Data RiceBatch `json:"data"`
+}
+
+// Checkpoint defines model for Checkpoint.
+type Checkpoint struct {
+	Location   string `json:"location"`
+	Note       string `json:"note"`
+	RecordedBy string `json:"recordedBy"`
+	Timestamp  string `json:"timestamp"`
+}
+
+// CreateBatchRequest defines model for CreateBatchRequest.
+type CreateBatchRequest struct {
+	BatchID AssetID `json:"batchID"`
+
+	// FarmInputs Optional inputs for footprint accounting, sent as private data
+	FarmInputs   *FarmInputs `json:"farmInputs,omitempty"`
+	FarmerName   string      `json:"farmerName"`
+	FieldID      AssetID     `json:"fieldID"`
+	HarvestDate  Date        `json:"harvestDate"`
+	QuantityInKg int         `json:"quantityInKg"`
+	Variety      string      `json:"variety"`
+}
+
+// CreateOrderRequest defines model for CreateOrderRequest.
+type CreateOrderRequest struct {
+	MillerName   string  `json:"millerName"`
+	OrderID      AssetID `json:"orderID"`
+	QuantityInKg int     `json:"quantityInKg"`
+
+	// Terms Price terms; an invoice is raised when they are given
+	Terms   *SaleTerms `json:"terms,omitempty"`
+	Variety string     `json:"variety"`
+}
+
+// Currency defines model for Currency.
+type Currency = string
+
+// Date defines model for Date.
+type Date = string
+
+// DispatchRequest defines model for DispatchRequest.
+type DispatchRequest struct {
+	RetailerName string `json:"retailerName"`
+
+	// Terms Price terms; an invoice is raised when they are given
+	Terms *SaleTerms `json:"terms,omitempty"`
+}
+
+// ErrorDetail defines model for ErrorDetail.
+type ErrorDetail struct {
+	Address string `json:"address"`
+	Message string `json:"message"`
+	MspID   string `json:"mspID"`
+}
+
+// ErrorResponse defines model for ErrorResponse.
+type ErrorResponse struct {
+	Error APIError `json:"error"`
+}
+
+// FarmInputs Optional inputs for footprint accounting, sent as private data
+type FarmInputs struct {
+	CultivatedAreaHa *float64 `json:"cultivatedAreaHa,omitempty"`
+	FertiliserNKg    *int     `json:"fertiliserNKg,omitempty"`
+
+	// FieldEmissionsKgCO2e Measured field emissions, for the measured method
+	FieldEmissionsKgCO2e *int                           `json:"fieldEmissionsKgCO2e,omitempty"`
+	FieldEmissionsMethod FarmInputsFieldEmissionsMethod `json:"fieldEmissionsMethod"`
+	SeasonDays           *int                           `json:"seasonDays,omitempty"`
+	WaterUsageM3         *int                           `json:"waterUsageM3,omitempty"`
+}
+
+// FarmInputsFieldEmissionsMethod defines model for FarmInputs.FieldEmissionsMethod.
+type FarmInputsFieldEmissionsMethod string
+
+// Footprint defines model for Footprint.
+type Footprint struct {
+	Allocations    *[]FootprintAllocation `json:"allocations,omitempty"`
+	AssetID        string                 `json:"assetID"`
+	ByStage        map[string]int64       `json:"byStage"`
+	Co2eGrams      int64                  `json:"co2eGrams"`
+	Co2eGramsPerKg int64                  `json:"co2eGramsPerKg"`
+	Entries        *[]FootprintEntry      `json:"entries,omitempty"`
+	Kind           string                 `json:"kind"`
+	MassInKg       int                    `json:"massInKg"`
+	WaterLitres    int64                  `json:"waterLitres"`
+}
+
+// FootprintAllocation defines model for FootprintAllocation.
+type FootprintAllocation struct {
+	Co2eGrams      int64  `json:"co2eGrams"`
+	MassInKg       int    `json:"massInKg"`
+	SourceID       string `json:"sourceID"`
+	SourceMassInKg int    `json:"sourceMassInKg"`
+	WaterLitres    int64  `json:"waterLitres"`
+}
+
+// FootprintEntry defines model for FootprintEntry.
+type FootprintEntry struct {
+	Co2eGrams   int64  `json:"co2eGrams"`
+	Description string `json:"description"`
+	RecordedAt  string `json:"recordedAt"`
+	RecordedBy  string `json:"recordedBy"`
+	Stage       string `json:"stage"`
+	WaterLitres int64  `json:"waterLitres"`
+}
+
+// FootprintResponse defines model for FootprintResponse.
+type FootprintResponse struct {
+	Data Footprint `json:"data"`
+}
+
+// HistoryRecord defines model for HistoryRecord.
+type HistoryRecord struct {
+	IsDelete  bool       `json:"isDelete"`
+	Record    *RiceBatch `json:"record,omitempty"`
+	Timestamp string     `json:"timestamp"`
+	TxId      string     `json:"txId"`
+}
+
+// LoginRequest defines model for LoginRequest.
+type LoginRequest struct {
+	Password string `json:"password"`
+	Username string `json:"username"`
+}
+
+// MatchOrderRequest defines model for MatchOrderRequest.
+type MatchOrderRequest struct {
+	BatchID AssetID `json:"batchID"`
+}
+
+// PackagingRun defines model for PackagingRun.
+type PackagingRun struct {
+	BatchID     string `json:"batchID"`
+	BestBefore  string `json:"bestBefore"`
+	FirstSerial string `json:"firstSerial"`
+	LastSerial  string `json:"lastSerial"`
+	PackDate    string `json:"packDate"`
+	PackSizeKg  int    `json:"packSizeKg"`
+	PackedBy    string `json:"packedBy"`
+	RunID       string `json:"runID"`
+	UnitCount   int    `json:"unitCount"`
+}
+
+// RetailUnitProvenance defines model for RetailUnitProvenance.
+type RetailUnitProvenance struct {
+	Batch     *RiceBatch       `json:"batch,omitempty"`
+	Expired   bool             `json:"expired"`
+	History   *[]HistoryRecord `json:"history,omitempty"`
+	Run       *PackagingRun    `json:"run,omitempty"`
+	Serial    string           `json:"serial"`
+	Shipments *[]Shipment      `json:"shipments,omitempty"`
+	Storage   *[]StorageRecord `json:"storage,omitempty"`
+}
+
+// RetailUnitResponse defines model for RetailUnitResponse.
+type RetailUnitResponse struct {
+	Data RetailUnitProvenance `json:"data"`
+}
+
+// RiceBatch defines model for RiceBatch.
+type RiceBatch struct {
+	BatchID        string    `json:"batchID"`
+	Certifications *[]string `json:"certifications,omitempty"`
+	CropSeason     *string   `json:"cropSeason,omitempty"`
+
+	// DeliveredInKg Quantity delivered without discrepancy so far
+	DeliveredInKg *int `json:"deliveredInKg,omitempty"`
+
+	// FarmerName Farmer who harvested the batch
+	FarmerName  *string      `json:"farmerName,omitempty"`
+	FieldID     *string      `json:"fieldID,omitempty"`
+	Flags       *[]BatchFlag `json:"flags,omitempty"`
+	HarvestDate string       `json:"harvestDate"`
+
+	// PackedInKg Quantity packed into retail units so far
+	PackedInKg *int `json:"packedInKg,omitempty"`
+
+	// ProducedBy Current holder of the batch
+	ProducedBy   string `json:"producedBy"`
+	QuantityInKg int    `json:"quantityInKg"`
+
+	// ShippedInKg Quantity put on shipments so far
+	ShippedInKg *int   `json:"shippedInKg,omitempty"`
+	Status      string `json:"status"`
+	Variety     string `json:"variety"`
+}
+
+// SaleTerms Price terms; an invoice is raised when they are given
+type SaleTerms struct {
+	Currency Currency `json:"currency"`
+
+	// TaxRateBps Tax rate in basis points, 1800 = 18%
+	TaxRateBps *int64 `json:"taxRateBps,omitempty"`
+
+	// UnitPrice Minor currency units per kg
+	UnitPrice int64 `json:"unitPrice"`
+}
+
+// Session defines model for Session.
+type Session struct {
+	ExpiresAt time.Time `json:"expiresAt"`
+	Message   string    `json:"message"`
+
+	// Token Session token to send as a Bearer token
+	Token string `json:"token"`
+}
+
+// Shipment defines model for Shipment.
+type Shipment struct {
+	ActualArrival          *string       `json:"actualArrival,omitempty"`
+	BatchID                string        `json:"batchID"`
+	Carrier                string        `json:"carrier"`
+	Checkpoints            *[]Checkpoint `json:"checkpoints,omitempty"`
+	DeliveredQuantityInKg  int           `json:"deliveredQuantityInKg"`
+	DepartureTime          string        `json:"departureTime"`
+	Destination            string        `json:"destination"`
+	DiscrepancyID          *string       `json:"discrepancyID,omitempty"`
+	DispatchedQuantityInKg int           `json:"dispatchedQuantityInKg"`
+	ExpectedArrival        string        `json:"expectedArrival"`
+	Origin                 string        `json:"origin"`
+	SealNumbers            *[]string     `json:"sealNumbers,omitempty"`
+	ShipmentID             string        `json:"shipmentID"`
+	StartedBy              string        `json:"startedBy"`
+	Status                 string        `json:"status"`
+	VehicleID              string        `json:"vehicleID"`
+}
+
+// StorageRecord defines model for StorageRecord.
+type StorageRecord struct {
+	BatchID       string  `json:"batchID"`
+	Bin           string  `json:"bin"`
+	EntryDate     string  `json:"entryDate"`
+	ExitDate      *string `json:"exitDate,omitempty"`
+	ShrinkageInKg int     `json:"shrinkageInKg"`
+	Status        string  `json:"status"`
+	StorageID     string  `json:"storageID"`
+	Variety       string  `json:"variety"`
+	WarehouseID   string  `json:"warehouseID"`
+	WeightInKg    int     `json:"weightInKg"`
+	WeightOutKg   int     `json:"weightOutKg"`
+}
+
+// TransactionResponse defines model for TransactionResponse.
+type TransactionResponse struct {
+	// Data A committed transaction and the message its contract returned
+	Data TransactionResult `json:"data"`
+}
+
+// TransactionResult A committed transaction and the message its contract returned
+type TransactionResult struct {
+	// BlockNumber Block the transaction was committed in
+	BlockNumber uint64 `json:"blockNumber"`
+
+	// Result Message returned by the contract
+	Result *string `json:"result,omitempty"`
+
+	// Status Validation code of the transaction, VALID once committed
+	Status        string `json:"status"`
+	TransactionID string `json:"transactionID"`
+}
+
+// BatchID defines model for BatchID.
+type BatchID = AssetID
+
+// Error defines model for Error.
+type Error = ErrorResponse
+
+// ListBatchesParams defines parameters for ListBatches.
+type ListBatchesParams struct {
+	// Start First batch ID of the range, inclusive
+	Start *string `form:"start,omitempty" json:"start,omitempty"`
+
+	// End Batch ID the range stops before
+	End *string `form:"end,omitempty" json:"end,omitempty"`
+}
+
+// LoginJSONRequestBody defines body for Login for application/json ContentType.
+type LoginJSONRequestBody = LoginRequest
+
+// CreateBatchJSONRequestBody defines body for CreateBatch for application/json ContentType.
+type CreateBatchJSONRequestBody = CreateBatchRequest
+
+// DispatchBatchJSONRequestBody defines body for DispatchBatch for application/json ContentType.
+type DispatchBatchJSONRequestBody = DispatchRequest
+
+// CreateOrderJSONRequestBody defines body for CreateOrder for application/json ContentType.
+type CreateOrderJSONRequestBody = CreateOrderRequest
+
+// MatchOrderJSONRequestBody defines body for MatchOrder for application/json ContentType.
+type MatchOrderJSONRequestBody = MatchOrderRequest
+
+// RequestEditorFn  is the function signature for the RequestEditor callback function
+type RequestEditorFn func(ctx context.Context, req *http.Request) error
+
+// Doer performs HTTP requests.
+//
+// The standard http.Client implements this interface.
+type HttpRequestDoer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Client which conforms to the OpenAPI3 specification for this service.
+type Client struct {
+	// The endpoint of the server conforming to this interface, with scheme,
+	// https://api.deepmap.com for example. This can contain a path relative
+	// to the server, such as https://api.deepmap.com/dev-test, and all the
+	// paths in the swagger spec will be appended to the server.
+	Server string
+
+	// Doer for performing requests, typically a *http.Client with any
+	// customized settings, such as certificate chains.
+	Client HttpRequestDoer
+
+	// A list of callbacks for modifying requests which are generated before sending over
+	// the network.
+	RequestEditors []RequestEditorFn
+}
+
+// ClientOption allows setting custom parameters during construction
+type ClientOption func(*Client) error
+
+// Creates a new Client, with reasonable defaults
+func NewClient(server string, opts ...ClientOption) (*Client, error) {
+	// create a client with sane default values
+	client := Client{
+		Server: server,
+	}
+	// mutate client and add all optional params
+	for _, o := range opts {
+		if err := o(&client); err != nil {
+			return nil, err
+		}
+	}
+	// ensure the server URL always has a trailing slash
+	if !strings.HasSuffix(client.Server, "/") {
+		client.Server += "/"
+	}
+	// create httpClient, if not already present
+	if client.Client == nil {
+		client.Client = &http.Client{}
+	}
+	return &client, nil
+}
+
+// WithHTTPClient allows overriding the default Doer, which is
+// automatically created using http.Client. This is useful for tests.
+func WithHTTPClient(doer HttpRequestDoer) ClientOption {
+	return func(c *Client) error {
+		c.Client = doer
+		return nil
+	}
+}
+
+// WithRequestEditorFn allows setting up a callback function, which will be
+// called right before sending the request. This can be used to mutate the request.
+func WithRequestEditorFn(fn RequestEditorFn) ClientOption {
+	return func(c *Client) error {
+		c.RequestEditors = append(c.RequestEditors, fn)
+		return nil
+	}
+}
+
+// The interface specification for the client above.
+type ClientInterface interface {
+	// LoginWithBody request with any body
+	LoginWithBody(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	Login(ctx context.Context, body LoginJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// ListBatches request
+	ListBatches(ctx context.Context, params *ListBatchesParams, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// CreateBatchWithBody request with any body
+	CreateBatchWithBody(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	CreateBatch(ctx context.Context, body CreateBatchJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// GetBatch request
+	GetBatch(ctx context.Context, batchID BatchID, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// DispatchBatchWithBody request with any body
+	DispatchBatchWithBody(ctx context.Context, batchID BatchID, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	DispatchBatch(ctx context.Context, batchID BatchID, body DispatchBatchJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// GetBatchHistory request
+	GetBatchHistory(ctx context.Context, batchID BatchID, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// GetFootprint request
+	GetFootprint(ctx context.Context, assetID AssetID, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// CreateOrderWithBody request with any body
+	CreateOrderWithBody(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	CreateOrder(ctx context.Context, body CreateOrderJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// MatchOrderWithBody request with any body
+	MatchOrderWithBody(ctx context.Context, orderID AssetID, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	MatchOrder(ctx context.Context, orderID AssetID, body MatchOrderJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// VerifyRetailUnit request
+	VerifyRetailUnit(ctx context.Context, serial AssetID, reqEditors ...RequestEditorFn) (*http.Response, error)
+}
+
+func (c *Client) LoginWithBody(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewLoginRequestWithBody(c.Server, contentType, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) Login(ctx context.Context, body LoginJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewLoginRequest(c.Server, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) ListBatches(ctx context.Context, params *ListBatchesParams, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewListBatchesRequest(c.Server, params)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) CreateBatchWithBody(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewCreateBatchRequestWithBody(c.Server, contentType, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) CreateBatch(ctx context.Context, body CreateBatchJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewCreateBatchRequest(c.Server, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) GetBatch(ctx context.Context, batchID BatchID, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewGetBatchRequest(c.Server, batchID)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) DispatchBatchWithBody(ctx context.Context, batchID BatchID, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewDispatchBatchRequestWithBody(c.Server, batchID, contentType, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) DispatchBatch(ctx context.Context, batchID BatchID, body DispatchBatchJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewDispatchBatchRequest(c.Server, batchID, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) GetBatchHistory(ctx context.Context, batchID BatchID, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewGetBatchHistoryRequest(c.Server, batchID)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) GetFootprint(ctx context.Context, assetID AssetID, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewGetFootprintRequest(c.Server, assetID)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) CreateOrderWithBody(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewCreateOrderRequestWithBody(c.Server, contentType, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) CreateOrder(ctx context.Context, body CreateOrderJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewCreateOrderRequest(c.Server, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) MatchOrderWithBody(ctx context.Context, orderID AssetID, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewMatchOrderRequestWithBody(c.Server, orderID, contentType, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) MatchOrder(ctx context.Context, orderID AssetID, body MatchOrderJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewMatchOrderRequest(c.Server, orderID, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) VerifyRetailUnit(ctx context.Context, serial AssetID, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewVerifyRetailUnitRequest(c.Server, serial)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+// NewLoginRequest calls the generic Login builder with application/json body
+func NewLoginRequest(server string, body LoginJSONRequestBody) (*http.Request, error) {
+	var bodyReader io.Reader
+	buf, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	bodyReader = bytes.NewReader(buf)
+	return NewLoginRequestWithBody(server, "application/json", bodyReader)
+}
+
+// NewLoginRequestWithBody generates requests for Login with any type of body
+func NewLoginRequestWithBody(server string, contentType string, body io.Reader) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/auth/login")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", queryURL.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Content-Type", contentType)
+
+	return req, nil
+}
+
+// NewListBatchesRequest generates requests for ListBatches
+func NewListBatchesRequest(server string, params *ListBatchesParams) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/v1/batches")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if params != nil {
+		queryValues := queryURL.Query()
+
+		if params.Start != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "start", runtime.ParamLocationQuery, *params.Start); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		if params.End != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "end", runtime.ParamLocationQuery, *params.End); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		queryURL.RawQuery = queryValues.Encode()
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewCreateBatchRequest calls the generic CreateBatch builder with application/json body
+func NewCreateBatchRequest(server string, body CreateBatchJSONRequestBody) (*http.Request, error) {
+	var bodyReader io.Reader
+	buf, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	bodyReader = bytes.NewReader(buf)
+	return NewCreateBatchRequestWithBody(server, "application/json", bodyReader)
+}
+
+// NewCreateBatchRequestWithBody generates requests for CreateBatch with any type of body
+func NewCreateBatchRequestWithBody(server string, contentType string, body io.Reader) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/v1/batches")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", queryURL.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Content-Type", contentType)
+
+	return req, nil
+}
+
+// NewGetBatchRequest generates requests for GetBatch
+func NewGetBatchRequest(server string, batchID BatchID) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParamWithLocation("simple", false, "batchID", runtime.ParamLocationPath, batchID)
+	if err != nil {
+		return nil, err
+	}
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/v1/batches/%s", pathParam0)
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewDispatchBatchRequest calls the generic DispatchBatch builder with application/json body
+func NewDispatchBatchRequest(server string, batchID BatchID, body DispatchBatchJSONRequestBody) (*http.Request, error) {
+	var bodyReader io.Reader
+	buf, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	bodyReader = bytes.NewReader(buf)
+	return NewDispatchBatchRequestWithBody(server, batchID, "application/json", bodyReader)
+}
+
+// NewDispatchBatchRequestWithBody generates requests for DispatchBatch with any type of body
+func NewDispatchBatchRequestWithBody(server string, batchID BatchID, contentType string, body io.Reader) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParamWithLocation("simple", false, "batchID", runtime.ParamLocationPath, batchID)
+	if err != nil {
+		return nil, err
+	}
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/v1/batches/%s/dispatch", pathParam0)
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", queryURL.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Content-Type", contentType)
+
+	return req, nil
+}
+
+// NewGetBatchHistoryRequest generates requests for GetBatchHistory
+func NewGetBatchHistoryRequest(server string, batchID BatchID) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParamWithLocation("simple", false, "batchID", runtime.ParamLocationPath, batchID)
+	if err != nil {
+		return nil, err
+	}
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/v1/batches/%s/history", pathParam0)
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewGetFootprintRequest generates requests for GetFootprint
+func NewGetFootprintRequest(server string, assetID AssetID) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParamWithLocation("simple", false, "assetID", runtime.ParamLocationPath, assetID)
+	if err != nil {
+		return nil, err
+	}
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/v1/footprints/%s", pathParam0)
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewCreateOrderRequest calls the generic CreateOrder builder with application/json body
+func NewCreateOrderRequest(server string, body CreateOrderJSONRequestBody) (*http.Request, error) {
+	var bodyReader io.Reader
+	buf, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	bodyReader = bytes.NewReader(buf)
+	return NewCreateOrderRequestWithBody(server, "application/json", bodyReader)
+}
+
+// NewCreateOrderRequestWithBody generates requests for CreateOrder with any type of body
+func NewCreateOrderRequestWithBody(server string, contentType string, body io.Reader) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/v1/orders")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", queryURL.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Content-Type", contentType)
+
+	return req, nil
+}
+
+// NewMatchOrderRequest calls the generic MatchOrder builder with application/json body
+func NewMatchOrderRequest(server string, orderID AssetID, body MatchOrderJSONRequestBody) (*http.Request, error) {
+	var bodyReader io.Reader
+	buf, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	bodyReader = bytes.NewReader(buf)
+	return NewMatchOrderRequestWithBody(server, orderID, "application/json", bodyReader)
+}
+
+// NewMatchOrderRequestWithBody generates requests for MatchOrder with any type of body
+func NewMatchOrderRequestWithBody(server string, orderID AssetID, contentType string, body io.Reader) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParamWithLocation("simple", false, "orderID", runtime.ParamLocationPath, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/v1/orders/%s/match", pathParam0)
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", queryURL.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Content-Type", contentType)
+
+	return req, nil
+}
+
+// NewVerifyRetailUnitRequest generates requests for VerifyRetailUnit
+func NewVerifyRetailUnitRequest(server string, serial AssetID) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParamWithLocation("simple", false, "serial", runtime.ParamLocationPath, serial)
+	if err != nil {
+		return nil, err
+	}
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/v1/units/%s", pathParam0)
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+func (c *Client) applyEditors(ctx context.Context, req *http.Request, additionalEditors []RequestEditorFn) error {
+	for _, r := range c.RequestEditors {
+		if err := r(ctx, req); err != nil {
+			return err
+		}
+	}
+	for _, r := range additionalEditors {
+		if err := r(ctx, req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ClientWithResponses builds on ClientInterface to offer response payloads
+type ClientWithResponses struct {
+	ClientInterface
+}
+
+// NewClientWithResponses creates a new ClientWithResponses, which wraps
+// Client with return type handling
+func NewClientWithResponses(server string, opts ...ClientOption) (*ClientWithResponses, error) {
+	client, err := NewClient(server, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &ClientWithResponses{client}, nil
+}
+
+// WithBaseURL overrides the baseURL.
+func WithBaseURL(baseURL string) ClientOption {
+	return func(c *Client) error {
+		newBaseURL, err := url.Parse(baseURL)
+		if err != nil {
+			return err
+		}
+		c.Server = newBaseURL.String()
+		return nil
+	}
+}
+
+// ClientWithResponsesInterface is the interface specification for the client with responses above.
+type ClientWithResponsesInterface interface {
+	// LoginWithBodyWithResponse request with any body
+	LoginWithBodyWithResponse(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*LoginResponse, error)
+
+	LoginWithResponse(ctx context.Context, body LoginJSONRequestBody, reqEditors ...RequestEditorFn) (*LoginResponse, error)
+
+	// ListBatchesWithResponse request
+	ListBatchesWithResponse(ctx context.Context, params *ListBatchesParams, reqEditors ...RequestEditorFn) (*ListBatchesResponse, error)
+
+	// CreateBatchWithBodyWithResponse request with any body
+	CreateBatchWithBodyWithResponse(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*CreateBatchResponse, error)
+
+	CreateBatchWithResponse(ctx context.Context, body CreateBatchJSONRequestBody, reqEditors ...RequestEditorFn) (*CreateBatchResponse, error)
+
+	// GetBatchWithResponse request
+	GetBatchWithResponse(ctx context.Context, batchID BatchID, reqEditors ...RequestEditorFn) (*GetBatchResponse, error)
+
+	// DispatchBatchWithBodyWithResponse request with any body
+	DispatchBatchWithBodyWithResponse(ctx context.Context, batchID BatchID, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*DispatchBatchResponse, error)
+
+	DispatchBatchWithResponse(ctx context.Context, batchID BatchID, body DispatchBatchJSONRequestBody, reqEditors ...RequestEditorFn) (*DispatchBatchResponse, error)
+
+	// GetBatchHistoryWithResponse request
+	GetBatchHistoryWithResponse(ctx context.Context, batchID BatchID, reqEditors ...RequestEditorFn) (*GetBatchHistoryResponse, error)
+
+	// GetFootprintWithResponse request
+	GetFootprintWithResponse(ctx context.Context, assetID AssetID, reqEditors ...RequestEditorFn) (*GetFootprintResponse, error)
+
+	// CreateOrderWithBodyWithResponse request with any body
+	CreateOrderWithBodyWithResponse(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*CreateOrderResponse, error)
+
+	CreateOrderWithResponse(ctx context.Context, body CreateOrderJSONRequestBody, reqEditors ...RequestEditorFn) (*CreateOrderResponse, error)
+
+	// MatchOrderWithBodyWithResponse request with any body
+	MatchOrderWithBodyWithResponse(ctx context.Context, orderID AssetID, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*MatchOrderResponse, error)
+
+	MatchOrderWithResponse(ctx context.Context, orderID AssetID, body MatchOrderJSONRequestBody, reqEditors ...RequestEditorFn) (*MatchOrderResponse, error)
+
+	// VerifyRetailUnitWithResponse request
+	VerifyRetailUnitWithResponse(ctx context.Context, serial AssetID, reqEditors ...RequestEditorFn) (*VerifyRetailUnitResponse, error)
+}
+
+type LoginResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *Session
+	JSONDefault  *Error
+}
+
+// Status returns HTTPResponse.Status
+func (r LoginResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r LoginResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type ListBatchesResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *BatchListResponse
+	JSONDefault  *Error
+}
+
+// Status returns HTTPResponse.Status
+func (r ListBatchesResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r ListBatchesResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type CreateBatchResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *TransactionResponse
+	JSONDefault  *Error
+}
+
+// Status returns HTTPResponse.Status
+func (r CreateBatchResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r CreateBatchResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type GetBatchResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *BatchResponse
+	JSONDefault  *Error
+}
+
+// Status returns HTTPResponse.Status
+func (r GetBatchResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r GetBatchResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type DispatchBatchResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *TransactionResponse
+	JSONDefault  *Error
+}
+
+// Status returns HTTPResponse.Status
+func (r DispatchBatchResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r DispatchBatchResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type GetBatchHistoryResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *BatchHistoryResponse
+	JSONDefault  *Error
+}
+
+// Status returns HTTPResponse.Status
+func (r GetBatchHistoryResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r GetBatchHistoryResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type GetFootprintResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *FootprintResponse
+	JSONDefault  *Error
+}
+
+// Status returns HTTPResponse.Status
+func (r GetFootprintResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r GetFootprintResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type CreateOrderResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *TransactionResponse
+	JSONDefault  *Error
+}
+
+// Status returns HTTPResponse.Status
+func (r CreateOrderResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r CreateOrderResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type MatchOrderResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *TransactionResponse
+	JSONDefault  *Error
+}
+
+// Status returns HTTPResponse.Status
+func (r MatchOrderResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r MatchOrderResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type VerifyRetailUnitResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *RetailUnitResponse
+	JSONDefault  *Error
+}
+
+// Status returns HTTPResponse.Status
+func (r VerifyRetailUnitResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r VerifyRetailUnitResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+// LoginWithBodyWithResponse request with arbitrary body returning *LoginResponse
+func (c *ClientWithResponses) LoginWithBodyWithResponse(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*LoginResponse, error) {
+	rsp, err := c.LoginWithBody(ctx, contentType, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseLoginResponse(rsp)
+}
+
+func (c *ClientWithResponses) LoginWithResponse(ctx context.Context, body LoginJSONRequestBody, reqEditors ...RequestEditorFn) (*LoginResponse, error) {
+	rsp, err := c.Login(ctx, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseLoginResponse(rsp)
+}
+
+// ListBatchesWithResponse request returning *ListBatchesResponse
+func (c *ClientWithResponses) ListBatchesWithResponse(ctx context.Context, params *ListBatchesParams, reqEditors ...RequestEditorFn) (*ListBatchesResponse, error) {
+	rsp, err := c.ListBatches(ctx, params, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseListBatchesResponse(rsp)
+}
+
+// CreateBatchWithBodyWithResponse request with arbitrary body returning *CreateBatchResponse
+func (c *ClientWithResponses) CreateBatchWithBodyWithResponse(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*CreateBatchResponse, error) {
+	rsp, err := c.CreateBatchWithBody(ctx, contentType, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseCreateBatchResponse(rsp)
+}
+
+func (c *ClientWithResponses) CreateBatchWithResponse(ctx context.Context, body CreateBatchJSONRequestBody, reqEditors ...RequestEditorFn) (*CreateBatchResponse, error) {
+	rsp, err := c.CreateBatch(ctx, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseCreateBatchResponse(rsp)
+}
+
+// GetBatchWithResponse request returning *GetBatchResponse
+func (c *ClientWithResponses) GetBatchWithResponse(ctx context.Context, batchID BatchID, reqEditors ...RequestEditorFn) (*GetBatchResponse, error) {
+	rsp, err := c.GetBatch(ctx, batchID, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseGetBatchResponse(rsp)
+}
+
+// DispatchBatchWithBodyWithResponse request with arbitrary body returning *DispatchBatchResponse
+func (c *ClientWithResponses) DispatchBatchWithBodyWithResponse(ctx context.Context, batchID BatchID, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*DispatchBatchResponse, error) {
+	rsp, err := c.DispatchBatchWithBody(ctx, batchID, contentType, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseDispatchBatchResponse(rsp)
+}
+
+func (c *ClientWithResponses) DispatchBatchWithResponse(ctx context.Context, batchID BatchID, body DispatchBatchJSONRequestBody, reqEditors ...RequestEditorFn) (*DispatchBatchResponse, error) {
+	rsp, err := c.DispatchBatch(ctx, batchID, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseDispatchBatchResponse(rsp)
+}
+
+// GetBatchHistoryWithResponse request returning *GetBatchHistoryResponse
+func (c *ClientWithResponses) GetBatchHistoryWithResponse(ctx context.Context, batchID BatchID, reqEditors ...RequestEditorFn) (*GetBatchHistoryResponse, error) {
+	rsp, err := c.GetBatchHistory(ctx, batchID, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseGetBatchHistoryResponse(rsp)
+}
+
+// GetFootprintWithResponse request returning *GetFootprintResponse
+func (c *ClientWithResponses) GetFootprintWithResponse(ctx context.Context, assetID AssetID, reqEditors ...RequestEditorFn) (*GetFootprintResponse, error) {
+	rsp, err := c.GetFootprint(ctx, assetID, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseGetFootprintResponse(rsp)
+}
+
+// CreateOrderWithBodyWithResponse request with arbitrary body returning *CreateOrderResponse
+func (c *ClientWithResponses) CreateOrderWithBodyWithResponse(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*CreateOrderResponse, error) {
+	rsp, err := c.CreateOrderWithBody(ctx, contentType, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseCreateOrderResponse(rsp)
+}
+
+func (c *ClientWithResponses) CreateOrderWithResponse(ctx context.Context, body CreateOrderJSONRequestBody, reqEditors ...RequestEditorFn) (*CreateOrderResponse, error) {
+	rsp, err := c.CreateOrder(ctx, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseCreateOrderResponse(rsp)
+}
+
+// MatchOrderWithBodyWithResponse request with arbitrary body returning *MatchOrderResponse
+func (c *ClientWithResponses) MatchOrderWithBodyWithResponse(ctx context.Context, orderID AssetID, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*MatchOrderResponse, error) {
+	rsp, err := c.MatchOrderWithBody(ctx, orderID, contentType, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseMatchOrderResponse(rsp)
+}
+
+func (c *ClientWithResponses) MatchOrderWithResponse(ctx context.Context, orderID AssetID, body MatchOrderJSONRequestBody, reqEditors ...RequestEditorFn) (*MatchOrderResponse, error) {
+	rsp, err := c.MatchOrder(ctx, orderID, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseMatchOrderResponse(rsp)
+}
+
+// VerifyRetailUnitWithResponse request returning *VerifyRetailUnitResponse
+func (c *ClientWithResponses) VerifyRetailUnitWithResponse(ctx context.Context, serial AssetID, reqEditors ...RequestEditorFn) (*VerifyRetailUnitResponse, error) {
+	rsp, err := c.VerifyRetailUnit(ctx, serial, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseVerifyRetailUnitResponse(rsp)
+}
+
+// ParseLoginResponse parses an HTTP response from a LoginWithResponse call
+func ParseLoginResponse(rsp *http.Response) (*LoginResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &LoginResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest Session
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && true:
+		var dest Error
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSONDefault = &dest
+
+	}
+
+	return response, nil
+}
+
+// ParseListBatchesResponse parses an HTTP response from a ListBatchesWithResponse call
+func ParseListBatchesResponse(rsp *http.Response) (*ListBatchesResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &ListBatchesResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest BatchListResponse
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && true:
+		var dest Error
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSONDefault = &dest
+
+	}
+
+	return response, nil
+}
+
+// ParseCreateBatchResponse parses an HTTP response from a CreateBatchWithResponse call
+func ParseCreateBatchResponse(rsp *http.Response) (*CreateBatchResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &CreateBatchResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest TransactionResponse
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && true:
+		var dest Error
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSONDefault = &dest
+
+	}
+
+	return response, nil
+}
+
+// ParseGetBatchResponse parses an HTTP response from a GetBatchWithResponse call
+func ParseGetBatchResponse(rsp *http.Response) (*GetBatchResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &GetBatchResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest BatchResponse
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && true:
+		var dest Error
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSONDefault = &dest
+
+	}
+
+	return response, nil
+}
+
+// ParseDispatchBatchResponse parses an HTTP response from a DispatchBatchWithResponse call
+func ParseDispatchBatchResponse(rsp *http.Response) (*DispatchBatchResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &DispatchBatchResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest TransactionResponse
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && true:
+		var dest Error
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSONDefault = &dest
+
+	}
+
+	return response, nil
+}
+
+// ParseGetBatchHistoryResponse parses an HTTP response from a GetBatchHistoryWithResponse call
+func ParseGetBatchHistoryResponse(rsp *http.Response) (*GetBatchHistoryResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &GetBatchHistoryResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest BatchHistoryResponse
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && true:
+		var dest Error
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSONDefault = &dest
+
+	}
+
+	return response, nil
+}
+
+// ParseGetFootprintResponse parses an HTTP response from a GetFootprintWithResponse call
+func ParseGetFootprintResponse(rsp *http.Response) (*GetFootprintResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &GetFootprintResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest FootprintResponse
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && true:
+		var dest Error
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSONDefault = &dest
+
+	}
+
+	return response, nil
+}
+
+// ParseCreateOrderResponse parses an HTTP response from a CreateOrderWithResponse call
+func ParseCreateOrderResponse(rsp *http.Response) (*CreateOrderResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &CreateOrderResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest TransactionResponse
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && true:
+		var dest Error
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSONDefault = &dest
+
+	}
+
+	return response, nil
+}
+
+// ParseMatchOrderResponse parses an HTTP response from a MatchOrderWithResponse call
+func ParseMatchOrderResponse(rsp *http.Response) (*MatchOrderResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &MatchOrderResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest TransactionResponse
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && true:
+		var dest Error
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSONDefault = &dest
+
+	}
+
+	return response, nil
+}
+
+// ParseVerifyRetailUnitResponse parses an HTTP response from a VerifyRetailUnitWithResponse call
+func ParseVerifyRetailUnitResponse(rsp *http.Response) (*VerifyRetailUnitResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &VerifyRetailUnitResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest RetailUnitResponse
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && true:
+		var dest Error
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSONDefault = &dest
+
+	}
+
+	return response, nil
+}
@@ -0,0 +1,620 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Web users sign in with a local password or through OIDC and receive a
+// session token, set as an HttpOnly cookie and also accepted as a Bearer
+// token for scripts and devices. Every user is mapped to one org and to the
+// enrolled Fabric identity (a wallet label, or a certificate and key
+// directory) their transactions are signed with, so the ledger records the
+// actual user. Users who keep their key on their own device are mapped to a
+// certificate alone and sign through the offline routes. Public provenance routes and background jobs keep signing with the org's
+// identity from the network config.
+
+const sessionCookie = "rice_session"
+const sessionIssuer = "rice-frontend"
+const loginRequestsPerMinute = 10
+
+// Paths reachable without a session
+var anonymousPrefixes = []string{"/public/", "/provenance/", "/01/", "/api/public/"}
+var anonymousPaths = map[string]bool{
+	"/":                       true,
+	"/api/auth/login":         true,
+	"/api/auth/oidc/login":    true,
+	"/api/auth/oidc/callback": true,
+	"/api/openapi.json":       true,
+}
+
+type User struct {
+	Username     string `json:"username"`
+	PasswordHash string `json:"passwordHash,omitempty"` // bcrypt; empty for OIDC-only users
+	OIDCSubject  string `json:"oidcSubject,omitempty"`  // Value of the configured OIDC claim
+	Org          string `json:"org"`
+	Wallet       string `json:"wallet,omitempty"`   // Enrolled identity the user signs with: a wallet label,
+	CertPath     string `json:"certPath,omitempty"` // or a certificate and key directory on disk
+	KeyPath      string `json:"keyPath,omitempty"`
+	Certificate  string `json:"certificate,omitempty"` // PEM, for users who sign offline
+	Admin        bool   `json:"admin,omitempty"`
+}
+
+// View of a user without the password hash
+func (u *User) public() gin.H {
+	return gin.H{
+		"username":    u.Username,
+		"oidcSubject": u.OIDCSubject,
+		"org":         u.Org,
+		"mspID":       appConfig.Orgs[u.Org].MSPID,
+		"wallet":      u.Wallet,
+		"certPath":    u.CertPath,
+		"keyPath":     u.KeyPath,
+		"certificate": u.Certificate,
+		"admin":       u.Admin,
+		"hasPassword": u.PasswordHash != "",
+	}
+}
+
+// Caller is the Fabric identity a transaction is signed as
+type Caller struct {
+	Org      string
+	Username string // Empty for the org's identity from the network config
+	Wallet   string // Wallet label; CertPath and KeyPath are used when empty
+	CertPath string
+	KeyPath  string
+	// PEM certificate of a caller who signs offline; the server has no key for it
+	Certificate string
+}
+
+// Key the gateway pool caches the caller's Gateway under
+func (c Caller) identityKey() string {
+	if c.Wallet != "" {
+		return "wallet:" + c.Wallet
+	}
+	if c.Certificate != "" {
+		digest := sha256.Sum256([]byte(c.Certificate))
+		return "cert:" + hex.EncodeToString(digest[:])
+	}
+	return "file:" + c.CertPath
+}
+
+func (c Caller) String() string {
+	if c.Username == "" {
+		return c.Org
+	}
+	return c.Username + "@" + c.Org
+}
+
+// serviceCaller signs as the org's identity from the network config
+func serviceCaller(org string) Caller {
+	config := appConfig.Orgs[org]
+	return Caller{Org: org, CertPath: config.CertPath, KeyPath: config.KeyDirectory}
+}
+
+// monitorCaller is the farmer org's role=monitor identity, which flags batches
+func monitorCaller() Caller {
+	caller := serviceCaller(appConfig.Roles.Farmer)
+	caller.Wallet = appConfig.Roles.Monitor
+	return caller
+}
+
+// callerFrom returns the signed-in user's identity; authenticate guarantees there is one
+func callerFrom(c *gin.Context) Caller {
+	user := c.MustGet("user").(*User)
+	return Caller{
+		Org:         user.Org,
+		Username:    user.Username,
+		Wallet:      user.Wallet,
+		CertPath:    user.CertPath,
+		KeyPath:     user.KeyPath,
+		Certificate: user.Certificate,
+	}
+}
+
+type UserStore struct {
+	mu    sync.Mutex
+	path  string
+	users map[string]*User
+}
+
+func newUserStore(path string) (*UserStore, error) {
+	store := &UserStore{path: path, users: make(map[string]*User)}
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if len(data) > 0 {
+		var users []*User
+		if err := json.Unmarshal(data, &users); err != nil {
+			return nil, fmt.Errorf("invalid users file %s: %w", path, err)
+		}
+		for _, user := range users {
+			store.users[user.Username] = user
+		}
+	}
+	return store, nil
+}
+
+// Get returns a copy of a user, or nil when there is none
+func (s *UserStore) Get(username string) *User {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if user, ok := s.users[username]; ok {
+		copy := *user
+		return &copy
+	}
+	return nil
+}
+
+// BySubject finds the user an OIDC login maps to
+func (s *UserStore) BySubject(subject string) *User {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, user := range s.users {
+		if user.OIDCSubject != "" && user.OIDCSubject == subject {
+			copy := *user
+			return &copy
+		}
+	}
+	return nil
+}
+
+// List returns every user sorted by name
+func (s *UserStore) List() []*User {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	users := make([]*User, 0, len(s.users))
+	for _, user := range s.users {
+		users = append(users, user)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Username < users[j].Username })
+	return users
+}
+
+// Put creates or replaces a user and saves the store
+func (s *UserStore) Put(user *User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[user.Username] = user
+	return s.save()
+}
+
+// Delete removes a user and saves the store
+func (s *UserStore) Delete(username string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.users[username]; !ok {
+		return false, nil
+	}
+	delete(s.users, username)
+	return true, s.save()
+}
+
+// Unmap removes the wallet label from every user that signs with it and
+// returns their names. They keep their login until an admin maps a new identity.
+func (s *UserStore) Unmap(label string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var usernames []string
+	for _, user := range s.users {
+		if user.Wallet == label {
+			user.Wallet = ""
+			usernames = append(usernames, user.Username)
+		}
+	}
+	if len(usernames) == 0 {
+		return nil, nil
+	}
+	sort.Strings(usernames)
+	return usernames, s.save()
+}
+
+// Callers hold s.mu
+func (s *UserStore) save() error {
+	users := make([]*User, 0, len(s.users))
+	for _, user := range s.users {
+		users = append(users, user)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Username < users[j].Username })
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	data, _ := json.MarshalIndent(users, "", "  ")
+	// The file holds password hashes, so keep it private to the service account
+	return os.WriteFile(s.path, data, 0o600)
+}
+
+// Check a user mapping against the network config before it is stored
+func validateUser(user *User) error {
+	if err := validateUserAccount(user); err != nil {
+		return err
+	}
+	if user.Wallet != "" {
+		entry, err := wallet.Get(user.Wallet)
+		if err != nil {
+			return err
+		}
+		if entry == nil {
+			return newAPIError(ErrCodeInvalidArgument, "wallet has no identity %s", user.Wallet)
+		}
+		if entry.Org != user.Org {
+			return newAPIError(ErrCodeInvalidArgument, "wallet identity %s belongs to %s, not %s", user.Wallet, entry.Org, user.Org)
+		}
+		user.CertPath, user.KeyPath, user.Certificate = "", "", ""
+	} else if user.Certificate != "" {
+		certificate, err := identity.CertificateFromPEM([]byte(user.Certificate))
+		if err != nil {
+			return newAPIError(ErrCodeInvalidArgument, "certificate: %v", err)
+		}
+		if time.Now().After(certificate.NotAfter) {
+			return newAPIError(ErrCodeInvalidArgument, "certificate expired on %s", certificate.NotAfter.Format(time.RFC3339))
+		}
+		user.CertPath, user.KeyPath = "", ""
+	} else {
+		if err := checkPath(user.CertPath, false); err != nil {
+			return newAPIError(ErrCodeInvalidArgument, "certPath: %v", err)
+		}
+		if appConfig.Orgs[user.Org].Signer.Type == SignerPKCS11 {
+			user.KeyPath = ""
+		} else if err := checkPath(user.KeyPath, true); err != nil {
+			return newAPIError(ErrCodeInvalidArgument, "keyPath: %v", err)
+		}
+	}
+	return nil
+}
+
+// validateUserAccount checks the login side of a user, everything but the identity it signs with
+func validateUserAccount(user *User) error {
+	if !safeIDPattern.MatchString(user.Username) {
+		return newAPIError(ErrCodeInvalidArgument, "username must start with a letter or digit and contain only letters, digits, '.', '_' or '-'")
+	}
+	if _, ok := appConfig.Orgs[user.Org]; !ok {
+		return newAPIError(ErrCodeInvalidArgument, "unknown organization %q", user.Org)
+	}
+	if user.PasswordHash == "" && user.OIDCSubject == "" {
+		return newAPIError(ErrCodeInvalidArgument, "a password or oidcSubject is required")
+	}
+	return nil
+}
+
+type Authenticator struct {
+	users  *UserStore
+	secret []byte
+	ttl    time.Duration
+	secure bool
+	oidc   *oidcLogin
+}
+
+type sessionClaims struct {
+	Org string `json:"org"`
+	jwt.RegisteredClaims
+}
+
+// Compared against when a username is unknown, so failed logins take the same time
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("dummy password"), bcrypt.DefaultCost)
+
+func newAuthenticator(config AuthConfig) (*Authenticator, error) {
+	users, err := newUserStore(config.UsersFile)
+	if err != nil {
+		return nil, err
+	}
+	ttl, _ := time.ParseDuration(config.SessionTTL)
+	auth := &Authenticator{users: users, ttl: ttl, secure: config.SecureCookies}
+
+	if config.SessionSecret != "" {
+		auth.secret = []byte(config.SessionSecret)
+	} else {
+		auth.secret = make([]byte, 32)
+		if _, err := rand.Read(auth.secret); err != nil {
+			return nil, err
+		}
+		logger.Println("auth: no session secret configured, sessions end when the server restarts")
+	}
+
+	if config.OIDC != nil {
+		auth.oidc = newOIDCLogin(*config.OIDC)
+	}
+
+	if err := auth.bootstrap(); err != nil {
+		return nil, err
+	}
+	return auth, nil
+}
+
+// An empty store gets an admin user, mapped to the first org's configured
+// identity, with a one-off password printed to the console
+func (a *Authenticator) bootstrap() error {
+	if len(a.users.List()) > 0 {
+		return nil
+	}
+	orgs := make([]string, 0, len(appConfig.Orgs))
+	for org := range appConfig.Orgs {
+		orgs = append(orgs, org)
+	}
+	sort.Strings(orgs)
+
+	secret := make([]byte, 12)
+	if _, err := rand.Read(secret); err != nil {
+		return err
+	}
+	password := hex.EncodeToString(secret)
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	caller := serviceCaller(orgs[0])
+	admin := &User{
+		Username:     "admin",
+		PasswordHash: string(hash),
+		Org:          caller.Org,
+		CertPath:     caller.CertPath,
+		KeyPath:      caller.KeyPath,
+		Admin:        true,
+	}
+	if err := a.users.Put(admin); err != nil {
+		return err
+	}
+	logger.Printf("auth: created user admin (%s) with password %s - change it after signing in", admin.Org, password)
+	return nil
+}
+
+// issueSession signs a session token for the user and sets it as a cookie
+func (a *Authenticator) issueSession(c *gin.Context, user *User) (string, time.Time, error) {
+	now := time.Now()
+	expires := now.Add(a.ttl)
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, sessionClaims{
+		Org: user.Org,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    sessionIssuer,
+			Subject:   user.Username,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expires),
+		},
+	}).SignedString(a.secret)
+	if err != nil {
+		return "", expires, err
+	}
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(sessionCookie, token, int(a.ttl.Seconds()), "/", "", a.secure, true)
+	return token, expires, nil
+}
+
+// Read the session token from the Authorization header or the cookie
+func (a *Authenticator) sessionUser(c *gin.Context) (*User, error) {
+	token := ""
+	if header := c.GetHeader("Authorization"); strings.HasPrefix(header, "Bearer ") {
+		token = strings.TrimPrefix(header, "Bearer ")
+	} else if cookie, err := c.Cookie(sessionCookie); err == nil {
+		token = cookie
+	}
+	if token == "" {
+		return nil, newAPIError(ErrCodeUnauthenticated, "sign in required")
+	}
+
+	var claims sessionClaims
+	_, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (interface{}, error) {
+		return a.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithIssuer(sessionIssuer), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, newAPIError(ErrCodeUnauthenticated, "invalid session: %v", err)
+	}
+
+	// Look the user up on every request so deleted or remapped users take effect at once
+	user := a.users.Get(claims.Subject)
+	if user == nil || user.Org != claims.Org {
+		return nil, newAPIError(ErrCodeUnauthenticated, "session is no longer valid")
+	}
+	return user, nil
+}
+
+// authenticate rejects requests without a valid session outside the anonymous paths
+func (a *Authenticator) authenticate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		path := c.Request.URL.Path
+		anonymous := anonymousPaths[path]
+		for _, prefix := range anonymousPrefixes {
+			anonymous = anonymous || strings.HasPrefix(path, prefix)
+		}
+
+		user, err := a.sessionUser(c)
+		if err != nil {
+			if anonymous {
+				c.Next()
+				return
+			}
+			respondError(c, err)
+			return
+		}
+		c.Set("user", user)
+		c.Next()
+	}
+}
+
+func requireAdmin(c *gin.Context) {
+	if user := c.MustGet("user").(*User); !user.Admin {
+		respondError(c, newAPIError(ErrCodeForbidden, "only administrators can manage users"))
+		return
+	}
+	c.Next()
+}
+
+func registerAuthRoutes(router *gin.Engine, auth *Authenticator) {
+	limiter := newRateLimiter(loginRequestsPerMinute, time.Minute)
+
+	// Sign in with a local username and password
+	router.POST("/api/auth/login", limiter.middleware(), func(c *gin.Context) {
+		var req struct {
+			Username string `json:"username"`
+			Password string `json:"password"`
+		}
+		if err := c.BindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Bad request", "error": err.Error()})
+			return
+		}
+
+		user := auth.users.Get(req.Username)
+		hash := dummyPasswordHash
+		if user != nil && user.PasswordHash != "" {
+			hash = []byte(user.PasswordHash)
+		}
+		if bcrypt.CompareHashAndPassword(hash, []byte(req.Password)) != nil || user == nil || user.PasswordHash == "" {
+			respondError(c, newAPIError(ErrCodeUnauthenticated, "invalid username or password"))
+			return
+		}
+
+		token, expires, err := auth.issueSession(c, user)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"message":   fmt.Sprintf("Signed in as %s", user.Username),
+			"data":      user.public(),
+			"token":     token,
+			"expiresAt": expires.UTC().Format(time.RFC3339),
+		})
+	})
+
+	router.POST("/api/auth/logout", func(c *gin.Context) {
+		c.SetCookie(sessionCookie, "", -1, "/", "", auth.secure, true)
+		c.JSON(http.StatusOK, gin.H{"message": "Signed out"})
+	})
+
+	// The signed-in user and the identity their transactions are signed with
+	router.GET("/api/auth/me", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"data": c.MustGet("user").(*User).public()})
+	})
+
+	// Change the signed-in user's own password
+	router.POST("/api/auth/password", func(c *gin.Context) {
+		var req struct {
+			CurrentPassword string `json:"currentPassword"`
+			NewPassword     string `json:"newPassword"`
+		}
+		if err := c.BindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Bad request", "error": err.Error()})
+			return
+		}
+		user := c.MustGet("user").(*User)
+		if user.PasswordHash == "" || bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.CurrentPassword)) != nil {
+			respondError(c, newAPIError(ErrCodeForbidden, "current password is incorrect"))
+			return
+		}
+		if len(req.NewPassword) < 8 {
+			respondError(c, newAPIError(ErrCodeInvalidArgument, "newPassword must be at least 8 characters"))
+			return
+		}
+		hash, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		user.PasswordHash = string(hash)
+		if err := auth.users.Put(user); err != nil {
+			respondError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Password changed"})
+	})
+
+	users := router.Group("/api/auth/users", requireAdmin)
+
+	users.GET("", func(c *gin.Context) {
+		var views []gin.H
+		for _, user := range auth.users.List() {
+			views = append(views, user.public())
+		}
+		c.JSON(http.StatusOK, gin.H{"data": views})
+	})
+
+	// Create or update a user and the Fabric identity they are mapped to
+	users.PUT("/:username", func(c *gin.Context) {
+		var req struct {
+			Password    string `json:"password"`
+			OIDCSubject string `json:"oidcSubject"`
+			Org         string `json:"org"`
+			Wallet      string `json:"wallet"`
+			CertPath    string `json:"certPath"`
+			KeyPath     string `json:"keyPath"`
+			Certificate string `json:"certificate"`
+			Admin       bool   `json:"admin"`
+		}
+		if err := c.BindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Bad request", "error": err.Error()})
+			return
+		}
+
+		user := &User{
+			Username:    c.Param("username"),
+			OIDCSubject: req.OIDCSubject,
+			Org:         req.Org,
+			Wallet:      req.Wallet,
+			CertPath:    req.CertPath,
+			KeyPath:     req.KeyPath,
+			Certificate: req.Certificate,
+			Admin:       req.Admin,
+		}
+		if existing := auth.users.Get(user.Username); existing != nil {
+			user.PasswordHash = existing.PasswordHash
+		}
+		if req.Password != "" {
+			if len(req.Password) < 8 {
+				respondError(c, newAPIError(ErrCodeInvalidArgument, "password must be at least 8 characters"))
+				return
+			}
+			hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+			if err != nil {
+				respondError(c, err)
+				return
+			}
+			user.PasswordHash = string(hash)
+		}
+		if err := validateUser(user); err != nil {
+			respondError(c, err)
+			return
+		}
+		if err := auth.users.Put(user); err != nil {
+			respondError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Saved user %s", user.Username), "data": user.public()})
+	})
+
+	users.DELETE("/:username", func(c *gin.Context) {
+		username := c.Param("username")
+		if username == c.MustGet("user").(*User).Username {
+			respondError(c, newAPIError(ErrCodeInvalidArgument, "administrators cannot delete themselves"))
+			return
+		}
+		deleted, err := auth.users.Delete(username)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		if !deleted {
+			respondError(c, newAPIError(ErrCodeNotFound, "user %s does not exist", username))
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Deleted user %s", username)})
+	})
+
+	if auth.oidc != nil {
+		auth.oidc.registerRoutes(router, auth)
+	}
+}
@@ -1,99 +1,94 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
-	"time"
 
 	"github.com/hyperledger/fabric-gateway/pkg/client"
-)
-
-// Submit a transaction to the Fabric network
-func submitTxnFn(
-	organization string,
-	channelName string,
-	chaincodeName string,
-	contractName string,
-	txnType string,
-	privateData map[string][]byte,
-	txnName string,
-	args ...string,
-) string {
 
-	orgProfile := profile[organization]
-	mspID := orgProfile.MSPID
-	certPath := orgProfile.CertPath
-	keyPath := orgProfile.KeyDirectory
-	tlsCertPath := orgProfile.TLSCertPath
-	gatewayPeer := orgProfile.GatewayPeer
-	peerEndpoint := orgProfile.PeerEndpoint
+	"ricetypes"
+)
 
-	// gRPC connection
-	clientConnection := newGrpcConnection(tlsCertPath, gatewayPeer, peerEndpoint)
-	defer clientConnection.Close()
+// Reads decode the contract's JSON into the shared ricetypes values, or into
+// a handler's own struct for contracts without a shared type. Writes wait for
+// the transaction to commit and report where it landed.
 
-	// Identity and signer
-	id := newIdentity(certPath, mspID)
-	sign := newSign(keyPath)
+// TransactionResult is a committed transaction and the message its contract returned
+type TransactionResult struct {
+	TransactionID string `json:"transactionID"`
+	BlockNumber   uint64 `json:"blockNumber"`
+	Status        string `json:"status"`
+	Result        string `json:"result,omitempty"`
+}
 
-	// Gateway connection
-	gw, err := client.Connect(
-		id,
-		client.WithSign(sign),
-		client.WithClientConnection(clientConnection),
-		client.WithEvaluateTimeout(5*time.Second),
-		client.WithEndorseTimeout(15*time.Second),
-		client.WithSubmitTimeout(5*time.Second),
-		client.WithCommitStatusTimeout(1*time.Minute),
-	)
+// contractFor returns the contract signing as caller
+func contractFor(caller Caller, channelName string, chaincodeName string, contractName string) (*client.Contract, error) {
+	if caller.Certificate != "" {
+		return nil, newAPIError(ErrCodeInvalidArgument, "%s signs offline, submit through /api/offline/transactions", caller)
+	}
+	gw, err := gateways.Gateway(caller)
 	if err != nil {
-		panic(fmt.Errorf("failed to connect to gateway: %w", err))
+		return nil, err
 	}
-	defer gw.Close()
-
-	network := gw.GetNetwork(channelName)
-	contract := network.GetContractWithName(chaincodeName, contractName)
-
-	fmt.Printf("\n--> Submitting transaction: %s\n", txnName)
-
-	switch txnType {
-	case "invoke":
-		result, err := contract.SubmitTransaction(txnName, args...)
-		if err != nil {
-			panic(fmt.Errorf("failed to submit transaction: %w", err))
-		}
-		return fmt.Sprintf("*** Transaction submitted successfully: %s\n", string(result))
-
-	case "query":
-		evaluateResult, err := contract.EvaluateTransaction(txnName, args...)
-		if err != nil {
-			panic(fmt.Errorf("failed to evaluate transaction: %w", err))
-		}
-
-		var result string
-		if isByteSliceEmpty(evaluateResult) {
-			result = string(evaluateResult)
-		} else {
-			result = formatJSON(evaluateResult)
-		}
-
-		return result
+	return gw.GetNetwork(channelName).GetContractWithName(chaincodeName, contractName), nil
+}
 
-	case "private":
-		result, err := contract.Submit(
-			txnName,
-			client.WithArguments(args...),
-			client.WithTransient(privateData),
-		)
-		if err != nil {
-			panic(fmt.Errorf("failed to submit private transaction: %w", err))
-		}
-		return fmt.Sprintf("*** Transaction committed successfully\nresult: %s\n", result)
+// Evaluate a transaction on the configured contract and decode its JSON result.
+// Contracts return nothing rather than an empty list, which leaves result unchanged.
+func evaluateJSON(caller Caller, txnName string, result interface{}, args ...string) error {
+	contract, err := contractFor(caller, appConfig.Channel, appConfig.Chaincode, appConfig.Contract)
+	if err != nil {
+		return err
+	}
+	logger.Printf("--> Evaluating transaction: %s as %s", txnName, caller)
+	data, err := contract.EvaluateTransaction(txnName, args...)
+	if err != nil {
+		return fmt.Errorf("failed to evaluate transaction: %w", err)
+	}
+	if isByteSliceEmpty(data) {
+		return nil
+	}
+	if err := json.Unmarshal(data, result); err != nil {
+		return newAPIError(ErrCodeBadLedgerResponse, "%s returned an unexpected result: %v", txnName, err)
+	}
+	return nil
+}
 
-	default:
-		return "Invalid transaction type"
+// Submit a transaction on the configured contract and wait for it to commit
+func submitTransaction(caller Caller, transient map[string][]byte, txnName string, args ...string) (*TransactionResult, error) {
+	contract, err := contractFor(caller, appConfig.Channel, appConfig.Chaincode, appConfig.Contract)
+	if err != nil {
+		return nil, err
+	}
+	logger.Printf("--> Submitting transaction: %s as %s", txnName, caller)
+	options := []client.ProposalOption{client.WithArguments(args...)}
+	if len(transient) > 0 {
+		options = append(options, client.WithTransient(transient))
+	}
+	result, commit, err := contract.SubmitAsync(txnName, options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to submit transaction: %w", err)
+	}
+	status, err := commit.Status()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit status: %w", err)
+	}
+	if !status.Successful {
+		return nil, commitStatusError(status)
 	}
+	return &TransactionResult{
+		TransactionID: status.TransactionID,
+		BlockNumber:   status.BlockNumber,
+		Status:        status.Code.String(),
+		Result:        string(result),
+	}, nil
+}
+
+// Submit a transaction described by one of the shared argument structs
+func submitArgs(caller Caller, args ricetypes.TransactionArgs) (*TransactionResult, error) {
+	return submitTransaction(caller, args.Transient(), args.Function(), args.Args()...)
 }
 
 func isByteSliceEmpty(data []byte) bool {
 	return len(data) == 0
-}
\ No newline at end of file
+}
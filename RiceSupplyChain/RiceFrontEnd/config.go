@@ -0,0 +1,705 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Network configuration is read from the file named by RICE_CONFIG, or
+// ./config.yaml / ./config.json when present; otherwise the test-network
+// defaults below apply. Environment variables then override single values:
+//
+//	RICE_LISTEN_ADDRESS, RICE_PUBLIC_BASE_URL
+//	RICE_TRUSTED_PROXIES   comma-separated addresses or CIDRs
+//	RICE_CHANNEL, RICE_CHAINCODE, RICE_CONTRACT
+//	RICE_ORG_<ORG>_MSPID, _CRYPTO_PATH, _CERT_PATH, _KEY_PATH, _TLS_CERT_PATH
+//	RICE_ORG_<ORG>_PEERS   comma-separated endpoints, each optionally
+//	                       prefixed with its TLS host name: peer0.org1.example.com=localhost:7051
+//	RICE_ORG_<ORG>_CA_URL, _CA_NAME, _CA_TLS_CERT_PATH, _CA_REGISTRAR_ID, _CA_REGISTRAR_SECRET
+//	RICE_ORG_<ORG>_SIGNER_TYPE, _SIGNER_PASSPHRASE, _SIGNER_LIBRARY, _SIGNER_TOKEN_LABEL,
+//	                       _SIGNER_PIN, _SIGNER_KEY_LABEL
+//	RICE_ROLE_FARMER, RICE_ROLE_PROCESSOR, RICE_ROLE_RETAILER, RICE_ROLE_PUBLIC, RICE_ROLE_MONITOR
+//	RICE_WALLET_TYPE, RICE_WALLET_PATH, RICE_WALLET_PASSPHRASE, RICE_WALLET_RENEW_BEFORE
+//	RICE_AUTH_USERS_FILE, RICE_AUTH_SESSION_SECRET, RICE_AUTH_SESSION_TTL
+//	RICE_AUTH_OIDC_ISSUER_URL, _CLIENT_ID, _CLIENT_SECRET, _LOCAL
+//	RICE_STORAGE_TELEMETRY_DIR, _DOCUMENTS_DIR, _RULES_FILE, _ALERTS_FILE
+//
+// <ORG> is the upper-cased org name; an org that only appears in the
+// environment is added. Relative paths of an org are resolved against its
+// cryptoPath when one is set.
+
+const envPrefix = "RICE_"
+
+var defaultConfigPaths = []string{"./config.yaml", "./config.yml", "./config.json"}
+
+// Loaded in main, read by every handler
+var appConfig *Config
+
+type Config struct {
+	ListenAddress string               `json:"listenAddress" yaml:"listenAddress"`
+	PublicBaseURL string               `json:"publicBaseURL" yaml:"publicBaseURL"` // Base of GS1 Digital Link URIs printed on labels
+	Channel       string               `json:"channel" yaml:"channel"`
+	Chaincode     string               `json:"chaincode" yaml:"chaincode"`
+	Contract      string               `json:"contract" yaml:"contract"`
+	Orgs          map[string]OrgConfig `json:"orgs" yaml:"orgs"`
+	Roles         RolesConfig          `json:"roles" yaml:"roles"`
+	Wallet        WalletConfig         `json:"wallet" yaml:"wallet"`
+	Auth          AuthConfig           `json:"auth" yaml:"auth"`
+	Storage       StorageConfig        `json:"storage" yaml:"storage"`
+
+	// Reverse proxies whose X-Forwarded-For header is believed. None by
+	// default, so the rate limiters key on the connecting address and a
+	// client cannot choose its own IP.
+	TrustedProxies []string `json:"trustedProxies,omitempty" yaml:"trustedProxies,omitempty"`
+}
+
+type OrgConfig struct {
+	MSPID        string       `json:"mspID" yaml:"mspID"`
+	CryptoPath   string       `json:"cryptoPath,omitempty" yaml:"cryptoPath,omitempty"`
+	CertPath     string       `json:"certPath" yaml:"certPath"`
+	KeyDirectory string       `json:"keyPath" yaml:"keyPath"`
+	TLSCertPath  string       `json:"tlsCertPath" yaml:"tlsCertPath"` // Default TLS CA for the org's peers
+	Peers        []PeerConfig `json:"peers" yaml:"peers"`
+	CA           *CAConfig    `json:"ca,omitempty" yaml:"ca,omitempty"` // Needed to enroll users into the wallet
+	Signer       SignerConfig `json:"signer,omitempty" yaml:"signer,omitempty"`
+}
+
+// RolesConfig names the org that plays each supply-chain role. The server
+// signs its own reads and background jobs with the matching org's identity.
+type RolesConfig struct {
+	Farmer    string `json:"farmer" yaml:"farmer"`       // Anchors telemetry and reads batches for the alert rules
+	Processor string `json:"processor" yaml:"processor"` // The miller
+	Retailer  string `json:"retailer" yaml:"retailer"`
+	Public    string `json:"public" yaml:"public"` // Serves the anonymous provenance API
+	// Wallet label of a farmer-org identity enrolled with role=monitor, the
+	// only kind of identity the contract lets flag batches. Without it alert
+	// rules do not flag batches on-ledger.
+	Monitor string `json:"monitor,omitempty" yaml:"monitor,omitempty"`
+}
+
+// SignerConfig picks where the private keys of the org's file identities live
+type SignerConfig struct {
+	Type       string `json:"type" yaml:"type"`                                 // pem, encrypted-pem or pkcs11
+	Passphrase string `json:"passphrase,omitempty" yaml:"passphrase,omitempty"` // encrypted-pem
+	Library    string `json:"library,omitempty" yaml:"library,omitempty"`       // pkcs11 module, e.g. libsofthsm2.so
+	TokenLabel string `json:"tokenLabel,omitempty" yaml:"tokenLabel,omitempty"`
+	PIN        string `json:"pin,omitempty" yaml:"pin,omitempty"`
+	KeyLabel   string `json:"keyLabel,omitempty" yaml:"keyLabel,omitempty"` // By default the key whose CKA_ID is the certificate's SKI
+}
+
+type CAConfig struct {
+	URL             string `json:"url" yaml:"url"`
+	CAName          string `json:"caName,omitempty" yaml:"caName,omitempty"`
+	TLSCertPath     string `json:"tlsCertPath,omitempty" yaml:"tlsCertPath,omitempty"` // Required for https URLs
+	RegistrarID     string `json:"registrarID" yaml:"registrarID"`                     // Enrolled into the wallet on first use
+	RegistrarSecret string `json:"registrarSecret" yaml:"registrarSecret"`
+}
+
+type WalletConfig struct {
+	Type        string `json:"type" yaml:"type"` // file or encrypted
+	Path        string `json:"path" yaml:"path"`
+	Passphrase  string `json:"passphrase,omitempty" yaml:"passphrase,omitempty"` // Encrypted wallets only
+	RenewBefore string `json:"renewBefore" yaml:"renewBefore"`                   // Re-enroll certificates this long before they expire
+}
+
+// StorageConfig places the files the server keeps off-chain
+type StorageConfig struct {
+	TelemetryDir string `json:"telemetryDir" yaml:"telemetryDir"` // Per-batch sensor readings and anchor state
+	DocumentsDir string `json:"documentsDir" yaml:"documentsDir"` // Content-addressed uploads
+	RulesFile    string `json:"rulesFile" yaml:"rulesFile"`       // Alert rules; the built-in rules apply when it does not exist
+	AlertsFile   string `json:"alertsFile" yaml:"alertsFile"`     // Alerts raised so far
+}
+
+// PeerConfig is one gateway peer; the first reachable peer of an org is used
+type PeerConfig struct {
+	Endpoint    string `json:"endpoint" yaml:"endpoint"`
+	GatewayPeer string `json:"gatewayPeer,omitempty" yaml:"gatewayPeer,omitempty"` // TLS host name override
+	TLSCertPath string `json:"tlsCertPath,omitempty" yaml:"tlsCertPath,omitempty"`
+}
+
+type AuthConfig struct {
+	UsersFile     string      `json:"usersFile" yaml:"usersFile"`
+	SessionSecret string      `json:"sessionSecret" yaml:"sessionSecret"` // HMAC key for session tokens; random per process when empty
+	SessionTTL    string      `json:"sessionTTL" yaml:"sessionTTL"`       // Go duration, e.g. 12h
+	SecureCookies bool        `json:"secureCookies" yaml:"secureCookies"` // Set when served over HTTPS
+	OIDC          *OIDCConfig `json:"oidc,omitempty" yaml:"oidc,omitempty"`
+}
+
+type OIDCConfig struct {
+	IssuerURL     string   `json:"issuerURL" yaml:"issuerURL"`
+	ClientID      string   `json:"clientID" yaml:"clientID"`
+	ClientSecret  string   `json:"clientSecret" yaml:"clientSecret"`
+	Scopes        []string `json:"scopes,omitempty" yaml:"scopes,omitempty"`
+	UsernameClaim string   `json:"usernameClaim,omitempty" yaml:"usernameClaim,omitempty"` // Matched against a user's oidcSubject, default email
+	Local         bool     `json:"local,omitempty" yaml:"local,omitempty"`                 // Serve the stand-in provider at /oidc-dev; needs -tags oidcdev
+}
+
+func testNetworkOrg(org string, mspID string, port int) OrgConfig {
+	domain := org + ".example.com"
+	return OrgConfig{
+		MSPID:        mspID,
+		CryptoPath:   "../../fabric-samples/test-network/organizations/peerOrganizations/" + domain,
+		CertPath:     "users/User1@" + domain + "/msp/signcerts/cert.pem",
+		KeyDirectory: "users/User1@" + domain + "/msp/keystore/",
+		TLSCertPath:  "peers/peer0." + domain + "/tls/ca.crt",
+		Peers: []PeerConfig{
+			{Endpoint: fmt.Sprintf("localhost:%d", port), GatewayPeer: "peer0." + domain},
+		},
+	}
+}
+
+func defaultConfig() *Config {
+	return &Config{
+		ListenAddress: "localhost:3001",
+		PublicBaseURL: "http://localhost:3001",
+		Channel:       "mychannel",
+		Chaincode:     "rice",
+		Contract:      "RiceContract",
+		Orgs: map[string]OrgConfig{
+			"org1": testNetworkOrg("org1", "Org1MSP", 7051),
+			"org2": testNetworkOrg("org2", "Org2MSP", 9051),
+			"org3": testNetworkOrg("org3", "Org3MSP", 11051),
+		},
+		Roles: RolesConfig{
+			Farmer:    "org1",
+			Processor: "org2",
+			Retailer:  "org3",
+			Public:    "org3",
+		},
+		Wallet: WalletConfig{
+			Type:        "file",
+			Path:        "./data/wallet",
+			RenewBefore: "720h",
+		},
+		Auth: AuthConfig{
+			UsersFile:  "./data/users.json",
+			SessionTTL: "12h",
+		},
+		Storage: StorageConfig{
+			TelemetryDir: "./data/telemetry",
+			DocumentsDir: "./data/documents",
+			RulesFile:    "./rules.json",
+			AlertsFile:   "./data/alerts.json",
+		},
+	}
+}
+
+// loadConfig reads the config file, applies environment overrides and validates the result
+func loadConfig() (*Config, error) {
+	config := defaultConfig()
+
+	path := os.Getenv(envPrefix + "CONFIG")
+	if path == "" {
+		for _, candidate := range defaultConfigPaths {
+			if _, err := os.Stat(candidate); err == nil {
+				path = candidate
+				break
+			}
+		}
+	}
+	if path != "" {
+		fileConfig, err := readConfigFile(path)
+		if err != nil {
+			return nil, err
+		}
+		config.merge(fileConfig)
+	}
+
+	if err := config.applyEnv(os.Environ()); err != nil {
+		return nil, err
+	}
+	config.resolvePaths()
+	if oidc := config.Auth.OIDC; oidc != nil && oidc.Local && oidc.IssuerURL == "" {
+		oidc.IssuerURL = localIssuerURL(config.PublicBaseURL)
+	}
+	if err := config.validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	return config, nil
+}
+
+func readConfigFile(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	var config Config
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&config)
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(&config)
+	default:
+		return nil, fmt.Errorf("config file %s must end in .yaml, .yml or .json", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return &config, nil
+}
+
+// Values set in other replace the defaults. A file that lists orgs replaces
+// the default orgs entirely, so a network without org3 does not inherit it.
+func (c *Config) merge(other *Config) {
+	if other.ListenAddress != "" {
+		c.ListenAddress = other.ListenAddress
+	}
+	if other.PublicBaseURL != "" {
+		c.PublicBaseURL = other.PublicBaseURL
+	}
+	if len(other.TrustedProxies) > 0 {
+		c.TrustedProxies = other.TrustedProxies
+	}
+	if other.Channel != "" {
+		c.Channel = other.Channel
+	}
+	if other.Chaincode != "" {
+		c.Chaincode = other.Chaincode
+	}
+	if other.Contract != "" {
+		c.Contract = other.Contract
+	}
+	if len(other.Orgs) > 0 {
+		c.Orgs = other.Orgs
+	}
+	if other.Roles.Farmer != "" {
+		c.Roles.Farmer = other.Roles.Farmer
+	}
+	if other.Roles.Processor != "" {
+		c.Roles.Processor = other.Roles.Processor
+	}
+	if other.Roles.Retailer != "" {
+		c.Roles.Retailer = other.Roles.Retailer
+	}
+	if other.Roles.Public != "" {
+		c.Roles.Public = other.Roles.Public
+	}
+	if other.Roles.Monitor != "" {
+		c.Roles.Monitor = other.Roles.Monitor
+	}
+	if other.Wallet.Type != "" {
+		c.Wallet.Type = other.Wallet.Type
+	}
+	if other.Wallet.Path != "" {
+		c.Wallet.Path = other.Wallet.Path
+	}
+	if other.Wallet.Passphrase != "" {
+		c.Wallet.Passphrase = other.Wallet.Passphrase
+	}
+	if other.Wallet.RenewBefore != "" {
+		c.Wallet.RenewBefore = other.Wallet.RenewBefore
+	}
+	if other.Auth.UsersFile != "" {
+		c.Auth.UsersFile = other.Auth.UsersFile
+	}
+	if other.Auth.SessionSecret != "" {
+		c.Auth.SessionSecret = other.Auth.SessionSecret
+	}
+	if other.Auth.SessionTTL != "" {
+		c.Auth.SessionTTL = other.Auth.SessionTTL
+	}
+	c.Auth.SecureCookies = c.Auth.SecureCookies || other.Auth.SecureCookies
+	if other.Auth.OIDC != nil {
+		c.Auth.OIDC = other.Auth.OIDC
+	}
+	if other.Storage.TelemetryDir != "" {
+		c.Storage.TelemetryDir = other.Storage.TelemetryDir
+	}
+	if other.Storage.DocumentsDir != "" {
+		c.Storage.DocumentsDir = other.Storage.DocumentsDir
+	}
+	if other.Storage.RulesFile != "" {
+		c.Storage.RulesFile = other.Storage.RulesFile
+	}
+	if other.Storage.AlertsFile != "" {
+		c.Storage.AlertsFile = other.Storage.AlertsFile
+	}
+}
+
+// Longer suffixes come first so _CA_TLS_CERT_PATH is not read as _TLS_CERT_PATH or _CERT_PATH
+var orgEnvSuffixes = []string{
+	"_MSPID", "_CRYPTO_PATH", "_CA_TLS_CERT_PATH", "_TLS_CERT_PATH", "_CERT_PATH", "_KEY_PATH", "_PEERS",
+	"_CA_URL", "_CA_NAME", "_CA_REGISTRAR_ID", "_CA_REGISTRAR_SECRET",
+	"_SIGNER_TYPE", "_SIGNER_PASSPHRASE", "_SIGNER_LIBRARY", "_SIGNER_TOKEN_LABEL", "_SIGNER_PIN", "_SIGNER_KEY_LABEL",
+}
+
+func (c *Config) applyEnv(environ []string) error {
+	for _, entry := range environ {
+		key, value, ok := strings.Cut(entry, "=")
+		if !ok || !strings.HasPrefix(key, envPrefix) {
+			continue
+		}
+		switch name := strings.TrimPrefix(key, envPrefix); name {
+		case "LISTEN_ADDRESS":
+			c.ListenAddress = value
+		case "PUBLIC_BASE_URL":
+			c.PublicBaseURL = value
+		case "TRUSTED_PROXIES":
+			c.TrustedProxies = nil
+			for _, proxy := range strings.Split(value, ",") {
+				if proxy = strings.TrimSpace(proxy); proxy != "" {
+					c.TrustedProxies = append(c.TrustedProxies, proxy)
+				}
+			}
+		case "CHANNEL":
+			c.Channel = value
+		case "CHAINCODE":
+			c.Chaincode = value
+		case "CONTRACT":
+			c.Contract = value
+		case "ROLE_FARMER":
+			c.Roles.Farmer = value
+		case "ROLE_PROCESSOR":
+			c.Roles.Processor = value
+		case "ROLE_RETAILER":
+			c.Roles.Retailer = value
+		case "ROLE_PUBLIC":
+			c.Roles.Public = value
+		case "ROLE_MONITOR":
+			c.Roles.Monitor = value
+		case "WALLET_TYPE":
+			c.Wallet.Type = value
+		case "WALLET_PATH":
+			c.Wallet.Path = value
+		case "WALLET_PASSPHRASE":
+			c.Wallet.Passphrase = value
+		case "WALLET_RENEW_BEFORE":
+			c.Wallet.RenewBefore = value
+		case "AUTH_USERS_FILE":
+			c.Auth.UsersFile = value
+		case "AUTH_SESSION_SECRET":
+			c.Auth.SessionSecret = value
+		case "AUTH_SESSION_TTL":
+			c.Auth.SessionTTL = value
+		case "AUTH_SECURE_COOKIES":
+			c.Auth.SecureCookies = value == "true"
+		case "STORAGE_TELEMETRY_DIR":
+			c.Storage.TelemetryDir = value
+		case "STORAGE_DOCUMENTS_DIR":
+			c.Storage.DocumentsDir = value
+		case "STORAGE_RULES_FILE":
+			c.Storage.RulesFile = value
+		case "STORAGE_ALERTS_FILE":
+			c.Storage.AlertsFile = value
+		case "AUTH_OIDC_ISSUER_URL", "AUTH_OIDC_CLIENT_ID", "AUTH_OIDC_CLIENT_SECRET", "AUTH_OIDC_LOCAL":
+			if c.Auth.OIDC == nil {
+				c.Auth.OIDC = &OIDCConfig{}
+			}
+			switch name {
+			case "AUTH_OIDC_ISSUER_URL":
+				c.Auth.OIDC.IssuerURL = value
+			case "AUTH_OIDC_CLIENT_ID":
+				c.Auth.OIDC.ClientID = value
+			case "AUTH_OIDC_CLIENT_SECRET":
+				c.Auth.OIDC.ClientSecret = value
+			case "AUTH_OIDC_LOCAL":
+				c.Auth.OIDC.Local = value == "true"
+			}
+		default:
+			if !strings.HasPrefix(name, "ORG_") {
+				continue
+			}
+			if err := c.applyOrgEnv(key, strings.TrimPrefix(name, "ORG_"), value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (c *Config) applyOrgEnv(key string, name string, value string) error {
+	for _, suffix := range orgEnvSuffixes {
+		if !strings.HasSuffix(name, suffix) || len(name) == len(suffix) {
+			continue
+		}
+		org := strings.ToLower(strings.TrimSuffix(name, suffix))
+		if c.Orgs == nil {
+			c.Orgs = make(map[string]OrgConfig)
+		}
+		orgConfig := c.Orgs[org]
+		switch suffix {
+		case "_MSPID":
+			orgConfig.MSPID = value
+		case "_CRYPTO_PATH":
+			orgConfig.CryptoPath = value
+		case "_CERT_PATH":
+			orgConfig.CertPath = value
+		case "_KEY_PATH":
+			orgConfig.KeyDirectory = value
+		case "_TLS_CERT_PATH":
+			orgConfig.TLSCertPath = value
+		case "_PEERS":
+			peers, err := parsePeers(value)
+			if err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			orgConfig.Peers = peers
+		case "_SIGNER_TYPE":
+			orgConfig.Signer.Type = value
+		case "_SIGNER_PASSPHRASE":
+			orgConfig.Signer.Passphrase = value
+		case "_SIGNER_LIBRARY":
+			orgConfig.Signer.Library = value
+		case "_SIGNER_TOKEN_LABEL":
+			orgConfig.Signer.TokenLabel = value
+		case "_SIGNER_PIN":
+			orgConfig.Signer.PIN = value
+		case "_SIGNER_KEY_LABEL":
+			orgConfig.Signer.KeyLabel = value
+		default:
+			// The remaining suffixes all configure the CA
+			ca := CAConfig{}
+			if orgConfig.CA != nil {
+				ca = *orgConfig.CA
+			}
+			switch suffix {
+			case "_CA_URL":
+				ca.URL = value
+			case "_CA_NAME":
+				ca.CAName = value
+			case "_CA_TLS_CERT_PATH":
+				ca.TLSCertPath = value
+			case "_CA_REGISTRAR_ID":
+				ca.RegistrarID = value
+			case "_CA_REGISTRAR_SECRET":
+				ca.RegistrarSecret = value
+			}
+			orgConfig.CA = &ca
+		}
+		c.Orgs[org] = orgConfig
+		return nil
+	}
+	return fmt.Errorf("%s is not a recognised setting; org settings end in one of %s", key, strings.Join(orgEnvSuffixes, ", "))
+}
+
+// Parse "host:port" or "tlsHostName=host:port" entries separated by commas
+func parsePeers(value string) ([]PeerConfig, error) {
+	var peers []PeerConfig
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		peer := PeerConfig{Endpoint: entry}
+		if hostName, endpoint, ok := strings.Cut(entry, "="); ok {
+			peer = PeerConfig{Endpoint: endpoint, GatewayPeer: hostName}
+		}
+		peers = append(peers, peer)
+	}
+	if len(peers) == 0 {
+		return nil, fmt.Errorf("no peers listed")
+	}
+	return peers, nil
+}
+
+// Resolve relative paths against cryptoPath and fill in signer and per-peer defaults
+func (c *Config) resolvePaths() {
+	for org, orgConfig := range c.Orgs {
+		resolve := func(path string) string {
+			if path == "" || orgConfig.CryptoPath == "" || filepath.IsAbs(path) {
+				return path
+			}
+			return filepath.Join(orgConfig.CryptoPath, path)
+		}
+		orgConfig.CertPath = resolve(orgConfig.CertPath)
+		orgConfig.KeyDirectory = resolve(orgConfig.KeyDirectory)
+		orgConfig.TLSCertPath = resolve(orgConfig.TLSCertPath)
+		if orgConfig.Signer.Type == "" {
+			orgConfig.Signer.Type = SignerPEM
+		}
+		if orgConfig.CA != nil {
+			ca := *orgConfig.CA
+			ca.TLSCertPath = resolve(ca.TLSCertPath)
+			orgConfig.CA = &ca
+		}
+
+		peers := make([]PeerConfig, len(orgConfig.Peers))
+		for i, peer := range orgConfig.Peers {
+			if peer.TLSCertPath == "" {
+				peer.TLSCertPath = orgConfig.TLSCertPath
+			} else {
+				peer.TLSCertPath = resolve(peer.TLSCertPath)
+			}
+			if peer.GatewayPeer == "" {
+				peer.GatewayPeer, _, _ = net.SplitHostPort(peer.Endpoint)
+			}
+			peers[i] = peer
+		}
+		orgConfig.Peers = peers
+		c.Orgs[org] = orgConfig
+	}
+}
+
+// validate reports every problem at once rather than stopping at the first
+func (c *Config) validate() error {
+	var problems []error
+	problem := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Errorf(format, args...))
+	}
+
+	if _, _, err := net.SplitHostPort(c.ListenAddress); err != nil {
+		problem("listenAddress %q must be host:port: %v", c.ListenAddress, err)
+	}
+	if u, err := url.Parse(c.PublicBaseURL); err != nil || u.Scheme == "" || u.Host == "" {
+		problem("publicBaseURL %q must be an absolute URL", c.PublicBaseURL)
+	}
+	for _, proxy := range c.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			problem("trustedProxies entry %q must be an IP address or CIDR", proxy)
+		}
+	}
+	if c.Channel == "" {
+		problem("channel is required")
+	}
+	if c.Chaincode == "" {
+		problem("chaincode is required")
+	}
+	if c.Contract == "" {
+		problem("contract is required")
+	}
+	if len(c.Orgs) == 0 {
+		problem("at least one org is required")
+	}
+	for _, role := range []struct{ name, org string }{
+		{"farmer", c.Roles.Farmer},
+		{"processor", c.Roles.Processor},
+		{"retailer", c.Roles.Retailer},
+		{"public", c.Roles.Public},
+	} {
+		if _, ok := c.Orgs[role.org]; !ok {
+			problem("roles.%s %q must name one of the configured orgs", role.name, role.org)
+		}
+	}
+	switch c.Wallet.Type {
+	case "file":
+	case "encrypted":
+		if len(c.Wallet.Passphrase) < 12 {
+			problem("wallet.passphrase must be at least 12 characters for an encrypted wallet")
+		}
+	default:
+		problem("wallet.type %q must be file or encrypted", c.Wallet.Type)
+	}
+	if c.Wallet.Path == "" {
+		problem("wallet.path is required")
+	}
+	if renew, err := time.ParseDuration(c.Wallet.RenewBefore); err != nil || renew <= 0 {
+		problem("wallet.renewBefore %q must be a positive duration", c.Wallet.RenewBefore)
+	}
+	if c.Auth.UsersFile == "" {
+		problem("auth.usersFile is required")
+	}
+	if c.Storage.TelemetryDir == "" || c.Storage.DocumentsDir == "" || c.Storage.RulesFile == "" || c.Storage.AlertsFile == "" {
+		problem("storage.telemetryDir, documentsDir, rulesFile and alertsFile are required")
+	}
+	if ttl, err := time.ParseDuration(c.Auth.SessionTTL); err != nil || ttl <= 0 {
+		problem("auth.sessionTTL %q must be a positive duration", c.Auth.SessionTTL)
+	}
+	if c.Auth.SessionSecret != "" && len(c.Auth.SessionSecret) < 32 {
+		problem("auth.sessionSecret must be at least 32 characters")
+	}
+	if oidc := c.Auth.OIDC; oidc != nil {
+		if oidc.ClientID == "" {
+			problem("auth.oidc.clientID is required")
+		}
+		if oidc.Local {
+			if oidc.IssuerURL != "" && oidc.IssuerURL != localIssuerURL(c.PublicBaseURL) {
+				problem("auth.oidc.issuerURL must be empty or %s when local is set", localIssuerURL(c.PublicBaseURL))
+			}
+		} else if u, err := url.Parse(oidc.IssuerURL); err != nil || u.Scheme == "" || u.Host == "" {
+			problem("auth.oidc.issuerURL %q must be an absolute URL", oidc.IssuerURL)
+		}
+	}
+
+	orgs := make([]string, 0, len(c.Orgs))
+	for org := range c.Orgs {
+		orgs = append(orgs, org)
+	}
+	sort.Strings(orgs)
+	for _, org := range orgs {
+		orgConfig := c.Orgs[org]
+		prefix := "orgs." + org
+		if orgConfig.MSPID == "" {
+			problem("%s.mspID is required", prefix)
+		}
+		if err := checkPath(orgConfig.CertPath, false); err != nil {
+			problem("%s.certPath: %v", prefix, err)
+		}
+		switch signer := orgConfig.Signer; signer.Type {
+		case SignerPEM, SignerEncryptedPEM:
+			if err := checkPath(orgConfig.KeyDirectory, true); err != nil {
+				problem("%s.keyPath: %v", prefix, err)
+			}
+			if signer.Type == SignerEncryptedPEM && signer.Passphrase == "" {
+				problem("%s.signer.passphrase is required for encrypted-pem", prefix)
+			}
+		case SignerPKCS11:
+			// Keys live on the token, so there is no keyPath to check
+			if err := checkPath(signer.Library, false); err != nil {
+				problem("%s.signer.library: %v", prefix, err)
+			}
+			if signer.TokenLabel == "" || signer.PIN == "" {
+				problem("%s.signer.tokenLabel and pin are required for pkcs11", prefix)
+			}
+		default:
+			problem("%s.signer.type %q must be pem, encrypted-pem or pkcs11", prefix, signer.Type)
+		}
+		if len(orgConfig.Peers) == 0 {
+			problem("%s.peers must list at least one peer", prefix)
+		}
+		if ca := orgConfig.CA; ca != nil {
+			u, err := url.Parse(ca.URL)
+			if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+				problem("%s.ca.url %q must be an http or https URL", prefix, ca.URL)
+			} else if u.Scheme == "https" {
+				if err := checkPath(ca.TLSCertPath, false); err != nil {
+					problem("%s.ca.tlsCertPath: %v", prefix, err)
+				}
+			}
+			if ca.RegistrarID == "" || ca.RegistrarSecret == "" {
+				problem("%s.ca.registrarID and registrarSecret are required", prefix)
+			}
+		}
+		for i, peer := range orgConfig.Peers {
+			peerPrefix := fmt.Sprintf("%s.peers[%d]", prefix, i)
+			if _, _, err := net.SplitHostPort(peer.Endpoint); err != nil {
+				problem("%s.endpoint %q must be host:port: %v", peerPrefix, peer.Endpoint, err)
+			}
+			if err := checkPath(peer.TLSCertPath, false); err != nil {
+				problem("%s.tlsCertPath: %v", peerPrefix, err)
+			}
+		}
+	}
+	return errors.Join(problems...)
+}
+
+func checkPath(path string, wantDir bool) error {
+	if path == "" {
+		return fmt.Errorf("path is required")
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("cannot be read: %w", err)
+	}
+	if wantDir && !info.IsDir() {
+		return fmt.Errorf("%s must be a directory", path)
+	}
+	if !wantDir && info.IsDir() {
+		return fmt.Errorf("%s must be a file, not a directory", path)
+	}
+	return nil
+}
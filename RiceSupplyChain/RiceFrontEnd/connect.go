@@ -1,71 +1,67 @@
 package main
 
 import (
-	"bytes"
 	"crypto/x509"
-	"encoding/json"
 	"fmt"
 	"os"
-	"path"
+	"time"
 
 	"github.com/hyperledger/fabric-gateway/pkg/identity"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
 	"google.golang.org/grpc/credentials"
 )
 
-func newGrpcConnection(tlsCertPath string, gatewayPeer string, peerEndpoint string) *grpc.ClientConn {
+func newGrpcConnection(tlsCertPath string, gatewayPeer string, peerEndpoint string) (*grpc.ClientConn, error) {
 	certificate, err := loadCertificate(tlsCertPath)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
 	certPool := x509.NewCertPool()
 	certPool.AddCert(certificate)
 	transportCredentials := credentials.NewClientTLSFromCert(certPool, gatewayPeer)
 
-	connection, err := grpc.Dial(peerEndpoint, grpc.WithTransportCredentials(transportCredentials))
+	// Connections are long-lived; gRPC redials a dropped peer with exponential backoff
+	connection, err := grpc.Dial(peerEndpoint,
+		grpc.WithTransportCredentials(transportCredentials),
+		grpc.WithConnectParams(grpc.ConnectParams{
+			Backoff:           backoff.DefaultConfig,
+			MinConnectTimeout: 5 * time.Second,
+		}),
+	)
 	if err != nil {
-		panic(fmt.Errorf("failed to create gRPC connection: %w", err))
+		return nil, fmt.Errorf("failed to create gRPC connection: %w", err)
 	}
 
-	return connection
+	return connection, nil
 }
 
-func newIdentity(certPath string, mspID string) *identity.X509Identity {
-	certificate, err := loadCertificate(certPath)
+func newIdentityFromPEM(certificatePEM []byte, mspID string) (*identity.X509Identity, error) {
+	certificate, err := identity.CertificateFromPEM(certificatePEM)
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("failed to parse certificate: %w", err)
 	}
 
 	id, err := identity.NewX509Identity(mspID, certificate)
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("failed to create identity: %w", err)
 	}
-	return id
+	return id, nil
 }
 
-func newSign(keyPath string) identity.Sign {
-	files, err := os.ReadDir(keyPath)
-	if err != nil {
-		panic(fmt.Errorf("failed to read private key directory: %w", err))
-	}
-
-	privateKeyPEM, err := os.ReadFile(path.Join(keyPath, files[0].Name()))
-	if err != nil {
-		panic(fmt.Errorf("failed to read private key file: %w", err))
-	}
-
+func newSignFromPEM(privateKeyPEM []byte) (identity.Sign, error) {
 	privateKey, err := identity.PrivateKeyFromPEM(privateKeyPEM)
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
 	}
 
 	sign, err := identity.NewPrivateKeySign(privateKey)
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("failed to create signer: %w", err)
 	}
 
-	return sign
+	return sign, nil
 }
 
 func loadCertificate(filename string) (*x509.Certificate, error) {
@@ -75,11 +71,3 @@ func loadCertificate(filename string) (*x509.Certificate, error) {
 	}
 	return identity.CertificateFromPEM(certPEM)
 }
-
-func formatJSON(data []byte) string {
-	var prettyJSON bytes.Buffer
-	if err := json.Indent(&prettyJSON, data, "", "  "); err != nil {
-		return string(data) // return raw if formatting fails
-	}
-	return prettyJSON.String()
-}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+
+	"ricetypes"
+)
+
+// Documents are stored content-addressed: the file body lives at
+// <dir>/<first two hex chars>/<sha256> with a .json sidecar holding the
+// original name and content type. The hash is anchored on-ledger with
+// AttachDocument and re-checked on every download.
+
+const maxDocumentSize = 20 << 20
+
+var sha256Pattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// Content types served as uploaded; anything else is sent as octet-stream
+var servedContentTypes = map[string]bool{
+	"application/pdf": true,
+	"image/png":       true,
+	"image/jpeg":      true,
+}
+
+type DocumentMeta struct {
+	SHA256      string `json:"sha256"`
+	FileName    string `json:"fileName"`
+	ContentType string `json:"contentType"`
+	Size        int64  `json:"size"`
+}
+
+type DocumentStore struct {
+	dir string
+}
+
+func newDocumentStore(dir string) *DocumentStore {
+	return &DocumentStore{dir: dir}
+}
+
+func (s *DocumentStore) blobPath(hash string) string {
+	return filepath.Join(s.dir, hash[:2], hash)
+}
+
+// Put streams a document into the store and returns its metadata
+func (s *DocumentStore) Put(r io.Reader, fileName string, contentType string) (*DocumentMeta, error) {
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return nil, err
+	}
+	tmp, err := os.CreateTemp(s.dir, "upload-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+
+	hasher := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hasher), r)
+	closeErr := tmp.Close()
+	if err != nil {
+		return nil, err
+	}
+	if closeErr != nil {
+		return nil, closeErr
+	}
+
+	meta := &DocumentMeta{
+		SHA256:      hex.EncodeToString(hasher.Sum(nil)),
+		FileName:    filepath.Base(fileName),
+		ContentType: contentType,
+		Size:        size,
+	}
+	path := s.blobPath(meta.SHA256)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	// Same content always lands on the same path, so an existing blob is kept
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if err := os.Rename(tmp.Name(), path); err != nil {
+			return nil, err
+		}
+	}
+	data, _ := json.MarshalIndent(meta, "", "  ")
+	if err := os.WriteFile(path+".json", data, 0o644); err != nil {
+		return nil, err
+	}
+	return meta, nil
+}
+
+// Get reads a document and the hash of the bytes actually on disk
+func (s *DocumentStore) Get(hash string) ([]byte, *DocumentMeta, string, error) {
+	path := s.blobPath(hash)
+	body, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	var meta DocumentMeta
+	if data, err := os.ReadFile(path + ".json"); err == nil {
+		json.Unmarshal(data, &meta)
+	}
+	sum := sha256.Sum256(body)
+	return body, &meta, hex.EncodeToString(sum[:]), nil
+}
+
+func registerDocumentRoutes(router *gin.Engine, store *DocumentStore) {
+	// Upload a document and anchor its hash against an asset
+	router.POST("/api/documents", func(c *gin.Context) {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxDocumentSize)
+		assetID := c.PostForm("assetID")
+		docType := c.PostForm("docType")
+		if assetID == "" || docType == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "assetID and docType are required"})
+			return
+		}
+		header, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "file is required", "error": err.Error()})
+			return
+		}
+		file, err := header.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Cannot read upload", "error": err.Error()})
+			return
+		}
+		defer file.Close()
+
+		meta, err := store.Put(file, header.Filename, header.Header.Get("Content-Type"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to store document", "error": err.Error()})
+			return
+		}
+
+		uri := "/api/documents/" + meta.SHA256
+		result, err := submitTransaction(callerFrom(c), nil, "AttachDocument", assetID, docType, meta.SHA256, uri)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"message":     "Document stored and anchored",
+			"data":        meta,
+			"uri":         uri,
+			"transaction": result,
+		})
+	})
+
+	// List documents anchored against an asset
+	router.GET("/api/documents", func(c *gin.Context) {
+		assetID := c.Query("assetID")
+		if assetID == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "assetID is required"})
+			return
+		}
+		documents := []*ricetypes.DocumentAnchor{}
+		if err := evaluateJSON(callerFrom(c), "GetDocuments", &documents, assetID); err != nil {
+			respondError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": documents})
+	})
+
+	// Download a document after checking its bytes against the ledger anchor
+	router.GET("/api/documents/:sha256", func(c *gin.Context) {
+		hash := c.Param("sha256")
+		if !sha256Pattern.MatchString(hash) {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid SHA-256 hash"})
+			return
+		}
+		body, meta, actual, err := store.Get(hash)
+		if os.IsNotExist(err) {
+			c.JSON(http.StatusNotFound, gin.H{"message": "Document not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to read document", "error": err.Error()})
+			return
+		}
+
+		var anchors []*ricetypes.DocumentAnchor
+		if err := evaluateJSON(callerFrom(c), "GetDocumentsByHash", &anchors, hash); err != nil {
+			respondError(c, err)
+			return
+		}
+		if len(anchors) == 0 {
+			c.JSON(http.StatusConflict, gin.H{"message": "Document is not anchored on the ledger"})
+			return
+		}
+		if actual != anchors[0].SHA256 {
+			c.JSON(http.StatusConflict, gin.H{
+				"message":  "Stored document does not match its ledger anchor",
+				"expected": anchors[0].SHA256,
+				"actual":   actual,
+			})
+			return
+		}
+
+		// The content type comes from the uploader, so only inert types are
+		// passed through and the document is never rendered inline
+		contentType := "application/octet-stream"
+		if servedContentTypes[meta.ContentType] {
+			contentType = meta.ContentType
+		}
+		disposition := "attachment"
+		if meta.FileName != "" {
+			if formatted := mime.FormatMediaType("attachment", map[string]string{"filename": meta.FileName}); formatted != "" {
+				disposition = formatted
+			}
+		}
+		c.Header("X-Document-SHA256", actual)
+		c.Header("X-Integrity-Verified", "true")
+		c.Header("Content-Disposition", disposition)
+		c.Header("X-Content-Type-Options", "nosniff")
+		c.Data(http.StatusOK, contentType, body)
+	})
+}
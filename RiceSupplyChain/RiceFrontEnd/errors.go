@@ -0,0 +1,245 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-protos-go-apiv2/gateway"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"ricetypes"
+)
+
+// Stable error codes returned to API clients, shared through ricetypes. The
+// HTTP status is derived from the code, so a client can rely on either.
+const (
+	ErrCodeInvalidArgument    = ricetypes.ErrCodeInvalidArgument
+	ErrCodeUnauthenticated    = ricetypes.ErrCodeUnauthenticated
+	ErrCodeNotFound           = ricetypes.ErrCodeNotFound
+	ErrCodeForbidden          = ricetypes.ErrCodeForbidden
+	ErrCodeConflict           = ricetypes.ErrCodeConflict
+	ErrCodeMVCCConflict       = ricetypes.ErrCodeMVCCConflict
+	ErrCodeEndorsementFailed  = ricetypes.ErrCodeEndorsementFailed
+	ErrCodeSubmitFailed       = ricetypes.ErrCodeSubmitFailed
+	ErrCodeCommitFailed       = ricetypes.ErrCodeCommitFailed
+	ErrCodeCommitStatusFailed = ricetypes.ErrCodeCommitStatusFailed
+	ErrCodeUnavailable        = ricetypes.ErrCodeUnavailable
+	ErrCodeTimeout            = ricetypes.ErrCodeTimeout
+	ErrCodeConfiguration      = ricetypes.ErrCodeConfiguration
+	ErrCodeBadLedgerResponse  = ricetypes.ErrCodeBadLedgerResponse
+	ErrCodeInternal           = ricetypes.ErrCodeInternal
+)
+
+var errorCodeStatus = map[string]int{
+	ErrCodeInvalidArgument:    http.StatusBadRequest,
+	ErrCodeUnauthenticated:    http.StatusUnauthorized,
+	ErrCodeNotFound:           http.StatusNotFound,
+	ErrCodeForbidden:          http.StatusForbidden,
+	ErrCodeConflict:           http.StatusConflict,
+	ErrCodeMVCCConflict:       http.StatusConflict,
+	ErrCodeEndorsementFailed:  http.StatusBadGateway,
+	ErrCodeSubmitFailed:       http.StatusBadGateway,
+	ErrCodeCommitFailed:       http.StatusBadGateway,
+	ErrCodeCommitStatusFailed: http.StatusGatewayTimeout,
+	ErrCodeUnavailable:        http.StatusServiceUnavailable,
+	ErrCodeTimeout:            http.StatusGatewayTimeout,
+	ErrCodeConfiguration:      http.StatusInternalServerError,
+	ErrCodeBadLedgerResponse:  http.StatusBadGateway,
+	ErrCodeInternal:           http.StatusInternalServerError,
+}
+
+// ErrorDetail is a per-peer error reported by the gateway
+type ErrorDetail struct {
+	Address string `json:"address"`
+	MSPID   string `json:"mspID"`
+	Message string `json:"message"`
+}
+
+// APIError is the JSON body of every error response
+type APIError struct {
+	Code          string        `json:"code"`
+	Message       string        `json:"message"`
+	TransactionID string        `json:"transactionID,omitempty"`
+	Details       []ErrorDetail `json:"details,omitempty"`
+}
+
+func (e *APIError) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+func (e *APIError) HTTPStatus() int {
+	if status, ok := errorCodeStatus[e.Code]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+func newAPIError(code string, format string, args ...interface{}) *APIError {
+	return &APIError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// Chaincode error text mapped to codes, for messages without a code prefix.
+// These follow the phrasing used in Chaincode/contracts.
+var chaincodeMessageCodes = []struct {
+	fragment string
+	code     string
+}{
+	{"does not exist", ErrCodeNotFound},
+	{"not registered", ErrCodeNotFound},
+	{"cannot be read", ErrCodeNotFound},
+	{"already exists", ErrCodeConflict},
+	{"already attached", ErrCodeConflict},
+	{"already booked", ErrCodeConflict},
+	{"already stored", ErrCodeConflict},
+	{"already recorded", ErrCodeConflict},
+	{"already revoked", ErrCodeConflict},
+	{"already initialized", ErrCodeConflict},
+	{"only org", ErrCodeForbidden},
+	{"under following mspid", ErrCodeForbidden},
+	{"cannot perform", ErrCodeForbidden},
+	{"only the ", ErrCodeForbidden},
+	{"only identities", ErrCodeForbidden},
+	{"only certifiers", ErrCodeForbidden},
+	{"must be", ErrCodeInvalidArgument},
+	{"is required", ErrCodeInvalidArgument},
+	{"are required", ErrCodeInvalidArgument},
+	{"cannot be negative", ErrCodeInvalidArgument},
+	{"mismatch", ErrCodeInvalidArgument},
+	{"unknown incoterm", ErrCodeInvalidArgument},
+	{"unknown fieldemissionsmethod", ErrCodeInvalidArgument},
+	{"unknown transport mode", ErrCodeInvalidArgument},
+	{"not a valid", ErrCodeInvalidArgument},
+	{"exceeds", ErrCodeInvalidArgument},
+	{"incorrect number of params", ErrCodeInvalidArgument},
+}
+
+// Split a leading "CODE: " off a chaincode error message when CODE is one we know
+func chaincodeCodePrefix(message string) (string, string, bool) {
+	code, rest, found := strings.Cut(message, ": ")
+	if !found {
+		return "", message, false
+	}
+	if _, ok := errorCodeStatus[code]; !ok {
+		return "", message, false
+	}
+	return code, rest, true
+}
+
+// Pick a code from a chaincode error message, or fallback when nothing matches
+func chaincodeErrorCode(message string, fallback string) string {
+	if code, _, ok := chaincodeCodePrefix(message); ok {
+		return code
+	}
+	lower := strings.ToLower(message)
+	for _, m := range chaincodeMessageCodes {
+		if strings.Contains(lower, m.fragment) {
+			return m.code
+		}
+	}
+	return fallback
+}
+
+// Strip the peer's "chaincode response 500, " prefix from a detail message
+func chaincodeMessage(message string) string {
+	if i := strings.Index(message, "chaincode response"); i >= 0 {
+		if comma := strings.Index(message[i:], ", "); comma >= 0 {
+			return message[i+comma+2:]
+		}
+	}
+	return message
+}
+
+// toAPIError unwraps fabric-gateway and gRPC errors into an APIError
+func toAPIError(err error) *APIError {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+
+	var commitErr *client.CommitError
+	if errors.As(err, &commitErr) {
+		code := ErrCodeCommitFailed
+		if commitErr.Code == peer.TxValidationCode_MVCC_READ_CONFLICT || commitErr.Code == peer.TxValidationCode_PHANTOM_READ_CONFLICT {
+			code = ErrCodeMVCCConflict
+		}
+		return &APIError{Code: code, Message: commitErr.Error(), TransactionID: commitErr.TransactionID}
+	}
+
+	// The transaction error types share TransactionError; which one tells us the stage that failed
+	fallback := ErrCodeInternal
+	transactionID := ""
+	var endorseErr *client.EndorseError
+	var submitErr *client.SubmitError
+	var commitStatusErr *client.CommitStatusError
+	switch {
+	case errors.As(err, &endorseErr):
+		fallback, transactionID = ErrCodeEndorsementFailed, endorseErr.TransactionID
+	case errors.As(err, &submitErr):
+		fallback, transactionID = ErrCodeSubmitFailed, submitErr.TransactionID
+	case errors.As(err, &commitStatusErr):
+		fallback, transactionID = ErrCodeCommitStatusFailed, commitStatusErr.TransactionID
+	}
+
+	st, ok := status.FromError(err)
+	if !ok {
+		return &APIError{Code: fallback, Message: err.Error(), TransactionID: transactionID}
+	}
+
+	apiErr = &APIError{Code: fallback, Message: st.Message(), TransactionID: transactionID}
+	for _, detail := range st.Details() {
+		if d, ok := detail.(*gateway.ErrorDetail); ok {
+			apiErr.Details = append(apiErr.Details, ErrorDetail{
+				Address: d.GetAddress(),
+				MSPID:   d.GetMspId(),
+				Message: d.GetMessage(),
+			})
+		}
+	}
+
+	switch st.Code() {
+	case codes.Unavailable:
+		apiErr.Code = ErrCodeUnavailable
+	case codes.DeadlineExceeded:
+		apiErr.Code = ErrCodeTimeout
+	case codes.NotFound:
+		apiErr.Code = ErrCodeNotFound
+	case codes.PermissionDenied:
+		apiErr.Code = ErrCodeForbidden
+	case codes.InvalidArgument:
+		apiErr.Code = ErrCodeInvalidArgument
+	default:
+		// Chaincode failures arrive as Aborted with the contract's message in the details
+		if len(apiErr.Details) > 0 {
+			apiErr.Message = chaincodeMessage(apiErr.Details[0].Message)
+		}
+		apiErr.Code = chaincodeErrorCode(apiErr.Message, fallback)
+		_, apiErr.Message, _ = chaincodeCodePrefix(apiErr.Message)
+	}
+	return apiErr
+}
+
+// commitStatusError reports a transaction that was ordered but failed validation
+func commitStatusError(status *client.Status) *APIError {
+	code := ErrCodeCommitFailed
+	if status.Code == peer.TxValidationCode_MVCC_READ_CONFLICT || status.Code == peer.TxValidationCode_PHANTOM_READ_CONFLICT {
+		code = ErrCodeMVCCConflict
+	}
+	return &APIError{
+		Code:          code,
+		Message:       fmt.Sprintf("transaction %s failed to commit with status code %d (%s)", status.TransactionID, int32(status.Code), status.Code),
+		TransactionID: status.TransactionID,
+	}
+}
+
+// respondError writes err as a structured JSON error with the matching HTTP status
+func respondError(c *gin.Context, err error) {
+	apiErr := toAPIError(err)
+	logger.Printf("❌ %s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+	c.AbortWithStatusJSON(apiErr.HTTPStatus(), gin.H{"error": apiErr})
+}
@@ -0,0 +1,40 @@
+package main
+
+import "testing"
+
+func TestChaincodeErrorCode(t *testing.T) {
+	tests := []struct {
+		message string
+		want    string
+	}{
+		{"NOT_FOUND: the rice batch B1 does not exist", ErrCodeNotFound},
+		{"CONFLICT: batch B1 is already booked on consignment C1", ErrCodeConflict},
+		{"FORBIDDEN: only the harvesting farmer can flag batch B1", ErrCodeForbidden},
+		{"INVALID_ARGUMENT: quantity exceeds what is left", ErrCodeInvalidArgument},
+		{"NOT_A_CODE: the rice batch B1 does not exist", ErrCodeNotFound},
+		{"the rice batch B1 does not exist", ErrCodeNotFound},
+		{"the asset B1 already exists", ErrCodeConflict},
+		{"user under following MSPID: Org2MSP can't create a rice batch", ErrCodeForbidden},
+		{"unknown incoterm \"XYZ\"", ErrCodeInvalidArgument},
+		{"unknown transport mode \"teleport\"", ErrCodeInvalidArgument},
+		{"quantity must be positive", ErrCodeInvalidArgument},
+		{"failed to read from world state: unknown error", ErrCodeEndorsementFailed},
+		{"peer of Org1MSP rejected the proposal", ErrCodeEndorsementFailed},
+		{"", ErrCodeEndorsementFailed},
+	}
+	for _, tt := range tests {
+		if got := chaincodeErrorCode(tt.message, ErrCodeEndorsementFailed); got != tt.want {
+			t.Errorf("chaincodeErrorCode(%q) = %s, want %s", tt.message, got, tt.want)
+		}
+	}
+}
+
+func TestChaincodeCodePrefixStripped(t *testing.T) {
+	code, message, ok := chaincodeCodePrefix("NOT_FOUND: the rice batch B1 does not exist")
+	if !ok || code != ErrCodeNotFound || message != "the rice batch B1 does not exist" {
+		t.Fatalf("got %q %q %v", code, message, ok)
+	}
+	if _, message, ok := chaincodeCodePrefix("failed to read from world state: boom"); ok || message != "failed to read from world state: boom" {
+		t.Fatalf("unprefixed message changed: %q %v", message, ok)
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-pdf/fpdf"
+
+	"ricetypes"
+)
+
+// Render a packing list as a landscape A4 document
+func renderPackingList(list *ricetypes.PackingList) ([]byte, error) {
+	pdf := fpdf.New("L", "mm", "A4", "")
+	pdf.SetMargins(10, 10, 10)
+	pdf.AddPage()
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.CellFormat(0, 10, "Packing List", "", 1, "L", false, 0, "")
+
+	pdf.SetFont("Helvetica", "", 10)
+	header := [][2]string{
+		{"Consignment", list.ConsignmentID},
+		{"Exporter", list.Exporter},
+		{"Consignee", list.Consignee},
+		{"Destination", list.DestinationCountry},
+		{"Incoterm", list.Incoterm},
+		{"HS code", list.HSCode},
+		{"Bill of lading", list.BillOfLading},
+		{"Vessel", list.Vessel},
+		{"Containers", strings.Join(list.ContainerNumbers, ", ")},
+	}
+	for _, field := range header {
+		if field[1] == "" {
+			continue
+		}
+		pdf.CellFormat(35, 6, field[0]+":", "", 0, "L", false, 0, "")
+		pdf.CellFormat(0, 6, tr(field[1]), "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(4)
+
+	widths := []float64{30, 30, 25, 70, 22, 30, 70}
+	columns := []string{"Batch", "Variety", "Harvested", "Description", "Packages", "Net kg", "Serials"}
+	pdf.SetFont("Helvetica", "B", 9)
+	pdf.SetFillColor(230, 230, 230)
+	for i, column := range columns {
+		pdf.CellFormat(widths[i], 7, column, "1", 0, "L", true, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Helvetica", "", 9)
+	for _, line := range list.Lines {
+		cells := []string{
+			line.BatchID, line.Variety, line.HarvestDate, line.Description,
+			strconv.Itoa(line.Packages), strconv.Itoa(line.NetWeightInKg), line.SerialRange,
+		}
+		for i, cell := range cells {
+			align := "L"
+			if i == 4 || i == 5 {
+				align = "R"
+			}
+			pdf.CellFormat(widths[i], 6, tr(cell), "1", 0, align, false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+
+	pdf.SetFont("Helvetica", "B", 9)
+	pdf.CellFormat(widths[0]+widths[1]+widths[2]+widths[3], 7, "Total", "1", 0, "R", false, 0, "")
+	pdf.CellFormat(widths[4], 7, strconv.Itoa(list.TotalPackages), "1", 0, "R", false, 0, "")
+	pdf.CellFormat(widths[5], 7, strconv.Itoa(list.TotalNetWeightInKg), "1", 0, "R", false, 0, "")
+	pdf.CellFormat(widths[6], 7, "", "1", 1, "L", false, 0, "")
+
+	var out bytes.Buffer
+	if err := pdf.Output(&out); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func registerExportRoutes(router *gin.Engine) {
+	// Consolidated packing list of an export consignment, ?format=json|pdf
+	router.GET("/api/exports/:consignmentID/packing-list", func(c *gin.Context) {
+		consignmentID := c.Param("consignmentID")
+		var list ricetypes.PackingList
+		if err := evaluateJSON(callerFrom(c), "GetPackingList", &list, consignmentID); err != nil {
+			respondError(c, err)
+			return
+		}
+
+		switch c.DefaultQuery("format", "json") {
+		case "json":
+			c.JSON(http.StatusOK, gin.H{"data": list})
+		case "pdf":
+			pdf, err := renderPackingList(&list)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to render packing list", "error": err.Error()})
+				return
+			}
+			c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "packing-list-"+consignmentID+".pdf"))
+			c.Data(http.StatusOK, "application/pdf", pdf)
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"message": "format must be json or pdf"})
+		}
+	})
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+)
+
+// CAClient talks to a Fabric CA server's REST API. Enroll authenticates
+// with the enrollment ID and secret; the other calls carry a token signed
+// by an already enrolled identity, as the fabric-ca-client CLI does.
+// Enrollment always generates a fresh P-256 key pair locally, so private
+// keys never leave this process.
+
+type CAClient struct {
+	url    string
+	caName string
+	http   *http.Client
+}
+
+type CAAttribute struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	ECert bool   `json:"ecert,omitempty"` // Include the attribute in enrollment certificates
+}
+
+type RegistrationRequest struct {
+	EnrollmentID   string        `json:"id"`
+	Type           string        `json:"type,omitempty"`
+	Secret         string        `json:"secret,omitempty"`
+	MaxEnrollments int           `json:"max_enrollments,omitempty"`
+	Affiliation    string        `json:"affiliation"`
+	Attributes     []CAAttribute `json:"attrs,omitempty"`
+	CAName         string        `json:"caname,omitempty"`
+}
+
+type RevocationRequest struct {
+	EnrollmentID string `json:"id,omitempty"`
+	AKI          string `json:"aki,omitempty"`
+	Serial       string `json:"serial,omitempty"`
+	Reason       string `json:"reason,omitempty"`
+	CAName       string `json:"caname,omitempty"`
+}
+
+// Enrollment is a freshly issued certificate and the key it was issued for
+type Enrollment struct {
+	CertificatePEM []byte
+	PrivateKeyPEM  []byte
+}
+
+func newCAClient(config CAConfig) (*CAClient, error) {
+	transport := &http.Transport{}
+	if strings.HasPrefix(config.URL, "https://") {
+		certificate, err := loadCertificate(config.TLSCertPath)
+		if err != nil {
+			return nil, err
+		}
+		roots := x509.NewCertPool()
+		roots.AddCert(certificate)
+		transport.TLSClientConfig = &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}
+	}
+	return &CAClient{
+		url:    strings.TrimSuffix(config.URL, "/"),
+		caName: config.CAName,
+		http:   &http.Client{Transport: transport, Timeout: 30 * time.Second},
+	}, nil
+}
+
+// Enroll issues a certificate for a registered identity
+func (ca *CAClient) Enroll(enrollmentID string, secret string) (*Enrollment, error) {
+	csr, keyPEM, err := newCSR(enrollmentID)
+	if err != nil {
+		return nil, err
+	}
+	body, _ := json.Marshal(map[string]string{"certificate_request": string(csr), "caname": ca.caName})
+	req, err := http.NewRequest(http.MethodPost, ca.url+"/api/v1/enroll", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(enrollmentID, secret)
+
+	var result struct {
+		Cert string `json:"Cert"`
+	}
+	if err := ca.do(req, &result); err != nil {
+		return nil, err
+	}
+	certPEM, err := base64.StdEncoding.DecodeString(result.Cert)
+	if err != nil {
+		return nil, newAPIError(ErrCodeBadLedgerResponse, "CA returned an unreadable certificate: %v", err)
+	}
+	return &Enrollment{CertificatePEM: certPEM, PrivateKeyPEM: keyPEM}, nil
+}
+
+// Reenroll issues a new certificate, with a new key, for an enrolled identity
+func (ca *CAClient) Reenroll(id *WalletIdentity) (*Enrollment, error) {
+	csr, keyPEM, err := newCSR(id.EnrollmentID)
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		Cert string `json:"Cert"`
+	}
+	payload := map[string]string{"certificate_request": string(csr), "caname": ca.caName}
+	if err := ca.authorized(id, "/api/v1/reenroll", payload, &result); err != nil {
+		return nil, err
+	}
+	certPEM, err := base64.StdEncoding.DecodeString(result.Cert)
+	if err != nil {
+		return nil, newAPIError(ErrCodeBadLedgerResponse, "CA returned an unreadable certificate: %v", err)
+	}
+	return &Enrollment{CertificatePEM: certPEM, PrivateKeyPEM: keyPEM}, nil
+}
+
+// Register creates an identity and returns its enrollment secret
+func (ca *CAClient) Register(registrar *WalletIdentity, request RegistrationRequest) (string, error) {
+	request.CAName = ca.caName
+	var result struct {
+		Secret string `json:"secret"`
+	}
+	if err := ca.authorized(registrar, "/api/v1/register", request, &result); err != nil {
+		return "", err
+	}
+	return result.Secret, nil
+}
+
+// Revoke revokes every certificate of an enrollment ID, or one certificate by AKI and serial
+func (ca *CAClient) Revoke(registrar *WalletIdentity, request RevocationRequest) error {
+	request.CAName = ca.caName
+	var result json.RawMessage
+	return ca.authorized(registrar, "/api/v1/revoke", request, &result)
+}
+
+// Send a request authenticated with a token signed by an enrolled identity
+func (ca *CAClient) authorized(id *WalletIdentity, uri string, payload interface{}, result interface{}) error {
+	body, _ := json.Marshal(payload)
+	token, err := caToken(id, http.MethodPost, uri, body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, ca.url+uri, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", token)
+	return ca.do(req, result)
+}
+
+// Fabric CA token: base64(cert) "." base64(signature over
+// method "." base64(uri) "." base64(body) "." base64(cert))
+func caToken(id *WalletIdentity, method string, uri string, body []byte) (string, error) {
+	encode := base64.StdEncoding.EncodeToString
+	b64Cert := encode([]byte(id.Credentials.Certificate))
+	payload := method + "." + encode([]byte(uri)) + "." + encode(body) + "." + b64Cert
+
+	sign, err := newSignFromPEM([]byte(id.Credentials.PrivateKey))
+	if err != nil {
+		return "", err
+	}
+	// The gateway signer normalises to low-S, which the CA insists on
+	digest := sha256.Sum256([]byte(payload))
+	signature, err := sign(digest[:])
+	if err != nil {
+		return "", err
+	}
+	return b64Cert + "." + encode(signature), nil
+}
+
+func (ca *CAClient) do(req *http.Request, result interface{}) error {
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := ca.http.Do(req)
+	if err != nil {
+		return newAPIError(ErrCodeUnavailable, "CA %s is unavailable: %v", ca.url, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return newAPIError(ErrCodeUnavailable, "CA %s response was cut short: %v", ca.url, err)
+	}
+
+	var envelope struct {
+		Success bool            `json:"success"`
+		Result  json.RawMessage `json:"result"`
+		Errors  []struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return newAPIError(ErrCodeBadLedgerResponse, "CA returned HTTP %d with an unreadable body", resp.StatusCode)
+	}
+	if !envelope.Success {
+		var messages []string
+		for _, e := range envelope.Errors {
+			messages = append(messages, e.Message)
+		}
+		message := strings.Join(messages, "; ")
+		return newAPIError(caErrorCode(resp.StatusCode, message), "CA request failed: %s", message)
+	}
+	if err := json.Unmarshal(envelope.Result, result); err != nil {
+		return newAPIError(ErrCodeBadLedgerResponse, "CA returned an unexpected result: %v", err)
+	}
+	return nil
+}
+
+func caErrorCode(status int, message string) string {
+	switch {
+	case strings.Contains(message, "already registered"):
+		return ErrCodeConflict
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return ErrCodeForbidden
+	case status == http.StatusNotFound:
+		return ErrCodeNotFound
+	case status >= 400 && status < 500:
+		return ErrCodeInvalidArgument
+	}
+	return ErrCodeUnavailable
+}
+
+// Generate a P-256 key and a CSR for it with the enrollment ID as common name
+func newCSR(enrollmentID string) ([]byte, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: enrollmentID},
+	}, key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create CSR: %w", err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	csrPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+	return csrPEM, keyPEM, nil
+}
+
+// Serial and AKI in the hex form the CA uses for revocation
+func certificateSerialAKI(certPEM string) (string, string, error) {
+	certificate, err := identity.CertificateFromPEM([]byte(certPEM))
+	if err != nil {
+		return "", "", err
+	}
+	return hex.EncodeToString(certificate.SerialNumber.Bytes()), hex.EncodeToString(certificate.AuthorityKeyId), nil
+}
@@ -0,0 +1,349 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+// GatewayPool holds one long-lived gRPC connection per org and a Gateway per
+// identity signing through it. gRPC reconnects a dropped connection by itself
+// with exponential backoff; the pool adds backoff around the initial dial, moves
+// on to the org's next configured peer when one stays unreachable, swaps the
+// Gateway when an identity's certificate or key changes on disk, and closes
+// everything on shutdown. Callers who sign offline get a Gateway with no
+// signer, which can only build requests and send ones signed elsewhere.
+
+const (
+	gatewayWatchInterval = 10 * time.Second
+	gatewayMinBackoff    = time.Second
+	gatewayMaxBackoff    = 30 * time.Second
+)
+
+// Pool used by every ledger call, created in main
+var gateways *GatewayPool
+
+type GatewayPool struct {
+	orgs   map[string]*orgGateway
+	wallet Wallet
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// Each org has one connection, shared by a Gateway per identity that signs through it
+type orgGateway struct {
+	mu         sync.Mutex
+	org        string
+	config     OrgConfig
+	peer       int // Index into config.Peers of the peer in use
+	connection *grpc.ClientConn
+	wallet     Wallet
+	identities map[string]*identityGateway // Keyed by Caller.identityKey
+	failures   int
+	retryAt    time.Time
+}
+
+type identityGateway struct {
+	gateway     *client.Gateway
+	certPath    string
+	keyPath     string
+	walletLabel string
+	certificate string // PEM of an offline signer
+	signer      Signer // Nil for wallet and offline identities
+	modTime     time.Time
+}
+
+func newGatewayPool(orgs map[string]OrgConfig, wallet Wallet) *GatewayPool {
+	pool := &GatewayPool{
+		orgs:   make(map[string]*orgGateway),
+		wallet: wallet,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	for org, config := range orgs {
+		pool.orgs[org] = &orgGateway{org: org, config: config, wallet: wallet, identities: make(map[string]*identityGateway)}
+	}
+	go pool.watch(gatewayWatchInterval)
+	return pool
+}
+
+// Gateway returns a Gateway that signs as the caller, connecting on first use
+func (p *GatewayPool) Gateway(caller Caller) (*client.Gateway, error) {
+	o, ok := p.orgs[caller.Org]
+	if !ok {
+		return nil, newAPIError(ErrCodeInvalidArgument, "unknown organization %q", caller.Org)
+	}
+	return o.get(caller)
+}
+
+// Forget closes the caller's Gateway, so a revoked or replaced identity is not used again
+func (p *GatewayPool) Forget(caller Caller) {
+	o, ok := p.orgs[caller.Org]
+	if !ok {
+		return
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if id, ok := o.identities[caller.identityKey()]; ok {
+		id.close()
+		delete(o.identities, caller.identityKey())
+	}
+}
+
+// Close stops the watcher and closes every Gateway and connection
+func (p *GatewayPool) Close() {
+	close(p.stop)
+	<-p.done
+	for _, o := range p.orgs {
+		o.mu.Lock()
+		o.disconnect()
+		o.mu.Unlock()
+	}
+}
+
+// Periodically pick up rotated identities and drop connections that were shut down
+func (p *GatewayPool) watch(interval time.Duration) {
+	defer close(p.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			for _, o := range p.orgs {
+				o.check()
+			}
+		}
+	}
+}
+
+func (o *orgGateway) get(caller Caller) (*client.Gateway, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.connection == nil {
+		if wait := time.Until(o.retryAt); wait > 0 {
+			return nil, newAPIError(ErrCodeUnavailable, "gateway for %s is unavailable, retrying in %v", o.org, wait.Round(time.Second))
+		}
+		if err := o.dial(); err != nil {
+			o.failures++
+			backoff := gatewayMinBackoff << (o.failures - 1)
+			if backoff > gatewayMaxBackoff || backoff <= 0 {
+				backoff = gatewayMaxBackoff
+			}
+			o.retryAt = time.Now().Add(backoff)
+			logger.Printf("gateway: %s connect to %s failed (attempt %d, next in %v): %v",
+				o.org, o.config.Peers[o.peer].Endpoint, o.failures, backoff, err)
+			o.nextPeer()
+			return nil, newAPIError(ErrCodeUnavailable, "gateway for %s is unavailable: %v", o.org, err)
+		}
+		o.failures = 0
+	}
+
+	if id, ok := o.identities[caller.identityKey()]; ok {
+		return id.gateway, nil
+	}
+	id := &identityGateway{certPath: caller.CertPath, keyPath: caller.KeyPath, walletLabel: caller.Wallet, certificate: caller.Certificate}
+	if err := o.open(id); err != nil {
+		return nil, newAPIError(ErrCodeConfiguration, "identity for %s cannot be loaded: %v", caller, err)
+	}
+	o.identities[caller.identityKey()] = id
+	return id.gateway, nil
+}
+
+// dial opens the connection to the current peer. Callers hold o.mu.
+func (o *orgGateway) dial() error {
+	peer := o.config.Peers[o.peer]
+	connection, err := newGrpcConnection(peer.TLSCertPath, peer.GatewayPeer, peer.Endpoint)
+	if err != nil {
+		return err
+	}
+	o.connection = connection
+	return nil
+}
+
+// open loads an identity from disk or the wallet and opens a Gateway for it
+// on the org's connection, replacing any Gateway it already had. Callers hold o.mu.
+func (o *orgGateway) open(id *identityGateway) error {
+	modTime, err := o.modTime(id)
+	if err != nil {
+		return err
+	}
+
+	var x509ID *identity.X509Identity
+	var sign identity.Sign
+	var signer Signer
+	if id.certificate != "" {
+		if x509ID, err = newIdentityFromPEM([]byte(id.certificate), o.config.MSPID); err != nil {
+			return err
+		}
+	} else if id.walletLabel != "" {
+		entry, err := o.wallet.Get(id.walletLabel)
+		if err != nil {
+			return err
+		}
+		if entry == nil {
+			return fmt.Errorf("wallet has no identity %s", id.walletLabel)
+		}
+		if entry.Org != o.org {
+			return fmt.Errorf("wallet identity %s belongs to %s", id.walletLabel, entry.Org)
+		}
+		if x509ID, err = newIdentityFromPEM([]byte(entry.Credentials.Certificate), entry.MSPID); err != nil {
+			return err
+		}
+		if sign, err = newSignFromPEM([]byte(entry.Credentials.PrivateKey)); err != nil {
+			return err
+		}
+	} else {
+		certificate, err := loadCertificate(id.certPath)
+		if err != nil {
+			return err
+		}
+		if x509ID, err = identity.NewX509Identity(o.config.MSPID, certificate); err != nil {
+			return fmt.Errorf("failed to create identity: %w", err)
+		}
+		if signer, err = newSigner(o.config.Signer, certificate, id.keyPath); err != nil {
+			return err
+		}
+		sign = signer.Sign
+	}
+
+	options := []client.ConnectOption{
+		client.WithClientConnection(o.connection),
+		client.WithEvaluateTimeout(5 * time.Second),
+		client.WithEndorseTimeout(15 * time.Second),
+		client.WithSubmitTimeout(5 * time.Second),
+		client.WithCommitStatusTimeout(1 * time.Minute),
+	}
+	if sign != nil {
+		options = append(options, client.WithSign(sign))
+	}
+	gw, err := client.Connect(x509ID, options...)
+	if err != nil {
+		if signer != nil {
+			signer.Close()
+		}
+		return fmt.Errorf("failed to connect to gateway: %w", err)
+	}
+
+	// The old Gateway does not own the connection, so closing it leaves the connection open
+	id.close()
+	id.gateway = gw
+	id.signer = signer
+	id.modTime = modTime
+	return nil
+}
+
+// nextPeer moves on to the org's next configured peer. Callers hold o.mu with no open connection.
+func (o *orgGateway) nextPeer() {
+	if o.connection == nil {
+		o.peer = (o.peer + 1) % len(o.config.Peers)
+	}
+}
+
+// disconnect closes every Gateway and the connection. Callers hold o.mu.
+func (o *orgGateway) disconnect() {
+	for key, id := range o.identities {
+		id.close()
+		delete(o.identities, key)
+	}
+	if o.connection != nil {
+		o.connection.Close()
+		o.connection = nil
+	}
+}
+
+func (o *orgGateway) check() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.connection == nil {
+		return
+	}
+	switch o.connection.GetState() {
+	case connectivity.Shutdown:
+		logger.Printf("gateway: %s connection shut down, reconnecting on next request", o.org)
+		o.disconnect()
+		return
+	case connectivity.TransientFailure:
+		// gRPC keeps redialling a dead peer forever, so fail over while another is configured
+		if len(o.config.Peers) > 1 {
+			logger.Printf("gateway: %s peer %s unreachable, failing over", o.org, o.config.Peers[o.peer].Endpoint)
+			o.disconnect()
+			o.nextPeer()
+			return
+		}
+	}
+
+	for _, id := range o.identities {
+		modTime, err := o.modTime(id)
+		if err != nil || !modTime.After(id.modTime) {
+			continue
+		}
+		logger.Printf("gateway: %s identity %s%s changed on disk, reloading", o.org, id.walletLabel, id.certPath)
+		if err := o.open(id); err != nil {
+			// Keep serving with the previous identity until the new files are readable
+			logger.Printf("gateway: %s identity reload failed: %v", o.org, err)
+		}
+	}
+}
+
+// close releases the identity's Gateway and signer, if it has them
+func (id *identityGateway) close() {
+	if id.gateway != nil {
+		id.gateway.Close()
+		id.gateway = nil
+	}
+	if id.signer != nil {
+		id.signer.Close()
+		id.signer = nil
+	}
+}
+
+func (o *orgGateway) modTime(id *identityGateway) (time.Time, error) {
+	if id.certificate != "" {
+		// Held in memory, so it never changes under the Gateway
+		return id.modTime, nil
+	}
+	if id.walletLabel != "" {
+		return o.wallet.ModTime(id.walletLabel)
+	}
+	if o.config.Signer.Type == SignerPKCS11 {
+		return identityModTime(id.certPath, "")
+	}
+	return identityModTime(id.certPath, id.keyPath)
+}
+
+// Latest modification time of an identity's certificate and key files
+func identityModTime(certPath string, keyPath string) (time.Time, error) {
+	latest := time.Time{}
+	info, err := os.Stat(certPath)
+	if err != nil {
+		return latest, fmt.Errorf("failed to read certificate file: %w", err)
+	}
+	latest = info.ModTime()
+	if keyPath == "" {
+		// Keys held in an HSM
+		return latest, nil
+	}
+
+	files, err := os.ReadDir(keyPath)
+	if err != nil {
+		return latest, fmt.Errorf("failed to read private key directory: %w", err)
+	}
+	for _, file := range files {
+		info, err := os.Stat(path.Join(keyPath, file.Name()))
+		if err == nil && info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
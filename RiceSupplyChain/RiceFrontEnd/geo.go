@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"ricetypes"
+)
+
+// GeoJSON FeatureCollection (RFC 7946) of field boundaries
+type FeatureCollection struct {
+	Type     string    `json:"type"`
+	Features []Feature `json:"features"`
+}
+
+type Feature struct {
+	Type       string                    `json:"type"`
+	ID         string                    `json:"id"`
+	Geometry   *ricetypes.GeoJSONPolygon `json:"geometry"`
+	Properties map[string]interface{}    `json:"properties"`
+}
+
+// Build a FeatureCollection of fields, tagged with their farm's details
+func fieldFeatures(caller Caller, farmID string) (*FeatureCollection, error) {
+	var fields []*ricetypes.Field
+	if err := evaluateJSON(caller, "GetFields", &fields, farmID); err != nil {
+		return nil, err
+	}
+
+	farms := map[string]ricetypes.Farm{}
+	collection := &FeatureCollection{Type: "FeatureCollection", Features: []Feature{}}
+	for _, field := range fields {
+		farm, ok := farms[field.FarmID]
+		if !ok {
+			if err := evaluateJSON(caller, "ReadFarm", &farm, field.FarmID); err != nil {
+				return nil, err
+			}
+			farms[field.FarmID] = farm
+		}
+
+		collection.Features = append(collection.Features, Feature{
+			Type:     "Feature",
+			ID:       field.FieldID,
+			Geometry: field.Boundary,
+			Properties: map[string]interface{}{
+				"fieldID":    field.FieldID,
+				"name":       field.Name,
+				"areaHa":     float64(field.AreaCentiHa) / 100,
+				"cropSeason": field.CropSeason,
+				"farmID":     farm.FarmID,
+				"farmName":   farm.Name,
+				"owner":      farm.Owner,
+				"region":     farm.Region,
+			},
+		})
+	}
+	return collection, nil
+}
+
+func registerGeoRoutes(router *gin.Engine) {
+	// Field boundaries as GeoJSON for mapping tools, ?farmID= limits to one farm
+	router.GET("/api/geo/fields", func(c *gin.Context) {
+		collection, err := fieldFeatures(callerFrom(c), c.Query("farmID"))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		if c.Query("download") != "" {
+			c.Header("Content-Disposition", `attachment; filename="fields.geojson"`)
+		}
+		data, _ := json.Marshal(collection)
+		c.Data(http.StatusOK, "application/geo+json", data)
+	})
+}
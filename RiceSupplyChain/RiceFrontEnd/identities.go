@@ -0,0 +1,417 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// IdentityManager enrolls identities with each org's Fabric CA and keeps
+// them in the wallet. An org's registrar is enrolled into the wallet on
+// first use with the bootstrap credentials from the config. Certificates
+// are re-enrolled in the background once they are within renewBefore of
+// expiring; the gateway pool notices the changed wallet entry and reloads.
+
+const identityRenewInterval = time.Hour
+
+type IdentityManager struct {
+	wallet      Wallet
+	renewBefore time.Duration
+
+	mu  sync.Mutex
+	cas map[string]*CAClient
+}
+
+// Summary of a wallet identity returned by the admin API
+type IdentityInfo struct {
+	Label        string `json:"label"`
+	Org          string `json:"org"`
+	MSPID        string `json:"mspID"`
+	EnrollmentID string `json:"enrollmentID"`
+	Serial       string `json:"serial"`
+	NotAfter     string `json:"notAfter"`
+}
+
+func newIdentityManager(wallet Wallet, config WalletConfig) *IdentityManager {
+	renewBefore, _ := time.ParseDuration(config.RenewBefore)
+	return &IdentityManager{wallet: wallet, renewBefore: renewBefore, cas: make(map[string]*CAClient)}
+}
+
+func (m *IdentityManager) ca(org string) (*CAClient, error) {
+	config, ok := appConfig.Orgs[org]
+	if !ok {
+		return nil, newAPIError(ErrCodeInvalidArgument, "unknown organization %q", org)
+	}
+	if config.CA == nil {
+		return nil, newAPIError(ErrCodeConfiguration, "no CA is configured for %s", org)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if ca, ok := m.cas[org]; ok {
+		return ca, nil
+	}
+	ca, err := newCAClient(*config.CA)
+	if err != nil {
+		return nil, newAPIError(ErrCodeConfiguration, "CA client for %s: %v", org, err)
+	}
+	m.cas[org] = ca
+	return ca, nil
+}
+
+// Registrar returns the org's registrar identity, enrolling it on first use
+func (m *IdentityManager) registrar(org string) (*WalletIdentity, error) {
+	ca, err := m.ca(org)
+	if err != nil {
+		return nil, err
+	}
+	config := appConfig.Orgs[org].CA
+	registrar, err := m.wallet.Get(walletLabel(config.RegistrarID, org))
+	if registrar != nil || err != nil {
+		return registrar, err
+	}
+	return m.enroll(ca, org, config.RegistrarID, config.RegistrarSecret)
+}
+
+func (m *IdentityManager) enroll(ca *CAClient, org string, enrollmentID string, secret string) (*WalletIdentity, error) {
+	enrollment, err := ca.Enroll(enrollmentID, secret)
+	if err != nil {
+		return nil, err
+	}
+	id := &WalletIdentity{
+		Label:        walletLabel(enrollmentID, org),
+		Type:         "X.509",
+		Version:      1,
+		MSPID:        appConfig.Orgs[org].MSPID,
+		Org:          org,
+		EnrollmentID: enrollmentID,
+	}
+	id.Credentials.Certificate = string(enrollment.CertificatePEM)
+	id.Credentials.PrivateKey = string(enrollment.PrivateKeyPEM)
+	if err := m.wallet.Put(id); err != nil {
+		return nil, err
+	}
+	logger.Printf("identities: enrolled %s", id.Label)
+	return id, nil
+}
+
+// Enroll an identity that is already registered with the org's CA
+func (m *IdentityManager) Enroll(org string, enrollmentID string, secret string) (*WalletIdentity, error) {
+	ca, err := m.ca(org)
+	if err != nil {
+		return nil, err
+	}
+	return m.enroll(ca, org, enrollmentID, secret)
+}
+
+// Onboard registers a new identity with the org's CA and enrolls it into the wallet
+func (m *IdentityManager) Onboard(org string, request RegistrationRequest) (*WalletIdentity, error) {
+	ca, err := m.ca(org)
+	if err != nil {
+		return nil, err
+	}
+	registrar, err := m.registrar(org)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := ca.Register(registrar, request)
+	if err != nil {
+		return nil, err
+	}
+	return m.enroll(ca, org, request.EnrollmentID, secret)
+}
+
+// Reenroll replaces an identity's certificate and key in the wallet
+func (m *IdentityManager) Reenroll(label string) (*WalletIdentity, error) {
+	id, err := m.get(label)
+	if err != nil {
+		return nil, err
+	}
+	ca, err := m.ca(id.Org)
+	if err != nil {
+		return nil, err
+	}
+	enrollment, err := ca.Reenroll(id)
+	if err != nil {
+		return nil, err
+	}
+	id.Credentials.Certificate = string(enrollment.CertificatePEM)
+	id.Credentials.PrivateKey = string(enrollment.PrivateKeyPEM)
+	if err := m.wallet.Put(id); err != nil {
+		return nil, err
+	}
+	logger.Printf("identities: re-enrolled %s", id.Label)
+	return id, nil
+}
+
+// Revoke revokes an identity with the CA and removes it from the wallet.
+// With certificateOnly the identity stays registered and can enroll again.
+func (m *IdentityManager) Revoke(label string, reason string, certificateOnly bool) error {
+	id, err := m.get(label)
+	if err != nil {
+		return err
+	}
+	ca, err := m.ca(id.Org)
+	if err != nil {
+		return err
+	}
+	registrar, err := m.registrar(id.Org)
+	if err != nil {
+		return err
+	}
+	if registrar.Label == id.Label {
+		return newAPIError(ErrCodeInvalidArgument, "the registrar %s cannot be revoked through the API", label)
+	}
+
+	request := RevocationRequest{Reason: reason}
+	if certificateOnly {
+		if request.Serial, request.AKI, err = certificateSerialAKI(id.Credentials.Certificate); err != nil {
+			return err
+		}
+	} else {
+		request.EnrollmentID = id.EnrollmentID
+	}
+	if err := ca.Revoke(registrar, request); err != nil {
+		return err
+	}
+	if err := m.wallet.Remove(label); err != nil {
+		return err
+	}
+	gateways.Forget(Caller{Org: id.Org, Wallet: label})
+	logger.Printf("identities: revoked %s (%s)", label, reason)
+	return nil
+}
+
+func (m *IdentityManager) get(label string) (*WalletIdentity, error) {
+	id, err := m.wallet.Get(label)
+	if err != nil {
+		return nil, err
+	}
+	if id == nil {
+		return nil, newAPIError(ErrCodeNotFound, "wallet has no identity %s", label)
+	}
+	return id, nil
+}
+
+// List summarises every identity in the wallet
+func (m *IdentityManager) List() ([]IdentityInfo, error) {
+	labels, err := m.wallet.List()
+	if err != nil {
+		return nil, err
+	}
+	infos := []IdentityInfo{}
+	for _, label := range labels {
+		id, err := m.wallet.Get(label)
+		if err != nil {
+			return nil, err
+		}
+		if id == nil {
+			continue // Removed since the listing
+		}
+		info := IdentityInfo{Label: label, Org: id.Org, MSPID: id.MSPID, EnrollmentID: id.EnrollmentID}
+		if certificate, err := identity.CertificateFromPEM([]byte(id.Credentials.Certificate)); err == nil {
+			info.Serial = fmt.Sprintf("%x", certificate.SerialNumber)
+			info.NotAfter = certificate.NotAfter.UTC().Format(time.RFC3339)
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// RenewExpiring re-enrolls every wallet identity that expires within renewBefore
+func (m *IdentityManager) RenewExpiring(now time.Time) {
+	labels, err := m.wallet.List()
+	if err != nil {
+		logger.Printf("identities: cannot list wallet: %v", err)
+		return
+	}
+	for _, label := range labels {
+		id, err := m.wallet.Get(label)
+		if err != nil || id == nil {
+			logger.Printf("identities: cannot read %s: %v", label, err)
+			continue
+		}
+		certificate, err := identity.CertificateFromPEM([]byte(id.Credentials.Certificate))
+		if err != nil {
+			logger.Printf("identities: %s has an unreadable certificate: %v", label, err)
+			continue
+		}
+		if certificate.NotAfter.Sub(now) > m.renewBefore {
+			continue
+		}
+		if appConfig.Orgs[id.Org].CA == nil {
+			logger.Printf("identities: %s expires %s but %s has no CA configured", label, certificate.NotAfter.Format(time.RFC3339), id.Org)
+			continue
+		}
+		if _, err := m.Reenroll(label); err != nil {
+			logger.Printf("identities: re-enrolling %s failed: %v", label, err)
+		}
+	}
+}
+
+// renewPeriodically runs RenewExpiring now and then every identityRenewInterval
+func (m *IdentityManager) renewPeriodically() {
+	ticker := time.NewTicker(identityRenewInterval)
+	defer ticker.Stop()
+	for {
+		m.RenewExpiring(time.Now())
+		<-ticker.C
+	}
+}
+
+func registerIdentityRoutes(router *gin.Engine, manager *IdentityManager, auth *Authenticator) {
+	admin := router.Group("/api/admin/identities", requireAdmin)
+
+	admin.GET("", func(c *gin.Context) {
+		infos, err := manager.List()
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": infos})
+	})
+
+	// Register a new identity with the org's CA, enroll it and optionally
+	// create a web user mapped to it
+	admin.POST("", func(c *gin.Context) {
+		var req struct {
+			Org            string        `json:"org"`
+			EnrollmentID   string        `json:"enrollmentID"`
+			Type           string        `json:"type"`
+			Affiliation    string        `json:"affiliation"`
+			MaxEnrollments int           `json:"maxEnrollments"`
+			Attributes     []CAAttribute `json:"attributes"`
+			Username       string        `json:"username"`
+			Password       string        `json:"password"`
+			OIDCSubject    string        `json:"oidcSubject"`
+		}
+		if err := c.BindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Bad request", "error": err.Error()})
+			return
+		}
+		if !safeIDPattern.MatchString(req.EnrollmentID) {
+			respondError(c, newAPIError(ErrCodeInvalidArgument, "enrollmentID must start with a letter or digit and contain only letters, digits, '.', '_' or '-'"))
+			return
+		}
+		if req.Type == "" {
+			req.Type = "client"
+		}
+
+		var user *User
+		if req.Username != "" {
+			if req.Password == "" && req.OIDCSubject == "" {
+				respondError(c, newAPIError(ErrCodeInvalidArgument, "a password or oidcSubject is required for the web user"))
+				return
+			}
+			user = &User{Username: req.Username, OIDCSubject: req.OIDCSubject, Org: req.Org, Wallet: walletLabel(req.EnrollmentID, req.Org)}
+			if auth.users.Get(req.Username) != nil {
+				respondError(c, newAPIError(ErrCodeConflict, "user %s already exists", req.Username))
+				return
+			}
+			if req.Password != "" {
+				if len(req.Password) < 8 {
+					respondError(c, newAPIError(ErrCodeInvalidArgument, "password must be at least 8 characters"))
+					return
+				}
+				hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+				if err != nil {
+					respondError(c, err)
+					return
+				}
+				user.PasswordHash = string(hash)
+			}
+			// Check the web user before registering, so a bad request does not leave an orphan identity
+			if err := validateUserAccount(user); err != nil {
+				respondError(c, err)
+				return
+			}
+		}
+
+		id, err := manager.Onboard(req.Org, RegistrationRequest{
+			EnrollmentID:   req.EnrollmentID,
+			Type:           req.Type,
+			Affiliation:    req.Affiliation,
+			MaxEnrollments: req.MaxEnrollments,
+			Attributes:     req.Attributes,
+		})
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		response := gin.H{"message": fmt.Sprintf("Enrolled %s", id.Label), "data": gin.H{"label": id.Label}}
+		if user != nil {
+			if err := validateUser(user); err != nil {
+				respondError(c, err)
+				return
+			}
+			if err := auth.users.Put(user); err != nil {
+				respondError(c, err)
+				return
+			}
+			response["user"] = user.public()
+		}
+		c.JSON(http.StatusOK, response)
+	})
+
+	// Enroll an identity registered out of band, e.g. with fabric-ca-client
+	admin.POST("/enroll", func(c *gin.Context) {
+		var req struct {
+			Org          string `json:"org"`
+			EnrollmentID string `json:"enrollmentID"`
+			Secret       string `json:"secret"`
+		}
+		if err := c.BindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Bad request", "error": err.Error()})
+			return
+		}
+		if !safeIDPattern.MatchString(req.EnrollmentID) || req.Secret == "" {
+			respondError(c, newAPIError(ErrCodeInvalidArgument, "enrollmentID and secret are required"))
+			return
+		}
+		id, err := manager.Enroll(req.Org, req.EnrollmentID, req.Secret)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Enrolled %s", id.Label), "data": gin.H{"label": id.Label}})
+	})
+
+	admin.POST("/:label/reenroll", func(c *gin.Context) {
+		id, err := manager.Reenroll(c.Param("label"))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Re-enrolled %s", id.Label)})
+	})
+
+	// Revoke with the CA and remove from the wallet, ?certificateOnly=true keeps the registration
+	admin.POST("/:label/revoke", func(c *gin.Context) {
+		var req struct {
+			Reason string `json:"reason"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil && c.Request.ContentLength > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Bad request", "error": err.Error()})
+			return
+		}
+		if req.Reason == "" {
+			req.Reason = "unspecified"
+		}
+		label := c.Param("label")
+		if err := manager.Revoke(label, req.Reason, c.Query("certificateOnly") == "true"); err != nil {
+			respondError(c, err)
+			return
+		}
+		unmapped, err := auth.users.Unmap(label)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Revoked %s", label), "data": gin.H{"unmappedUsers": unmapped}})
+	})
+}
@@ -0,0 +1,311 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-pdf/fpdf"
+	"github.com/skip2/go-qrcode"
+
+	"ricetypes"
+)
+
+// Labels carry a GS1 Digital Link URI (https://www.gs1.org/standards/gs1-digital-link)
+// pointing back at this server's resolver routes, under the configured publicBaseURL:
+//
+//	batch:       <base>/01/<GTIN>/10/<batchID>
+//	retail unit: <base>/01/<GTIN>/21/<serial>
+
+// GS1 example GTIN, used until products are registered with real GTINs
+const defaultGTIN = "09520123456788"
+
+// Check the length and mod-10 check digit of a GTIN-8/12/13/14
+func validGTIN(gtin string) bool {
+	switch len(gtin) {
+	case 8, 12, 13, 14:
+	default:
+		return false
+	}
+	sum := 0
+	for i := len(gtin) - 2; i >= 0; i-- {
+		digit := int(gtin[i] - '0')
+		if digit < 0 || digit > 9 {
+			return false
+		}
+		// Weights alternate 3,1,3,... starting next to the check digit
+		if (len(gtin)-2-i)%2 == 0 {
+			digit *= 3
+		}
+		sum += digit
+	}
+	check := int(gtin[len(gtin)-1] - '0')
+	return check == (10-sum%10)%10
+}
+
+// Pad a GTIN to the 14 digits Digital Link URIs use
+func gtin14(gtin string) string {
+	return strings.Repeat("0", 14-len(gtin)) + gtin
+}
+
+func batchDigitalLink(gtin string, batchID string) string {
+	return fmt.Sprintf("%s/01/%s/10/%s", strings.TrimSuffix(appConfig.PublicBaseURL, "/"), gtin14(gtin), url.PathEscape(batchID))
+}
+
+func unitDigitalLink(gtin string, serial string) string {
+	return fmt.Sprintf("%s/01/%s/21/%s", strings.TrimSuffix(appConfig.PublicBaseURL, "/"), gtin14(gtin), url.PathEscape(serial))
+}
+
+// Render a QR bitmap as SVG with one path covering every dark module
+func qrSVG(bitmap [][]bool) string {
+	var path strings.Builder
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if dark {
+				fmt.Fprintf(&path, "M%d %dh1v1h-1z", x, y)
+			}
+		}
+	}
+	size := len(bitmap)
+	return fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" shape-rendering="crispEdges">`+
+		`<rect width="%d" height="%d" fill="#fff"/><path d="%s" fill="#000"/></svg>`, size, size, size, size, path.String())
+}
+
+// Write a QR code for content in the format requested by the query string
+func writeQR(c *gin.Context, content string) {
+	qr, err := qrcode.New(content, qrcode.Medium)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to encode QR code", "error": err.Error()})
+		return
+	}
+
+	switch c.DefaultQuery("format", "png") {
+	case "svg":
+		c.Data(http.StatusOK, "image/svg+xml", []byte(qrSVG(qr.Bitmap())))
+	case "png":
+		size, err := strconv.Atoi(c.DefaultQuery("size", "256"))
+		if err != nil || size < 64 || size > 2048 {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "size must be between 64 and 2048"})
+			return
+		}
+		png, err := qr.PNG(size)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to render QR code", "error": err.Error()})
+			return
+		}
+		c.Data(http.StatusOK, "image/png", png)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"message": "format must be png or svg"})
+	}
+}
+
+type LabelItem struct {
+	BatchID string `json:"batchID"`
+	Serial  string `json:"serial"`
+	Grade   string `json:"grade"`
+}
+
+type labelContent struct {
+	Title       string
+	Variety     string
+	HarvestDate string
+	Grade       string
+	Link        string
+}
+
+// Look up what gets printed on a label from the ledger
+func fetchLabelContent(caller Caller, gtin string, item LabelItem) (content labelContent, err error) {
+	var batch ricetypes.RiceBatch
+	if item.Serial != "" {
+		var unit ricetypes.RetailUnitProvenance
+		if err := evaluateJSON(caller, "VerifyRetailUnit", &unit, item.Serial); err != nil {
+			return content, err
+		}
+		if unit.Batch != nil {
+			batch = *unit.Batch
+		}
+		content.Title = "Serial " + item.Serial
+		content.Link = unitDigitalLink(gtin, item.Serial)
+	} else {
+		if err := evaluateJSON(caller, "ReadRiceBatch", &batch, item.BatchID); err != nil {
+			return content, err
+		}
+		content.Title = "Batch " + item.BatchID
+		content.Link = batchDigitalLink(gtin, item.BatchID)
+	}
+
+	content.Variety = batch.Variety
+	content.HarvestDate = batch.HarvestDate
+	content.Grade = item.Grade
+	if content.Grade == "" {
+		content.Grade = "-"
+	}
+	return content, nil
+}
+
+// Lay labels out on A4 pages, two columns by five rows
+func renderLabelSheet(labels []labelContent) ([]byte, error) {
+	const (
+		margin   = 10.0
+		columns  = 2
+		rows     = 5
+		labelW   = 95.0
+		labelH   = 55.0
+		qrSize   = 40.0
+		padding  = 5.0
+		perSheet = columns * rows
+	)
+
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetAutoPageBreak(false, 0)
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	for i, label := range labels {
+		if i%perSheet == 0 {
+			pdf.AddPage()
+		}
+		slot := i % perSheet
+		x := margin + float64(slot%columns)*labelW
+		y := margin + float64(slot/columns)*labelH
+
+		pdf.SetDrawColor(180, 180, 180)
+		pdf.Rect(x, y, labelW, labelH, "D")
+
+		qr, err := qrcode.New(label.Link, qrcode.Medium)
+		if err != nil {
+			return nil, err
+		}
+		bitmap := qr.Bitmap()
+		module := qrSize / float64(len(bitmap))
+		pdf.SetFillColor(0, 0, 0)
+		for row, line := range bitmap {
+			for col, dark := range line {
+				if dark {
+					pdf.Rect(x+padding+float64(col)*module, y+padding+float64(row)*module, module, module, "F")
+				}
+			}
+		}
+
+		textX := x + padding + qrSize + padding
+		textW := labelW - qrSize - 3*padding
+		pdf.SetXY(textX, y+padding)
+		pdf.SetFont("Helvetica", "B", 12)
+		pdf.MultiCell(textW, 6, tr(label.Variety), "", "L", false)
+		pdf.SetFont("Helvetica", "", 9)
+		pdf.SetX(textX)
+		pdf.MultiCell(textW, 5, tr("Harvested: "+label.HarvestDate), "", "L", false)
+		pdf.SetX(textX)
+		pdf.MultiCell(textW, 5, tr("Grade: "+label.Grade), "", "L", false)
+		pdf.SetX(textX)
+		pdf.MultiCell(textW, 5, tr(label.Title), "", "L", false)
+
+		pdf.SetFont("Helvetica", "", 6)
+		pdf.SetXY(x+padding, y+labelH-padding-3)
+		pdf.CellFormat(labelW-2*padding, 3, label.Link, "", 0, "L", false, 0, "")
+	}
+
+	var out bytes.Buffer
+	if err := pdf.Output(&out); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func registerLabelRoutes(router *gin.Engine) {
+	gtinParam := func(c *gin.Context) (string, bool) {
+		gtin := c.DefaultQuery("gtin", defaultGTIN)
+		if !validGTIN(gtin) {
+			c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("Invalid GTIN %q", gtin)})
+			return "", false
+		}
+		return gtin, true
+	}
+
+	// QR code for a batch, ?format=png|svg&size=256&gtin=
+	router.GET("/api/labels/qr/batch/:batchID", func(c *gin.Context) {
+		gtin, ok := gtinParam(c)
+		if !ok {
+			return
+		}
+		writeQR(c, batchDigitalLink(gtin, c.Param("batchID")))
+	})
+
+	// QR code for a retail unit serial
+	router.GET("/api/labels/qr/unit/:serial", func(c *gin.Context) {
+		gtin, ok := gtinParam(c)
+		if !ok {
+			return
+		}
+		writeQR(c, unitDigitalLink(gtin, c.Param("serial")))
+	})
+
+	// Printable PDF label sheet
+	router.POST("/api/labels/sheet", func(c *gin.Context) {
+		var req struct {
+			GTIN  string      `json:"gtin"`
+			Items []LabelItem `json:"items"`
+		}
+		if err := c.BindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Bad request", "error": err.Error()})
+			return
+		}
+		if req.GTIN == "" {
+			req.GTIN = defaultGTIN
+		}
+		if !validGTIN(req.GTIN) {
+			c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("Invalid GTIN %q", req.GTIN)})
+			return
+		}
+		if len(req.Items) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "No label items supplied"})
+			return
+		}
+
+		var labels []labelContent
+		for _, item := range req.Items {
+			if item.BatchID == "" && item.Serial == "" {
+				c.JSON(http.StatusBadRequest, gin.H{"message": "Each item needs a batchID or serial"})
+				return
+			}
+			content, err := fetchLabelContent(callerFrom(c), req.GTIN, item)
+			if err != nil {
+				respondError(c, err)
+				return
+			}
+			labels = append(labels, content)
+		}
+
+		pdf, err := renderLabelSheet(labels)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to render label sheet", "error": err.Error()})
+			return
+		}
+		c.Header("Content-Disposition", `attachment; filename="labels.pdf"`)
+		c.Data(http.StatusOK, "application/pdf", pdf)
+	})
+
+	// GS1 Digital Link resolver: scanned labels land here and go to the provenance page
+	router.GET("/01/:gtin/10/:lot", func(c *gin.Context) {
+		c.Redirect(http.StatusFound, "/provenance/batch/"+url.PathEscape(c.Param("lot")))
+	})
+	router.GET("/01/:gtin/21/:serial", func(c *gin.Context) {
+		c.Redirect(http.StatusFound, "/provenance/unit/"+url.PathEscape(c.Param("serial")))
+	})
+	router.GET("/01/:gtin/10/:lot/21/:serial", func(c *gin.Context) {
+		c.Redirect(http.StatusFound, "/provenance/unit/"+url.PathEscape(c.Param("serial")))
+	})
+
+	// Consumer-facing provenance page
+	router.GET("/provenance/:kind/:id", func(c *gin.Context) {
+		kind := c.Param("kind")
+		if kind != "batch" && kind != "unit" {
+			c.String(http.StatusNotFound, "Not found")
+			return
+		}
+		c.HTML(http.StatusOK, "provenance.html", gin.H{"kind": kind, "id": c.Param("id")})
+	})
+}
@@ -1,22 +1,69 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
+
+	"ricetypes"
 )
 
-type Rice struct {
-	BatchID     string `json:"batchID"`
-	Variety     string `json:"variety"`
-	HarvestDate string `json:"harvestDate"`
-	Quantity    string `json:"quantity"`    // Keep as string to match chaincode
-	FarmerName  string `json:"farmerName"`
-}
+// Server log; gin logs the requests themselves
+var logger = log.New(os.Stdout, "", log.LstdFlags)
 
 func main() {
+	config, err := loadConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	appConfig = config
+
+	wallet, err = newWallet(appConfig.Wallet)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	// One gateway connection per org, shared by every request
+	gateways = newGatewayPool(appConfig.Orgs, wallet)
+
 	router := gin.Default()
+	// Without trusted proxies ClientIP is the connecting address, which the rate limiters rely on
+	if err := router.SetTrustedProxies(appConfig.TrustedProxies); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	// Every route outside the public ones needs a signed-in user
+	auth, err := newAuthenticator(appConfig.Auth)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	router.Use(auth.authenticate())
+	registerAuthRoutes(router, auth)
+
+	// Fabric CA enrollment into the wallet, with automatic re-enrollment
+	identities := newIdentityManager(wallet, appConfig.Wallet)
+	registerIdentityRoutes(router, identities, auth)
+	go identities.renewPeriodically()
+	if oidc := appConfig.Auth.OIDC; oidc != nil && oidc.Local {
+		provider, err := newLocalProvider(*oidc)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		provider.registerRoutes(router)
+	}
+
 	router.Static("/public", "./public")
 	router.LoadHTMLGlob("templates/*")
 
@@ -27,27 +74,20 @@ func main() {
 
 	// Create Rice Batch (Org1 - Farmer)
 	router.POST("/api/rice", func(c *gin.Context) {
-		defer func() {
-			if r := recover(); r != nil {
-				fmt.Println("❌ PANIC CURSED:", r)
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"message": "Chaincode transaction failed",
-					"error":   fmt.Sprint(r),
-				})
-			}
-		}()
-
-		var req Rice
+		var req ricetypes.CreateRiceBatchArgs
 		if err := c.BindJSON(&req); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"message": "Bad request", "error": err.Error()})
 			return
 		}
 
-		fmt.Println("➡️ Request Data:", req)
+		logger.Println("➡️ Request Data:", req)
 
-		result := submitTxnFn("org1", "mychannel", "rice", "RiceContract", "invoke",
-			map[string][]byte{}, "CreateRiceBatch",
-			req.BatchID, req.Variety, req.HarvestDate, req.Quantity, req.FarmerName)
+		// Farm inputs, when given, are sent as transient data
+		result, err := submitArgs(callerFrom(c), &req)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
 
 		c.JSON(http.StatusOK, gin.H{
 			"message": "Batch created",
@@ -57,16 +97,6 @@ func main() {
 
 	// Read Rice Batch (by Key)
 	router.GET("/api/rice/:id", func(ctx *gin.Context) {
-		// Prevent panic on unexpected error
-		defer func() {
-			if r := recover(); r != nil {
-				ctx.JSON(http.StatusInternalServerError, gin.H{
-					"message": "Failed to query the rice batch",
-					"error":   fmt.Sprint(r),
-				})
-			}
-		}()
-
 		batchID := ctx.Param("id")
 		if batchID == "" {
 			ctx.JSON(http.StatusBadRequest, gin.H{"message": "Batch ID is required"})
@@ -74,74 +104,65 @@ func main() {
 		}
 
 		// Call Chaincode Read
-		result := submitTxnFn("org1", "mychannel", "rice", "RiceContract", "query",
-			make(map[string][]byte), "ReadRiceBatch", batchID)
+		var batch ricetypes.RiceBatch
+		if err := evaluateJSON(callerFrom(ctx), "ReadRiceBatch", &batch, batchID); err != nil {
+			respondError(ctx, err)
+			return
+		}
 
 		ctx.JSON(http.StatusOK, gin.H{
 			"message": fmt.Sprintf("Fetched rice batch: %s", batchID),
-			"data":    result,
+			"data":    batch,
 		})
 	})
 
 	router.GET("/api/rice/all", func(c *gin.Context) {
-		result := submitTxnFn("org1", "mychannel", "rice", "RiceContract", "query",
-			map[string][]byte{}, "GetAllRiceBatches")
-		c.JSON(http.StatusOK, gin.H{"data": result})
+		batches := []*ricetypes.RiceBatch{}
+		if err := evaluateJSON(callerFrom(c), "GetAllRiceBatches", &batches); err != nil {
+			respondError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": batches})
 	})
 
 	router.GET("/api/rice/range", func(c *gin.Context) {
 		start := c.Query("start")
 		end := c.Query("end")
-		result := submitTxnFn("org1", "mychannel", "rice", "RiceContract", "query", map[string][]byte{}, "GetRiceBatchByRange", start, end)
-		c.JSON(http.StatusOK, gin.H{"data": result})
+		batches := []*ricetypes.RiceBatch{}
+		if err := evaluateJSON(callerFrom(c), "GetRiceBatchByRange", &batches, start, end); err != nil {
+			respondError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": batches})
 	})
 
 	router.GET("/api/rice/history/:id", func(c *gin.Context) {
 		batchID := c.Param("id")
-		result := submitTxnFn("org1", "mychannel", "rice", "RiceContract", "query", map[string][]byte{}, "GetRiceBatchHistory", batchID)
-		c.JSON(http.StatusOK, gin.H{"data": result})
+		history := []*ricetypes.HistoryQueryResult{}
+		if err := evaluateJSON(callerFrom(c), "GetRiceBatchHistory", &history, batchID); err != nil {
+			respondError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": history})
 	})
 
 	router.POST("/api/orders", func(c *gin.Context) {
-		type ProcessOrder struct {
-			Variety     string `json:"variety"`
-			Quantity    string `json:"quantityInKg"`
-			MillerName  string `json:"millerName"`
-			OrderID     string `json:"orderID"`
-		}
-		var req ProcessOrder
+		var req ricetypes.CreateProcessingOrderArgs
 		if err := c.BindJSON(&req); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input"})
 			return
 		}
 
-		transient := map[string][]byte{
-			"variety":     []byte(req.Variety),
-			"quantityInKg": []byte(req.Quantity),
-			"millerName":  []byte(req.MillerName),
+		result, err := submitArgs(callerFrom(c), &req)
+		if err != nil {
+			respondError(c, err)
+			return
 		}
-		result := submitTxnFn("org2", "mychannel", "rice", "RiceContract", "private", transient, "CreateProcessingOrder", req.OrderID)
-		c.JSON(http.StatusOK, gin.H{"message": result})
+		c.JSON(http.StatusOK, gin.H{"message": result.Result, "data": result})
 	})
 
 	router.POST("/api/orders/match", func(ctx *gin.Context) {
-		defer func() {
-			if r := recover(); r != nil {
-				fmt.Println("‼️ MatchProcessingOrder PANIC:", r)
-				ctx.JSON(http.StatusInternalServerError, gin.H{
-					"message": "Failed to match processing order",
-					"error":   fmt.Sprint(r),
-				})
-			}
-		}()
-
-		// Struct to hold expected JSON input
-		type MatchInfo struct {
-			BatchID string `json:"batchID"`
-			OrderID string `json:"orderID"`
-		}
-
-		var data MatchInfo
+		var data ricetypes.MatchProcessingOrderArgs
 
 		// Bind incoming JSON to struct
 		if err := ctx.BindJSON(&data); err != nil {
@@ -149,37 +170,127 @@ func main() {
 			return
 		}
 
-		fmt.Println("Matching Batch:", data.BatchID)
-		fmt.Println("With Order    :", data.OrderID)
+		logger.Println("Matching Batch:", data.BatchID)
+		logger.Println("With Order    :", data.OrderID)
 
 		// Call chaincode
-		result := submitTxnFn("org2", "mychannel", "rice", "RiceContract",
-			"invoke", map[string][]byte{}, "MatchProcessingOrder",
-			data.BatchID, data.OrderID)
+		result, err := submitArgs(callerFrom(ctx), &data)
+		if err != nil {
+			respondError(ctx, err)
+			return
+		}
 
 		ctx.JSON(http.StatusOK, gin.H{
 			"message": "Matched batch to order",
-			"result":  result,
+			"data":    result,
 		})
 	})
 
 	router.POST("/api/rice/dispatch", func(c *gin.Context) {
-		type Dispatch struct {
-			BatchID     string `json:"batchID"`
-			RetailerName string `json:"retailerName"`
-		}
-		var req Dispatch
+		var req ricetypes.DispatchToRetailerArgs
 		if err := c.BindJSON(&req); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input"})
 			return
 		}
-		result := submitTxnFn("org3", "mychannel", "rice", "RiceContract", "invoke", map[string][]byte{}, "DispatchToRetailer", req.BatchID, req.RetailerName)
-		c.JSON(http.StatusOK, gin.H{"message": result})
+		// Sale terms go as transient data so an invoice is raised for the dispatch
+		result, err := submitArgs(callerFrom(c), &req)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": result.Result, "data": result})
 	})
 
-	
+	// Consumer lookup of a retail bag by its printed serial
+	router.GET("/api/units/:serial", func(c *gin.Context) {
+		serial := c.Param("serial")
+		var result ricetypes.RetailUnitProvenance
+		if err := evaluateJSON(callerFrom(c), "VerifyRetailUnit", &result, serial); err != nil {
+			respondError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"message": fmt.Sprintf("Verified retail unit: %s", serial),
+			"data":    result,
+		})
+	})
 
+	// Carbon and water footprint of a batch, packaging run, retail unit or consignment
+	router.GET("/api/footprint/:assetID", func(c *gin.Context) {
+		assetID := c.Param("assetID")
+		var result ricetypes.Footprint
+		if err := evaluateJSON(callerFrom(c), "GetFootprint", &result, assetID); err != nil {
+			respondError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"message": fmt.Sprintf("Footprint of %s", assetID),
+			"data":    result,
+		})
+	})
+
+	// QR labels, GS1 Digital Link resolver and provenance page
+	registerLabelRoutes(router)
+
+	// Anonymous, rate-limited provenance API behind the provenance page
+	registerPublicRoutes(router)
+
+	// Sensor telemetry, anchored on-ledger in the background
+	telemetry := newTelemetryStore(appConfig.Storage.TelemetryDir)
+	registerTelemetryRoutes(router, telemetry)
+	go telemetry.anchorPeriodically(telemetryAnchorInterval)
+
+	// Alert rules over telemetry and batch state
+	rulesConfig, err := loadRulesConfig(appConfig.Storage.RulesFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "alert rules:", err)
+		os.Exit(1)
+	}
+	rules, err := newRuleEngine(rulesConfig, telemetry, appConfig.Storage.AlertsFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "alert rules:", err)
+		os.Exit(1)
+	}
+	registerRuleRoutes(router, rules)
+	go rules.evaluatePeriodically()
+
+	// Off-chain documents anchored by hash
+	registerDocumentRoutes(router, newDocumentStore(appConfig.Storage.DocumentsDir))
+
+	// Export consignment paperwork
+	registerExportRoutes(router)
+
+	// Farm and field boundaries for mapping
+	registerGeoRoutes(router)
+
+	// Transactions signed on the user's own device
+	registerOfflineRoutes(router, newOfflineSigner())
+
+	// Versioned API described by openapi.yaml
+	doc, spec, err := loadOpenAPI()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	registerAPIRoutes(router, doc, spec)
+
+	// Start the server and shut down cleanly on Ctrl-C or SIGTERM
+	server := &http.Server{Addr: appConfig.ListenAddress, Handler: router}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}()
 
-	// Start the server
-	router.Run("localhost:3001")
+	<-ctx.Done()
+	logger.Println("Shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		logger.Println("Server shutdown:", err)
+	}
+	gateways.Close()
 }
\ No newline at end of file
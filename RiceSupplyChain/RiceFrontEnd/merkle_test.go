@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func testLeaves(n int) [][]byte {
+	leaves := make([][]byte, n)
+	for i := range leaves {
+		leaves[i] = leafHash([]byte(fmt.Sprintf("reading-%d", i)))
+	}
+	return leaves
+}
+
+func TestMerkleProofVerifies(t *testing.T) {
+	for _, n := range []int{1, 2, 3, 4, 5, 7, 8, 13} {
+		leaves := testLeaves(n)
+		root := merkleRoot(leaves)
+		for i := range leaves {
+			proof, err := merkleProof(leaves, i)
+			if err != nil {
+				t.Fatalf("%d leaves, index %d: %v", n, i, err)
+			}
+			if !verifyMerkleProof(leaves[i], proof, root) {
+				t.Errorf("%d leaves, index %d: proof does not verify", n, i)
+			}
+		}
+	}
+}
+
+func TestMerkleRootSingleLeaf(t *testing.T) {
+	leaves := testLeaves(1)
+	if !bytes.Equal(merkleRoot(leaves), leaves[0]) {
+		t.Fatal("root of a single leaf should be the leaf itself")
+	}
+	proof, err := merkleProof(leaves, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(proof) != 0 {
+		t.Fatalf("single leaf proof has %d steps, want 0", len(proof))
+	}
+}
+
+func TestMerkleRootOddLeafPromoted(t *testing.T) {
+	leaves := testLeaves(3)
+	want := nodeHash(nodeHash(leaves[0], leaves[1]), leaves[2])
+	if !bytes.Equal(merkleRoot(leaves), want) {
+		t.Fatal("odd leaf should be promoted, not duplicated")
+	}
+}
+
+func TestMerkleProofRejectsTampering(t *testing.T) {
+	leaves := testLeaves(5)
+	root := merkleRoot(leaves)
+	proof, err := merkleProof(leaves, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if verifyMerkleProof(leafHash([]byte("tampered")), proof, root) {
+		t.Error("tampered leaf verified")
+	}
+	if verifyMerkleProof(leaves[3], proof, root) {
+		t.Error("proof for one leaf verified another")
+	}
+	flipped := append([]ProofStep(nil), proof...)
+	flipped[0].Left = !flipped[0].Left
+	if verifyMerkleProof(leaves[2], flipped, root) {
+		t.Error("proof with a swapped side verified")
+	}
+	if verifyMerkleProof(leaves[2], proof, merkleRoot(testLeaves(4))) {
+		t.Error("proof verified against another root")
+	}
+}
+
+func TestMerkleProofIndexOutOfRange(t *testing.T) {
+	leaves := testLeaves(3)
+	for _, index := range []int{-1, 3} {
+		if _, err := merkleProof(leaves, index); err == nil {
+			t.Errorf("index %d: expected an error", index)
+		}
+	}
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"encoding/base64"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// Offline signing for users who keep their private key on their own device.
+// The server builds each message with a Gateway that has no signer and hands
+// out its digest; the client signs the digest (ECDSA over the SHA-256 digest,
+// DER encoded, low-S) and posts the signature back. A transaction takes three
+// signatures: the proposal, the endorsed transaction, and the commit status
+// request, which the gateway also requires to be signed by the submitter.
+// Unsigned messages are kept here between steps, so a client only ever
+// handles digests and signatures. A step that fails can be signed and
+// posted again until the transaction expires.
+
+const offlineTransactionTTL = 5 * time.Minute
+
+// Steps a pending transaction waits on a signature for
+const (
+	offlineStepEndorse = "endorse"
+	offlineStepSubmit  = "submit"
+	offlineStepStatus  = "status"
+)
+
+type pendingTransaction struct {
+	caller  Caller
+	step    string
+	message []byte // Unsigned proposal, transaction or commit status request
+	result  []byte // Contract result, once endorsed
+	expires time.Time
+}
+
+type OfflineSigner struct {
+	mu      sync.Mutex
+	pending map[string]*pendingTransaction // Keyed by transaction ID
+}
+
+func newOfflineSigner() *OfflineSigner {
+	return &OfflineSigner{pending: make(map[string]*pendingTransaction)}
+}
+
+func (s *OfflineSigner) gateway(caller Caller) (*client.Gateway, error) {
+	if caller.Certificate == "" {
+		return nil, newAPIError(ErrCodeInvalidArgument, "%s does not sign offline, the server signs their transactions", caller)
+	}
+	return gateways.Gateway(caller)
+}
+
+func (s *OfflineSigner) put(transactionID string, pending *pendingTransaction) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for id, p := range s.pending {
+		if now.After(p.expires) {
+			delete(s.pending, id)
+		}
+	}
+	pending.expires = now.Add(offlineTransactionTTL)
+	s.pending[transactionID] = pending
+}
+
+// take removes a pending transaction waiting on step, which only its caller may continue
+func (s *OfflineSigner) take(transactionID string, caller Caller, step string) (*pendingTransaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pending, ok := s.pending[transactionID]
+	if !ok || time.Now().After(pending.expires) || pending.caller.identityKey() != caller.identityKey() {
+		return nil, newAPIError(ErrCodeNotFound, "no pending transaction %s, it may have expired", transactionID)
+	}
+	if pending.step != step {
+		return nil, newAPIError(ErrCodeConflict, "transaction %s is waiting for the %s signature", transactionID, pending.step)
+	}
+	delete(s.pending, transactionID)
+	return pending, nil
+}
+
+// Propose builds an unsigned proposal and returns its transaction ID and digest
+func (s *OfflineSigner) Propose(caller Caller, function string, args []string, transient map[string][]byte) (string, []byte, error) {
+	gw, err := s.gateway(caller)
+	if err != nil {
+		return "", nil, err
+	}
+	contract := gw.GetNetwork(appConfig.Channel).GetContractWithName(appConfig.Chaincode, appConfig.Contract)
+	options := []client.ProposalOption{client.WithArguments(args...)}
+	if len(transient) > 0 {
+		options = append(options, client.WithTransient(transient))
+	}
+	proposal, err := contract.NewProposal(function, options...)
+	if err != nil {
+		return "", nil, err
+	}
+	message, err := proposal.Bytes()
+	if err != nil {
+		return "", nil, err
+	}
+	s.put(proposal.TransactionID(), &pendingTransaction{caller: caller, step: offlineStepEndorse, message: message})
+	logger.Printf("--> Prepared offline transaction: %s %s as %s", function, proposal.TransactionID(), caller)
+	return proposal.TransactionID(), proposal.Digest(), nil
+}
+
+// Endorse sends the signed proposal for endorsement and returns the
+// transaction's result and the digest of the transaction to sign
+func (s *OfflineSigner) Endorse(caller Caller, transactionID string, signature []byte) ([]byte, []byte, error) {
+	gw, err := s.gateway(caller)
+	if err != nil {
+		return nil, nil, err
+	}
+	pending, err := s.take(transactionID, caller, offlineStepEndorse)
+	if err != nil {
+		return nil, nil, err
+	}
+	proposal, err := gw.NewSignedProposal(pending.message, signature)
+	if err != nil {
+		s.put(transactionID, pending)
+		return nil, nil, newAPIError(ErrCodeInvalidArgument, "invalid signed proposal: %v", err)
+	}
+	transaction, err := proposal.Endorse()
+	if err != nil {
+		s.put(transactionID, pending)
+		return nil, nil, err
+	}
+	message, err := transaction.Bytes()
+	if err != nil {
+		return nil, nil, err
+	}
+	s.put(transactionID, &pendingTransaction{caller: caller, step: offlineStepSubmit, message: message, result: transaction.Result()})
+	return transaction.Result(), transaction.Digest(), nil
+}
+
+// Submit sends the signed transaction to the orderer and returns the digest
+// of the commit status request to sign
+func (s *OfflineSigner) Submit(caller Caller, transactionID string, signature []byte) ([]byte, error) {
+	gw, err := s.gateway(caller)
+	if err != nil {
+		return nil, err
+	}
+	pending, err := s.take(transactionID, caller, offlineStepSubmit)
+	if err != nil {
+		return nil, err
+	}
+	transaction, err := gw.NewSignedTransaction(pending.message, signature)
+	if err != nil {
+		s.put(transactionID, pending)
+		return nil, newAPIError(ErrCodeInvalidArgument, "invalid signed transaction: %v", err)
+	}
+	commit, err := transaction.Submit()
+	if err != nil {
+		s.put(transactionID, pending)
+		return nil, err
+	}
+	message, err := commit.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	s.put(transactionID, &pendingTransaction{caller: caller, step: offlineStepStatus, message: message, result: pending.result})
+	return commit.Digest(), nil
+}
+
+// Status waits for the transaction to commit using the signed status request
+func (s *OfflineSigner) Status(caller Caller, transactionID string, signature []byte) (*TransactionResult, error) {
+	gw, err := s.gateway(caller)
+	if err != nil {
+		return nil, err
+	}
+	pending, err := s.take(transactionID, caller, offlineStepStatus)
+	if err != nil {
+		return nil, err
+	}
+	commit, err := gw.NewSignedCommit(pending.message, signature)
+	if err != nil {
+		s.put(transactionID, pending)
+		return nil, newAPIError(ErrCodeInvalidArgument, "invalid signed commit status request: %v", err)
+	}
+	status, err := commit.Status()
+	if err != nil {
+		s.put(transactionID, pending)
+		return nil, err
+	}
+	if !status.Successful {
+		return nil, commitStatusError(status)
+	}
+	return &TransactionResult{
+		TransactionID: status.TransactionID,
+		BlockNumber:   status.BlockNumber,
+		Status:        status.Code.String(),
+		Result:        string(pending.result),
+	}, nil
+}
+
+// Decode a base64 signature from a request
+func bindSignature(c *gin.Context) ([]byte, bool) {
+	var req struct {
+		Signature string `json:"signature"`
+	}
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Bad request", "error": err.Error()})
+		return nil, false
+	}
+	signature, err := base64.StdEncoding.DecodeString(req.Signature)
+	if err != nil || len(signature) == 0 {
+		respondError(c, newAPIError(ErrCodeInvalidArgument, "signature must be a base64 encoded DER ECDSA signature"))
+		return nil, false
+	}
+	return signature, true
+}
+
+func registerOfflineRoutes(router *gin.Engine, signer *OfflineSigner) {
+	offline := router.Group("/api/offline/transactions")
+
+	// Build a proposal for a contract function; sign the returned digest
+	offline.POST("", func(c *gin.Context) {
+		var req struct {
+			Function  string            `json:"function"`
+			Args      []string          `json:"args"`
+			Transient map[string]string `json:"transient"` // Sent to the contract as the raw string bytes
+		}
+		if err := c.BindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Bad request", "error": err.Error()})
+			return
+		}
+		if req.Function == "" {
+			respondError(c, newAPIError(ErrCodeInvalidArgument, "function is required"))
+			return
+		}
+		transient := map[string][]byte{}
+		for key, value := range req.Transient {
+			transient[key] = []byte(value)
+		}
+
+		transactionID, digest, err := signer.Propose(callerFrom(c), req.Function, req.Args, transient)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Sign the proposal digest",
+			"data": gin.H{
+				"transactionID": transactionID,
+				"next":          offlineStepEndorse,
+				"digest":        base64.StdEncoding.EncodeToString(digest),
+				"expiresIn":     offlineTransactionTTL.Seconds(),
+			},
+		})
+	})
+
+	offline.POST("/:txID/endorse", func(c *gin.Context) {
+		signature, ok := bindSignature(c)
+		if !ok {
+			return
+		}
+		result, digest, err := signer.Endorse(callerFrom(c), c.Param("txID"), signature)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Endorsed, sign the transaction digest",
+			"data": gin.H{
+				"transactionID": c.Param("txID"),
+				"next":          offlineStepSubmit,
+				"digest":        base64.StdEncoding.EncodeToString(digest),
+				"result":        string(result),
+			},
+		})
+	})
+
+	offline.POST("/:txID/submit", func(c *gin.Context) {
+		signature, ok := bindSignature(c)
+		if !ok {
+			return
+		}
+		digest, err := signer.Submit(callerFrom(c), c.Param("txID"), signature)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Submitted, sign the commit status digest to wait for the commit",
+			"data": gin.H{
+				"transactionID": c.Param("txID"),
+				"next":          offlineStepStatus,
+				"digest":        base64.StdEncoding.EncodeToString(digest),
+			},
+		})
+	})
+
+	offline.POST("/:txID/status", func(c *gin.Context) {
+		signature, ok := bindSignature(c)
+		if !ok {
+			return
+		}
+		result, err := signer.Status(callerFrom(c), c.Param("txID"), signature)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Transaction committed successfully",
+			"data":    result,
+		})
+	})
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/oauth2"
+)
+
+// OIDC sign-in uses the authorization code flow. The provider's discovery
+// document is fetched on first use rather than at startup, so the local
+// stand-in provider served by this process can be the issuer. A signed-in
+// OIDC user is mapped to a local user whose oidcSubject equals the
+// configured claim of their ID token.
+
+const oidcStateCookie = "rice_oidc_state"
+
+// Where the development provider in oidcdev.go is served when auth.oidc.local is set
+const localIssuerPath = "/oidc-dev"
+
+func localIssuerURL(publicBaseURL string) string {
+	return strings.TrimSuffix(publicBaseURL, "/") + localIssuerPath
+}
+
+type oidcLogin struct {
+	config OIDCConfig
+
+	mu       sync.Mutex
+	oauth2   *oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+func newOIDCLogin(config OIDCConfig) *oidcLogin {
+	if config.UsernameClaim == "" {
+		config.UsernameClaim = "email"
+	}
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{oidc.ScopeOpenID, "email", "profile"}
+	}
+	return &oidcLogin{config: config}
+}
+
+// Discover the provider once it first answers
+func (l *oidcLogin) provider(c *gin.Context) (*oauth2.Config, *oidc.IDTokenVerifier, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.oauth2 != nil {
+		return l.oauth2, l.verifier, nil
+	}
+
+	provider, err := oidc.NewProvider(c.Request.Context(), l.config.IssuerURL)
+	if err != nil {
+		return nil, nil, newAPIError(ErrCodeUnavailable, "OIDC provider %s is unavailable: %v", l.config.IssuerURL, err)
+	}
+	l.oauth2 = &oauth2.Config{
+		ClientID:     l.config.ClientID,
+		ClientSecret: l.config.ClientSecret,
+		Endpoint:     provider.Endpoint(),
+		RedirectURL:  strings.TrimSuffix(appConfig.PublicBaseURL, "/") + "/api/auth/oidc/callback",
+		Scopes:       l.config.Scopes,
+	}
+	l.verifier = provider.Verifier(&oidc.Config{ClientID: l.config.ClientID})
+	return l.oauth2, l.verifier, nil
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func (l *oidcLogin) registerRoutes(router *gin.Engine, auth *Authenticator) {
+	// Redirect to the provider's sign-in page
+	router.GET("/api/auth/oidc/login", func(c *gin.Context) {
+		config, _, err := l.provider(c)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		state, err := randomToken()
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		nonce, err := randomToken()
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		// State and nonce ride in a short-lived cookie and are checked on the way back
+		c.SetSameSite(http.SameSiteLaxMode)
+		c.SetCookie(oidcStateCookie, state+"."+nonce, 600, "/api/auth/oidc", "", auth.secure, true)
+		options := []oauth2.AuthCodeOption{oidc.Nonce(nonce)}
+		if hint := c.Query("login_hint"); hint != "" {
+			options = append(options, oauth2.SetAuthURLParam("login_hint", hint))
+		}
+		c.Redirect(http.StatusFound, config.AuthCodeURL(state, options...))
+	})
+
+	// Provider redirects here with an authorization code
+	router.GET("/api/auth/oidc/callback", func(c *gin.Context) {
+		config, verifier, err := l.provider(c)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		if providerErr := c.Query("error"); providerErr != "" {
+			respondError(c, newAPIError(ErrCodeUnauthenticated, "OIDC sign-in failed: %s %s", providerErr, c.Query("error_description")))
+			return
+		}
+
+		cookie, err := c.Cookie(oidcStateCookie)
+		c.SetCookie(oidcStateCookie, "", -1, "/api/auth/oidc", "", auth.secure, true)
+		state, nonce, ok := strings.Cut(cookie, ".")
+		if err != nil || !ok || state != c.Query("state") {
+			respondError(c, newAPIError(ErrCodeUnauthenticated, "OIDC state mismatch, start the sign-in again"))
+			return
+		}
+
+		token, err := config.Exchange(c.Request.Context(), c.Query("code"))
+		if err != nil {
+			respondError(c, newAPIError(ErrCodeUnauthenticated, "OIDC code exchange failed: %v", err))
+			return
+		}
+		rawIDToken, ok := token.Extra("id_token").(string)
+		if !ok {
+			respondError(c, newAPIError(ErrCodeUnauthenticated, "OIDC provider returned no id_token"))
+			return
+		}
+		idToken, err := verifier.Verify(c.Request.Context(), rawIDToken)
+		if err != nil {
+			respondError(c, newAPIError(ErrCodeUnauthenticated, "invalid ID token: %v", err))
+			return
+		}
+		if idToken.Nonce != nonce {
+			respondError(c, newAPIError(ErrCodeUnauthenticated, "ID token nonce mismatch"))
+			return
+		}
+
+		var claims map[string]interface{}
+		if err := idToken.Claims(&claims); err != nil {
+			respondError(c, newAPIError(ErrCodeUnauthenticated, "unreadable ID token claims: %v", err))
+			return
+		}
+		subject, _ := claims[l.config.UsernameClaim].(string)
+		if subject == "" {
+			respondError(c, newAPIError(ErrCodeUnauthenticated, "ID token has no %s claim", l.config.UsernameClaim))
+			return
+		}
+
+		user := auth.users.BySubject(subject)
+		if user == nil {
+			respondError(c, newAPIError(ErrCodeForbidden, "%s is not mapped to an organization, ask an administrator to add them", subject))
+			return
+		}
+		if _, _, err := auth.issueSession(c, user); err != nil {
+			respondError(c, err)
+			return
+		}
+		logger.Printf("auth: %s signed in through OIDC as %s", subject, user.Username)
+		c.Redirect(http.StatusFound, "/")
+	})
+}
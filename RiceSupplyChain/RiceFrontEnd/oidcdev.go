@@ -0,0 +1,212 @@
+//go:build oidcdev
+
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/subtle"
+	"encoding/base64"
+	"html/template"
+	"math/big"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// A minimal OIDC provider for development and tests, compiled in only with
+// -tags oidcdev and enabled with auth.oidc.local. It implements discovery,
+// JWKS, an authorization endpoint that signs in whoever is named in
+// login_hint (or asks for an email) without a password, and a token endpoint
+// for the authorization code grant. It accepts only the configured client,
+// but must never be built into a production server.
+
+const localCodeTTL = time.Minute
+
+type localCode struct {
+	subject     string
+	nonce       string
+	redirectURI string
+	expires     time.Time
+}
+
+type localProvider struct {
+	issuer string
+	client OIDCConfig
+	key    *rsa.PrivateKey
+	keyID  string
+
+	mu    sync.Mutex
+	codes map[string]localCode
+}
+
+var localLoginPage = template.Must(template.New("login").Parse(`<!DOCTYPE html>
+<html><head><title>Development sign-in</title></head>
+<body>
+  <h1>Development OIDC provider</h1>
+  <p>Any email signs in without a password. Do not use this in production.</p>
+  <form method="get">
+    {{range $name, $value := .}}<input type="hidden" name="{{$name}}" value="{{$value}}">{{end}}
+    <label>Email <input type="email" name="login_hint" required autofocus></label>
+    <button type="submit">Sign in</button>
+  </form>
+</body></html>`))
+
+func newLocalProvider(config OIDCConfig) (*localProvider, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	keyID, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	return &localProvider{
+		issuer: config.IssuerURL,
+		client: config,
+		key:    key,
+		keyID:  keyID,
+		codes:  make(map[string]localCode),
+	}, nil
+}
+
+// Check client credentials from HTTP basic auth or the form body
+func (p *localProvider) authenticClient(c *gin.Context) bool {
+	clientID, secret, ok := c.Request.BasicAuth()
+	if ok {
+		// RFC 6749 form-encodes basic auth credentials
+		clientID, _ = url.QueryUnescape(clientID)
+		secret, _ = url.QueryUnescape(secret)
+	} else {
+		clientID, secret = c.PostForm("client_id"), c.PostForm("client_secret")
+	}
+	return clientID == p.client.ClientID &&
+		subtle.ConstantTimeCompare([]byte(secret), []byte(p.client.ClientSecret)) == 1
+}
+
+func (p *localProvider) registerRoutes(router *gin.Engine) {
+	// The sign-in page and the token endpoint are reached without a session
+	anonymousPrefixes = append(anonymousPrefixes, localIssuerPath+"/")
+	dev := router.Group(localIssuerPath)
+
+	dev.GET("/.well-known/openid-configuration", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"issuer":                                p.issuer,
+			"authorization_endpoint":                p.issuer + "/authorize",
+			"token_endpoint":                        p.issuer + "/token",
+			"jwks_uri":                              p.issuer + "/jwks",
+			"response_types_supported":              []string{"code"},
+			"subject_types_supported":               []string{"public"},
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+			"scopes_supported":                      []string{"openid", "email", "profile"},
+			"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post"},
+		})
+	})
+
+	dev.GET("/jwks", func(c *gin.Context) {
+		encode := base64.RawURLEncoding.EncodeToString
+		c.JSON(http.StatusOK, gin.H{"keys": []gin.H{{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"kid": p.keyID,
+			"n":   encode(p.key.N.Bytes()),
+			"e":   encode(big.NewInt(int64(p.key.E)).Bytes()),
+		}}})
+	})
+
+	dev.GET("/authorize", func(c *gin.Context) {
+		if c.Query("client_id") != p.client.ClientID || c.Query("response_type") != "code" {
+			c.String(http.StatusBadRequest, "unknown client or unsupported response_type")
+			return
+		}
+		redirectURI, err := url.Parse(c.Query("redirect_uri"))
+		if err != nil || redirectURI.Scheme == "" {
+			c.String(http.StatusBadRequest, "invalid redirect_uri")
+			return
+		}
+
+		subject := c.Query("login_hint")
+		if subject == "" {
+			fields := map[string]string{}
+			for name, values := range c.Request.URL.Query() {
+				fields[name] = values[0]
+			}
+			c.Status(http.StatusOK)
+			c.Header("Content-Type", "text/html; charset=utf-8")
+			localLoginPage.Execute(c.Writer, fields)
+			return
+		}
+
+		code, err := randomToken()
+		if err != nil {
+			c.String(http.StatusInternalServerError, err.Error())
+			return
+		}
+		p.mu.Lock()
+		p.codes[code] = localCode{
+			subject:     subject,
+			nonce:       c.Query("nonce"),
+			redirectURI: redirectURI.String(),
+			expires:     time.Now().Add(localCodeTTL),
+		}
+		p.mu.Unlock()
+
+		query := redirectURI.Query()
+		query.Set("code", code)
+		query.Set("state", c.Query("state"))
+		redirectURI.RawQuery = query.Encode()
+		c.Redirect(http.StatusFound, redirectURI.String())
+	})
+
+	dev.POST("/token", func(c *gin.Context) {
+		if !p.authenticClient(c) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_client"})
+			return
+		}
+		if c.PostForm("grant_type") != "authorization_code" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported_grant_type"})
+			return
+		}
+
+		// Codes are single use
+		p.mu.Lock()
+		code, ok := p.codes[c.PostForm("code")]
+		delete(p.codes, c.PostForm("code"))
+		p.mu.Unlock()
+		if !ok || time.Now().After(code.expires) || code.redirectURI != c.PostForm("redirect_uri") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_grant"})
+			return
+		}
+
+		now := time.Now()
+		idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+			"iss":   p.issuer,
+			"sub":   code.subject,
+			"aud":   p.client.ClientID,
+			"iat":   now.Unix(),
+			"exp":   now.Add(5 * time.Minute).Unix(),
+			"nonce": code.nonce,
+			"email": code.subject,
+		})
+		idToken.Header["kid"] = p.keyID
+		signed, err := idToken.SignedString(p.key)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+			return
+		}
+		accessToken, _ := randomToken()
+		c.JSON(http.StatusOK, gin.H{
+			"access_token": accessToken,
+			"token_type":   "Bearer",
+			"expires_in":   300,
+			"id_token":     signed,
+		})
+	})
+
+	logger.Printf("auth: development OIDC provider enabled at %s - anyone can sign in as any email", p.issuer)
+}
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"ricetypes"
+)
+
+// The public provenance API needs no login. It reads only public world
+// state, never private collections, and copies a whitelist of fields into
+// PublicProvenance so new asset fields are not exposed by accident. Reads
+// are signed by the org configured as roles.public.
+
+const publicRequestsPerMinute = 30
+const publicCacheTTL = time.Minute
+const publicCacheMaxEntries = 1000
+
+type PublicEvent struct {
+	Date        string `json:"date"`
+	Stage       string `json:"stage"`
+	Description string `json:"description"`
+}
+
+type PublicProvenance struct {
+	BatchID        string        `json:"batchID"`
+	Variety        string        `json:"variety"`
+	FarmRegion     string        `json:"farmRegion,omitempty"`
+	HarvestDate    string        `json:"harvestDate"`
+	Mill           string        `json:"mill,omitempty"`
+	DispatchDate   string        `json:"dispatchDate,omitempty"`
+	Certifications []string      `json:"certifications"`
+	Recalled       bool          `json:"recalled"`
+	Serial         string        `json:"serial,omitempty"`
+	PackDate       string        `json:"packDate,omitempty"`
+	BestBefore     string        `json:"bestBefore,omitempty"`
+	Timeline       []PublicEvent `json:"timeline"`
+}
+
+// Map a batch status change to a consumer-facing timeline event
+func publicEvent(status string, date string) (PublicEvent, bool) {
+	switch {
+	case status == "Harvested":
+		return PublicEvent{Date: date, Stage: "Harvested", Description: "Paddy harvested at the farm"}, true
+	case strings.HasPrefix(status, "Assigned to Miller "):
+		mill := strings.TrimPrefix(status, "Assigned to Miller ")
+		return PublicEvent{Date: date, Stage: "Milled", Description: "Milled by " + mill}, true
+	case strings.HasPrefix(status, "Dispatched to "):
+		return PublicEvent{Date: date, Stage: "Dispatched", Description: "Dispatched to retail"}, true
+	case status == "Delivered":
+		return PublicEvent{Date: date, Stage: "Delivered", Description: "Received by the retailer"}, true
+	case strings.HasPrefix(status, "Recalled"):
+		return PublicEvent{Date: date, Stage: "Recalled", Description: "Withdrawn by the producer"}, true
+	}
+	return PublicEvent{}, false
+}
+
+// Build the sanitised provenance of a batch from its ledger history
+func buildPublicProvenance(batchID string) (*PublicProvenance, error) {
+	var batch ricetypes.RiceBatch
+	if err := evaluateJSON(serviceCaller(appConfig.Roles.Public), "ReadRiceBatch", &batch, batchID); err != nil {
+		return nil, err
+	}
+
+	var history []*ricetypes.HistoryQueryResult
+	if err := evaluateJSON(serviceCaller(appConfig.Roles.Public), "GetRiceBatchHistory", &history, batchID); err != nil {
+		return nil, err
+	}
+
+	provenance := &PublicProvenance{
+		BatchID:        batch.BatchID,
+		Variety:        batch.Variety,
+		HarvestDate:    batch.HarvestDate,
+		Certifications: []string{},
+		Timeline:       []PublicEvent{},
+	}
+
+	// Only the farm's region is public, never the field boundary
+	if batch.FieldID != "" {
+		var field ricetypes.Field
+		var farm ricetypes.Farm
+		if err := evaluateJSON(serviceCaller(appConfig.Roles.Public), "ReadField", &field, batch.FieldID); err != nil {
+			return nil, err
+		}
+		if err := evaluateJSON(serviceCaller(appConfig.Roles.Public), "ReadFarm", &farm, field.FarmID); err != nil {
+			return nil, err
+		}
+		provenance.FarmRegion = farm.Region
+	}
+
+	for _, certificationID := range batch.Certifications {
+		var certification ricetypes.Certification
+		if err := evaluateJSON(serviceCaller(appConfig.Roles.Public), "ReadCertification", &certification, certificationID); err != nil {
+			return nil, err
+		}
+		if certification.Status == "Active" {
+			provenance.Certifications = append(provenance.Certifications,
+				fmt.Sprintf("%s (%s)", certification.Scheme, certification.CertificateNumber))
+		}
+	}
+
+	// History comes newest first; walk it oldest first and keep status changes
+	lastStatus := ""
+	for i := len(history) - 1; i >= 0; i-- {
+		record := history[i]
+		if record.IsDelete || record.Record == nil || record.Record.Status == lastStatus {
+			continue
+		}
+		lastStatus = record.Record.Status
+
+		date := record.Timestamp
+		if parsed, err := time.Parse(time.RFC1123, record.Timestamp); err == nil {
+			date = parsed.Format("2006-01-02")
+		}
+		if record.Record.Status == "Harvested" {
+			date = batch.HarvestDate
+		}
+
+		event, ok := publicEvent(record.Record.Status, date)
+		if !ok {
+			continue
+		}
+		switch event.Stage {
+		case "Milled":
+			provenance.Mill = strings.TrimPrefix(event.Description, "Milled by ")
+		case "Dispatched":
+			provenance.DispatchDate = date
+		case "Recalled":
+			provenance.Recalled = true
+		}
+		provenance.Timeline = append(provenance.Timeline, event)
+	}
+	return provenance, nil
+}
+
+// Sanitised provenance of a retail unit, via its packaging run
+func buildPublicUnitProvenance(serial string) (*PublicProvenance, error) {
+	var unit ricetypes.RetailUnitProvenance
+	if err := evaluateJSON(serviceCaller(appConfig.Roles.Public), "VerifyRetailUnit", &unit, serial); err != nil {
+		return nil, err
+	}
+	if unit.Run == nil {
+		return nil, newAPIError(ErrCodeBadLedgerResponse, "retail unit %s has no packaging run", serial)
+	}
+
+	provenance, err := buildPublicProvenance(unit.Run.BatchID)
+	if err != nil {
+		return nil, err
+	}
+	provenance.Serial = unit.Serial
+	provenance.PackDate = unit.Run.PackDate
+	provenance.BestBefore = unit.Run.BestBefore
+	return provenance, nil
+}
+
+type cachedProvenance struct {
+	provenance *PublicProvenance
+	expires    time.Time
+}
+
+type publicProvenanceCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedProvenance
+}
+
+// Fetch provenance, serving repeated lookups from a short-lived cache
+func (c *publicProvenanceCache) get(kind string, id string) (provenance *PublicProvenance, err error) {
+	key := kind + "/" + id
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.provenance, nil
+	}
+
+	if kind == "unit" {
+		provenance, err = buildPublicUnitProvenance(id)
+	} else {
+		provenance, err = buildPublicProvenance(id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.evict(time.Now())
+	c.entries[key] = cachedProvenance{provenance: provenance, expires: time.Now().Add(publicCacheTTL)}
+	c.mu.Unlock()
+	return provenance, nil
+}
+
+// evict makes room for one more entry. Expired entries go first; when the
+// cache is still full the entry closest to expiry, which is also the oldest,
+// is dropped. Callers hold mu.
+func (c *publicProvenanceCache) evict(now time.Time) {
+	if len(c.entries) < publicCacheMaxEntries {
+		return
+	}
+	oldestKey := ""
+	var oldest time.Time
+	for key, entry := range c.entries {
+		if !now.Before(entry.expires) {
+			delete(c.entries, key)
+			continue
+		}
+		if oldestKey == "" || entry.expires.Before(oldest) {
+			oldestKey, oldest = key, entry.expires
+		}
+	}
+	if len(c.entries) >= publicCacheMaxEntries {
+		delete(c.entries, oldestKey)
+	}
+}
+
+// Fixed-window request limiter keyed by client IP
+type rateLimiter struct {
+	mu      sync.Mutex
+	limit   int
+	window  time.Duration
+	clients map[string]*rateWindow
+}
+
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+func newRateLimiter(limit int, window time.Duration) *rateLimiter {
+	return &rateLimiter{limit: limit, window: window, clients: make(map[string]*rateWindow)}
+}
+
+// allow reports whether the client may make another request and, if not, when to retry
+func (l *rateLimiter) allow(client string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Drop finished windows so the map does not grow without bound
+	if len(l.clients) > 10000 {
+		for key, w := range l.clients {
+			if now.Sub(w.start) >= l.window {
+				delete(l.clients, key)
+			}
+		}
+	}
+
+	w, ok := l.clients[client]
+	if !ok || now.Sub(w.start) >= l.window {
+		l.clients[client] = &rateWindow{start: now, count: 1}
+		return true, 0
+	}
+	if w.count >= l.limit {
+		return false, w.start.Add(l.window).Sub(now)
+	}
+	w.count++
+	return true, 0
+}
+
+func (l *rateLimiter) middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ok, retryAfter := l.allow(c.ClientIP(), time.Now())
+		if !ok {
+			c.Header("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"message": "Too many requests"})
+			return
+		}
+		c.Next()
+	}
+}
+
+func registerPublicRoutes(router *gin.Engine) {
+	cache := &publicProvenanceCache{entries: make(map[string]cachedProvenance)}
+	public := router.Group("/api/public", newRateLimiter(publicRequestsPerMinute, time.Minute).middleware())
+
+	// Read-only provenance for a batch or retail unit
+	public.GET("/provenance/:kind/:id", func(c *gin.Context) {
+		kind := c.Param("kind")
+		if kind != "batch" && kind != "unit" {
+			c.JSON(http.StatusNotFound, gin.H{"message": "Unknown provenance kind"})
+			return
+		}
+
+		provenance, err := cache.get(kind, c.Param("id"))
+		if err != nil {
+			// Ledger errors can mention internal details, so keep the public message generic
+			logger.Printf("public: provenance %s/%s failed: %v", kind, c.Param("id"), err)
+			c.JSON(http.StatusNotFound, gin.H{"message": "No provenance found"})
+			return
+		}
+		c.Header("Cache-Control", "public, max-age=60")
+		c.JSON(http.StatusOK, gin.H{"data": provenance})
+	})
+}
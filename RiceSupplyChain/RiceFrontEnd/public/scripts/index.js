@@ -1,3 +1,41 @@
+// ========== Sign in ==========
+const showSignedInUser = async () => {
+  const res = await fetch("/api/auth/me");
+  const label = document.getElementById("signedInAs");
+  if (!res.ok) {
+    label.textContent = "Not signed in";
+    return;
+  }
+  const result = await res.json();
+  label.textContent = `Signed in as ${result.data.username} (${result.data.mspID})`;
+};
+
+const login = async (event) => {
+  event.preventDefault();
+  const res = await fetch("/api/auth/login", {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify({
+      username: document.getElementById("loginUsername").value,
+      password: document.getElementById("loginPassword").value
+    })
+  });
+  const result = await res.json();
+  if (!res.ok) {
+    alert(result.error.message);
+    return;
+  }
+  document.getElementById("loginPassword").value = "";
+  showSignedInUser();
+};
+
+const logout = async () => {
+  await fetch("/api/auth/logout", { method: "POST" });
+  showSignedInUser();
+};
+
+document.addEventListener("DOMContentLoaded", showSignedInUser);
+
 // Add Rice Batch
 const addData = async (event) => {
     event.preventDefault();
@@ -7,16 +45,18 @@ const addData = async (event) => {
     const harvestDate = document.getElementById("harvestDate").value;
     const quantity = document.getElementById("quantity").value;
     const farmerName = document.getElementById("farmerName").value;
+    const fieldID = document.getElementById("fieldID").value;
 
     const riceData = {
         batchID,
         variety,
         harvestDate,
-        quantity,
-        farmerName
+        quantityInKg: Number(quantity),
+        farmerName,
+        fieldID
     };
 
-    if (!batchID || !variety || !harvestDate || !quantity || !farmerName) {
+    if (!batchID || !variety || !harvestDate || !quantity || !farmerName || !fieldID) {
         alert("Please enter all fields properly.");
     } else {
         try {
@@ -67,8 +107,9 @@ const createRiceBatch = async (event) => {
   const harvestDate = document.getElementById("harvestDate").value;
   const quantity = document.getElementById("quantity").value;
   const farmerName = document.getElementById("farmerName").value;
+  const fieldID = document.getElementById("fieldID").value;
 
-  if (!batchID || !variety || !harvestDate || !quantity || !farmerName) {
+  if (!batchID || !variety || !harvestDate || !quantity || !farmerName || !fieldID) {
     alert("All fields are required.");
     return;
   }
@@ -77,8 +118,14 @@ const createRiceBatch = async (event) => {
     batchID,
     variety,
     harvestDate,
-    quantity,
-    farmerName
+    quantityInKg: Number(quantity),
+    farmerName,
+    fieldID,
+    fieldEmissionsMethod: document.getElementById("fieldEmissionsMethod").value,
+    cultivatedAreaCentiHa: Math.round(Number(document.getElementById("cultivatedAreaHa").value) * 100),
+    waterUsageM3: Number(document.getElementById("waterUsageM3").value),
+    fertiliserNKg: Number(document.getElementById("fertiliserNKg").value),
+    fieldEmissionsKgCO2e: Number(document.getElementById("fieldEmissionsKgCO2e").value)
   };
 
   const res = await fetch("/api/rice", {
@@ -95,7 +142,7 @@ const createRiceBatch = async (event) => {
 const getAllRiceBatches = async () => {
   const res = await fetch("/api/rice/all");
   const result = await res.json();
-  alert("Rice Batches:\n" + result.data);
+  alert("Rice Batches:\n" + JSON.stringify(result.data, null, 2));
 };
 
 const getRiceByRange = async () => {
@@ -104,14 +151,14 @@ const getRiceByRange = async () => {
 
   const res = await fetch(`/api/rice/range?start=${start}&end=${end}`);
   const result = await res.json();
-  alert("Rice Batches by Range:\n" + result.data);
+  alert("Rice Batches by Range:\n" + JSON.stringify(result.data, null, 2));
 };
 
 const getRiceHistory = async () => {
   const batchID = document.getElementById("historyBatchID").value;
   const res = await fetch(`/api/rice/history/${batchID}`);
   const result = await res.json();
-  alert("Batch History:\n" + result.data);
+  alert("Batch History:\n" + JSON.stringify(result.data, null, 2));
 };
 
 // ========== ORG2: Create Processing Order ==========
@@ -120,6 +167,9 @@ const createOrder = async () => {
   const variety = document.getElementById("orderVariety").value;
   const quantity = document.getElementById("orderQuantity").value;
   const miller = document.getElementById("millerName").value;
+  const unitPrice = document.getElementById("orderUnitPrice").value;
+  const currency = document.getElementById("orderCurrency").value;
+  const taxRateBps = document.getElementById("orderTaxRate").value;
 
   if (!orderID || !variety || !quantity || !miller) {
     alert("All fields are required.");
@@ -129,8 +179,11 @@ const createOrder = async () => {
   const payload = {
     orderID,
     variety,
-    quantityInKg: quantity,
-    millerName: miller
+    quantityInKg: Number(quantity),
+    millerName: miller,
+    unitPrice: Number(unitPrice),
+    currency,
+    taxRateBps: Number(taxRateBps)
   };
 
   const res = await fetch("/api/orders", {
@@ -165,7 +218,7 @@ const matchOrder = async () => {
   });
 
   const result = await res.json();
-  alert("Match Result: " + result.result);
+  alert("Match Result: " + result.message);
 };
 
 // ========== ORG3: Dispatch Rice to Retailer ==========
@@ -0,0 +1,52 @@
+// ========== Consumer provenance page ==========
+const escapeHTML = (text) =>
+  String(text).replace(/[&<>"']/g, (ch) => ({
+    "&": "&amp;", "<": "&lt;", ">": "&gt;", '"': "&quot;", "'": "&#39;"
+  })[ch]);
+
+const renderField = (label, value) =>
+  `<p><strong>${label}:</strong> ${value ? escapeHTML(value) : "-"}</p>`;
+
+const loadProvenance = async () => {
+  const container = document.getElementById("provenance");
+  const kind = container.dataset.kind;
+  const id = container.dataset.id;
+
+  try {
+    const res = await fetch(`/api/public/provenance/${kind}/${encodeURIComponent(id)}`);
+    const result = await res.json();
+    if (!res.ok) {
+      container.innerHTML = `<p>${escapeHTML(result.message || "Not found")}</p>`;
+      return;
+    }
+
+    const data = result.data;
+    let html = "";
+    if (data.recalled) {
+      html += "<p><strong>This product has been recalled by the producer.</strong></p>";
+    }
+    if (data.serial) {
+      html += renderField("Serial", data.serial);
+      html += renderField("Packed", data.packDate);
+      html += renderField("Best before", data.bestBefore);
+    }
+    html += renderField("Variety", data.variety);
+    html += renderField("Farm region", data.farmRegion);
+    html += renderField("Harvest date", data.harvestDate);
+    html += renderField("Mill", data.mill);
+    html += renderField("Dispatch date", data.dispatchDate);
+    html += renderField("Certifications", data.certifications.join(", "));
+
+    html += "<h2>Journey</h2><ul>";
+    for (const event of data.timeline) {
+      html += `<li>${escapeHTML(event.date)} - <strong>${escapeHTML(event.stage)}</strong>: ${escapeHTML(event.description)}</li>`;
+    }
+    html += "</ul>";
+    container.innerHTML = html;
+  } catch (err) {
+    container.innerHTML = "<p>Provenance could not be loaded.</p>";
+    console.log(err);
+  }
+};
+
+loadProvenance();
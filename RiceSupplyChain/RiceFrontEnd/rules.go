@@ -0,0 +1,412 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"ricetypes"
+)
+
+// Alert rules are read from storage.rulesFile when present, otherwise the
+// defaults below apply. Two kinds of rule are supported:
+//
+//	threshold - a telemetry metric stays beyond a limit for at least Duration
+//	age       - a batch is older than MaxAgeDays and still has Status
+//
+// Each rule raises at most one alert per batch. Its ledger flag and webhook
+// call are retried on later evaluations until they succeed.
+
+type Rule struct {
+	ID           string  `json:"id"`
+	Kind         string  `json:"kind"`
+	Description  string  `json:"description"`
+	Severity     string  `json:"severity"`
+	Metric       string  `json:"metric,omitempty"`   // moisturePct, temperatureC or humidityPct
+	Operator     string  `json:"operator,omitempty"` // >, >=, < or <=
+	Threshold    float64 `json:"threshold,omitempty"`
+	Duration     string  `json:"duration,omitempty"` // Go duration, e.g. 6h
+	MaxAgeDays   int     `json:"maxAgeDays,omitempty"`
+	Status       string  `json:"status,omitempty"`
+	FlagOnLedger bool    `json:"flagOnLedger"`
+}
+
+type RulesConfig struct {
+	WebhookURL    string `json:"webhookURL"`
+	EvaluateEvery string `json:"evaluateEvery"`
+	Rules         []Rule `json:"rules"`
+}
+
+type Alert struct {
+	AlertID         string `json:"alertID"`
+	RuleID          string `json:"ruleID"`
+	BatchID         string `json:"batchID"`
+	Severity        string `json:"severity"`
+	Message         string `json:"message"`
+	RaisedAt        string `json:"raisedAt"`
+	FlaggedOnLedger bool   `json:"flaggedOnLedger"`
+	Notified        bool   `json:"notified"`
+}
+
+var defaultRulesConfig = RulesConfig{
+	EvaluateEvery: "10m",
+	Rules: []Rule{
+		{
+			ID:           "moisture-high",
+			Kind:         "threshold",
+			Description:  "Moisture above 14% for 6 hours",
+			Severity:     "critical",
+			Metric:       "moisturePct",
+			Operator:     ">",
+			Threshold:    14,
+			Duration:     "6h",
+			FlagOnLedger: true,
+		},
+		{
+			ID:          "unmilled-90-days",
+			Kind:        "age",
+			Description: "Harvested more than 90 days ago and not milled",
+			Severity:    "warning",
+			MaxAgeDays:  90,
+			Status:      "Harvested",
+		},
+	},
+}
+
+// Load the rules file, falling back to the defaults when it does not exist
+func loadRulesConfig(path string) (RulesConfig, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return defaultRulesConfig, nil
+	}
+	if err != nil {
+		return RulesConfig{}, err
+	}
+
+	var config RulesConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return RulesConfig{}, fmt.Errorf("invalid rules file %s: %w", path, err)
+	}
+	for _, rule := range config.Rules {
+		if err := validateRule(rule); err != nil {
+			return RulesConfig{}, fmt.Errorf("rule %q: %w", rule.ID, err)
+		}
+	}
+	return config, nil
+}
+
+func validateRule(rule Rule) error {
+	if rule.ID == "" {
+		return fmt.Errorf("id is required")
+	}
+	switch rule.Kind {
+	case "threshold":
+		if _, ok := readingMetric(Reading{}, rule.Metric); !ok {
+			return fmt.Errorf("unknown metric %q", rule.Metric)
+		}
+		if _, ok := compare(0, rule.Operator, 0); !ok {
+			return fmt.Errorf("unknown operator %q", rule.Operator)
+		}
+		if rule.Duration != "" {
+			if _, err := time.ParseDuration(rule.Duration); err != nil {
+				return err
+			}
+		}
+	case "age":
+		if rule.MaxAgeDays <= 0 {
+			return fmt.Errorf("maxAgeDays must be positive")
+		}
+	default:
+		return fmt.Errorf("unknown kind %q", rule.Kind)
+	}
+	return nil
+}
+
+func readingMetric(reading Reading, metric string) (float64, bool) {
+	switch metric {
+	case "moisturePct":
+		return reading.MoisturePct, true
+	case "temperatureC":
+		return reading.TemperatureC, true
+	case "humidityPct":
+		return reading.HumidityPct, true
+	}
+	return 0, false
+}
+
+func compare(value float64, operator string, threshold float64) (bool, bool) {
+	switch operator {
+	case ">":
+		return value > threshold, true
+	case ">=":
+		return value >= threshold, true
+	case "<":
+		return value < threshold, true
+	case "<=":
+		return value <= threshold, true
+	}
+	return false, false
+}
+
+// Check whether readings breach a threshold rule continuously for its duration
+func breachesThreshold(rule Rule, readings []Reading) (bool, string) {
+	var duration time.Duration
+	if rule.Duration != "" {
+		duration, _ = time.ParseDuration(rule.Duration)
+	}
+
+	sorted := append([]Reading(nil), readings...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Timestamp < sorted[j].Timestamp })
+
+	var start time.Time
+	inBreach := false
+	for _, reading := range sorted {
+		at, err := time.Parse(time.RFC3339, reading.Timestamp)
+		if err != nil {
+			continue
+		}
+		value, _ := readingMetric(reading, rule.Metric)
+		breached, _ := compare(value, rule.Operator, rule.Threshold)
+		if !breached {
+			inBreach = false
+			continue
+		}
+		if !inBreach {
+			inBreach = true
+			start = at
+		}
+		if at.Sub(start) >= duration {
+			return true, fmt.Sprintf("%s %s %v from %s to %s", rule.Metric, rule.Operator, rule.Threshold,
+				start.Format(time.RFC3339), at.Format(time.RFC3339))
+		}
+	}
+	return false, ""
+}
+
+// Check whether a batch has sat in a status for longer than the rule allows
+func breachesAge(rule Rule, batch *ricetypes.RiceBatch, now time.Time) (bool, string) {
+	if rule.Status != "" && batch.Status != rule.Status {
+		return false, ""
+	}
+	harvested, err := time.Parse("2006-01-02", batch.HarvestDate)
+	if err != nil {
+		return false, ""
+	}
+	age := int(now.Sub(harvested).Hours() / 24)
+	if age <= rule.MaxAgeDays {
+		return false, ""
+	}
+	return true, fmt.Sprintf("harvested %s, %d days ago, status %q", batch.HarvestDate, age, batch.Status)
+}
+
+type RuleEngine struct {
+	mu         sync.Mutex
+	config     RulesConfig
+	telemetry  *TelemetryStore
+	alertsPath string
+	alerts     map[string]*Alert
+}
+
+func newRuleEngine(config RulesConfig, telemetry *TelemetryStore, alertsPath string) (*RuleEngine, error) {
+	engine := &RuleEngine{config: config, telemetry: telemetry, alertsPath: alertsPath, alerts: make(map[string]*Alert)}
+	data, err := os.ReadFile(alertsPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if len(data) > 0 {
+		var alerts []*Alert
+		if err := json.Unmarshal(data, &alerts); err != nil {
+			return nil, fmt.Errorf("invalid alerts file %s: %w", alertsPath, err)
+		}
+		for _, alert := range alerts {
+			engine.alerts[alert.AlertID] = alert
+		}
+	}
+	return engine, nil
+}
+
+// Alerts returns recorded alerts, newest first
+func (e *RuleEngine) Alerts() []*Alert {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	alerts := make([]*Alert, 0, len(e.alerts))
+	for _, alert := range e.alerts {
+		alerts = append(alerts, alert)
+	}
+	sort.Slice(alerts, func(i, j int) bool { return alerts[i].RaisedAt > alerts[j].RaisedAt })
+	return alerts
+}
+
+// Evaluate runs every rule and returns the alerts raised by this run
+func (e *RuleEngine) Evaluate() ([]*Alert, error) {
+	batches, err := fetchBatches()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+
+	var raised []*Alert
+	for _, rule := range e.config.Rules {
+		for _, batch := range batches {
+			var breached bool
+			var detail string
+			switch rule.Kind {
+			case "threshold":
+				readings, err := e.telemetry.Readings(batch.BatchID)
+				if err != nil {
+					return raised, err
+				}
+				breached, detail = breachesThreshold(rule, readings)
+			case "age":
+				breached, detail = breachesAge(rule, batch, now)
+			}
+			if !breached {
+				continue
+			}
+			if alert := e.raise(rule, batch.BatchID, detail, now); alert != nil {
+				raised = append(raised, alert)
+			}
+		}
+	}
+
+	// New alerts and earlier ones whose delivery failed
+	for _, alert := range e.Alerts() {
+		e.dispatch(alert)
+	}
+	return raised, e.save()
+}
+
+// raise records an alert unless the rule already fired for this batch
+func (e *RuleEngine) raise(rule Rule, batchID string, detail string, now time.Time) *Alert {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	alertID := rule.ID + ":" + batchID
+	if _, exists := e.alerts[alertID]; exists {
+		return nil
+	}
+	alert := &Alert{
+		AlertID:  alertID,
+		RuleID:   rule.ID,
+		BatchID:  batchID,
+		Severity: rule.Severity,
+		Message:  fmt.Sprintf("%s: %s", rule.Description, detail),
+		RaisedAt: now.Format(time.RFC3339),
+	}
+	e.alerts[alertID] = alert
+	return alert
+}
+
+// dispatch flags the batch on-ledger and calls the webhook as configured.
+// Each is marked done only once it succeeds, so a failed delivery is retried
+// on the next evaluation.
+func (e *RuleEngine) dispatch(alert *Alert) {
+	var rule Rule
+	for _, r := range e.config.Rules {
+		if r.ID == alert.RuleID {
+			rule = r
+		}
+	}
+
+	e.mu.Lock()
+	flag := rule.FlagOnLedger && !alert.FlaggedOnLedger && appConfig.Roles.Monitor != ""
+	notify := e.config.WebhookURL != "" && !alert.Notified
+	e.mu.Unlock()
+
+	if flag {
+		_, err := submitTransaction(monitorCaller(), nil, "FlagBatch", alert.BatchID, alert.RuleID, alert.Message)
+		if err != nil {
+			logger.Printf("rules: FlagBatch for %s failed: %v", alert.BatchID, err)
+		} else {
+			e.mu.Lock()
+			alert.FlaggedOnLedger = true
+			e.mu.Unlock()
+		}
+	}
+
+	if notify {
+		if err := postWebhook(e.config.WebhookURL, alert); err != nil {
+			logger.Printf("rules: webhook for %s failed: %v", alert.AlertID, err)
+		} else {
+			e.mu.Lock()
+			alert.Notified = true
+			e.mu.Unlock()
+		}
+	}
+}
+
+func (e *RuleEngine) save() error {
+	alerts := e.Alerts()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(e.alertsPath), 0o755); err != nil {
+		return err
+	}
+	data, _ := json.MarshalIndent(alerts, "", "  ")
+	return os.WriteFile(e.alertsPath, data, 0o644)
+}
+
+// evaluatePeriodically runs Evaluate on the configured interval
+func (e *RuleEngine) evaluatePeriodically() {
+	interval, err := time.ParseDuration(e.config.EvaluateEvery)
+	if err != nil || interval <= 0 {
+		interval = 10 * time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if _, err := e.Evaluate(); err != nil {
+			logger.Println("rules: evaluation failed:", err)
+		}
+	}
+}
+
+func postWebhook(url string, alert *Alert) error {
+	body, _ := json.Marshal(alert)
+	client := http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// Read all batches from the ledger for rule evaluation
+func fetchBatches() (batches []*ricetypes.RiceBatch, err error) {
+	if err := evaluateJSON(serviceCaller(appConfig.Roles.Farmer), "GetAllRiceBatches", &batches); err != nil {
+		return nil, fmt.Errorf("failed to query batches: %w", err)
+	}
+	return batches, nil
+}
+
+func registerRuleRoutes(router *gin.Engine, engine *RuleEngine) {
+	router.GET("/api/rules", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"data": engine.config.Rules})
+	})
+
+	router.GET("/api/alerts", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"data": engine.Alerts()})
+	})
+
+	// Run the rules now instead of waiting for the next tick
+	router.POST("/api/alerts/evaluate", func(c *gin.Context) {
+		raised, err := engine.Evaluate()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Rule evaluation failed", "error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Raised %d new alerts", len(raised)), "data": raised})
+	})
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+	"github.com/youmark/pkcs8"
+)
+
+// A Signer holds the private key of a file or HSM identity. Each org picks
+// its backend with signer.type: PEM keys in a keystore directory, PKCS#8 keys
+// encrypted with a passphrase, or a PKCS#11 token (built with -tags pkcs11).
+// The key is the one matching the identity's certificate, not whichever file
+// happens to come first in the keystore.
+
+const (
+	SignerPEM          = "pem"
+	SignerEncryptedPEM = "encrypted-pem"
+	SignerPKCS11       = "pkcs11"
+)
+
+type Signer interface {
+	// Sign returns an ASN.1 DER ECDSA signature of a digest, normalised to low-S
+	Sign(digest []byte) ([]byte, error)
+	// Close releases the key, e.g. the HSM session it was found in
+	Close() error
+}
+
+func newSigner(config SignerConfig, certificate *x509.Certificate, keyPath string) (Signer, error) {
+	switch config.Type {
+	case SignerPEM, "":
+		return newPEMSigner(certificate, keyPath, nil)
+	case SignerEncryptedPEM:
+		return newPEMSigner(certificate, keyPath, []byte(config.Passphrase))
+	case SignerPKCS11:
+		return newPKCS11Signer(config, certificate)
+	}
+	return nil, fmt.Errorf("unknown signer type %q", config.Type)
+}
+
+type pemSigner struct {
+	sign identity.Sign
+}
+
+func (s *pemSigner) Sign(digest []byte) ([]byte, error) {
+	return s.sign(digest)
+}
+
+func (s *pemSigner) Close() error {
+	return nil
+}
+
+// Find the key for certificate in a keystore directory. With a passphrase
+// only encrypted PKCS#8 keys are read, without one only plain keys.
+func newPEMSigner(certificate *x509.Certificate, keyPath string, passphrase []byte) (Signer, error) {
+	entries, err := os.ReadDir(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key directory: %w", err)
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join(keyPath, name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read private key file: %w", err)
+		}
+		key, err := parseKeystoreKey(data, passphrase)
+		if err != nil {
+			return nil, fmt.Errorf("private key %s: %w", name, err)
+		}
+		if key == nil || !keyMatchesCertificate(key, certificate) {
+			continue
+		}
+		sign, err := identity.NewPrivateKeySign(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create signer: %w", err)
+		}
+		return &pemSigner{sign: sign}, nil
+	}
+
+	kind := "plain"
+	if passphrase != nil {
+		kind = "encrypted"
+	}
+	return nil, fmt.Errorf("no %s private key in %s matches the certificate", kind, keyPath)
+}
+
+// Parse one keystore file, returning nil for files in the other format or that are not keys
+func parseKeystoreKey(data []byte, passphrase []byte) (crypto.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, nil
+	}
+	switch {
+	case block.Type == "ENCRYPTED PRIVATE KEY" && passphrase != nil:
+		key, err := pkcs8.ParsePKCS8PrivateKey(block.Bytes, passphrase)
+		if err != nil {
+			return nil, fmt.Errorf("cannot be decrypted, check the signer passphrase: %w", err)
+		}
+		return key, nil
+	case block.Type == "PRIVATE KEY" && passphrase == nil:
+		return x509.ParsePKCS8PrivateKey(block.Bytes)
+	case block.Type == "EC PRIVATE KEY" && passphrase == nil:
+		return x509.ParseECPrivateKey(block.Bytes)
+	}
+	return nil, nil
+}
+
+func keyMatchesCertificate(key crypto.PrivateKey, certificate *x509.Certificate) bool {
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return false
+	}
+	public, ok := signer.Public().(interface{ Equal(crypto.PublicKey) bool })
+	return ok && public.Equal(certificate.PublicKey)
+}
@@ -0,0 +1,13 @@
+//go:build !pkcs11
+
+package main
+
+import (
+	"crypto/x509"
+	"fmt"
+)
+
+// PKCS#11 needs cgo, so it is only compiled in with -tags pkcs11
+func newPKCS11Signer(config SignerConfig, certificate *x509.Certificate) (Signer, error) {
+	return nil, fmt.Errorf("signer type %s needs a build with -tags pkcs11", SignerPKCS11)
+}
@@ -0,0 +1,172 @@
+//go:build pkcs11
+
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/miekg/pkcs11"
+)
+
+// PKCS#11 keys are looked up by CKA_LABEL when signer.keyLabel is set,
+// otherwise by CKA_ID equal to the certificate's subject key identifier,
+// which is how Fabric's own PKCS#11 provider stores them. Every identity
+// keeps its own logged-in session; the module is loaded once per process.
+
+var pkcs11Modules = struct {
+	sync.Mutex
+	byLibrary map[string]*pkcs11.Ctx
+}{byLibrary: make(map[string]*pkcs11.Ctx)}
+
+type pkcs11Signer struct {
+	mu      sync.Mutex
+	ctx     *pkcs11.Ctx
+	session pkcs11.SessionHandle
+	key     pkcs11.ObjectHandle
+	curve   elliptic.Curve
+}
+
+func loadPKCS11Module(library string) (*pkcs11.Ctx, error) {
+	pkcs11Modules.Lock()
+	defer pkcs11Modules.Unlock()
+	if ctx, ok := pkcs11Modules.byLibrary[library]; ok {
+		return ctx, nil
+	}
+	ctx := pkcs11.New(library)
+	if ctx == nil {
+		return nil, fmt.Errorf("failed to load PKCS#11 library %s", library)
+	}
+	if err := ctx.Initialize(); err != nil && !isPKCS11Error(err, pkcs11.CKR_CRYPTOKI_ALREADY_INITIALIZED) {
+		ctx.Destroy()
+		return nil, fmt.Errorf("failed to initialise PKCS#11 library %s: %w", library, err)
+	}
+	pkcs11Modules.byLibrary[library] = ctx
+	return ctx, nil
+}
+
+func isPKCS11Error(err error, code uint) bool {
+	var p11Err pkcs11.Error
+	return errors.As(err, &p11Err) && uint(p11Err) == code
+}
+
+func newPKCS11Signer(config SignerConfig, certificate *x509.Certificate) (Signer, error) {
+	public, ok := certificate.PublicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("PKCS#11 signing needs an ECDSA certificate")
+	}
+	ctx, err := loadPKCS11Module(config.Library)
+	if err != nil {
+		return nil, err
+	}
+	slot, err := findPKCS11Slot(ctx, config.TokenLabel)
+	if err != nil {
+		return nil, err
+	}
+	session, err := ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open PKCS#11 session: %w", err)
+	}
+	// Login state is shared by all sessions on a token, so a second identity finds it logged in
+	if err := ctx.Login(session, pkcs11.CKU_USER, config.PIN); err != nil && !isPKCS11Error(err, pkcs11.CKR_USER_ALREADY_LOGGED_IN) {
+		ctx.CloseSession(session)
+		return nil, fmt.Errorf("PKCS#11 login to token %s failed: %w", config.TokenLabel, err)
+	}
+
+	var key pkcs11.ObjectHandle
+	if config.KeyLabel != "" {
+		key, err = findPKCS11Key(ctx, session, pkcs11.NewAttribute(pkcs11.CKA_LABEL, config.KeyLabel))
+	} else {
+		key, err = findPKCS11Key(ctx, session, pkcs11.NewAttribute(pkcs11.CKA_ID, subjectKeyID(certificate, public)))
+	}
+	if err != nil {
+		ctx.CloseSession(session)
+		return nil, fmt.Errorf("token %s: %w", config.TokenLabel, err)
+	}
+	return &pkcs11Signer{ctx: ctx, session: session, key: key, curve: public.Curve}, nil
+}
+
+func findPKCS11Slot(ctx *pkcs11.Ctx, tokenLabel string) (uint, error) {
+	slots, err := ctx.GetSlotList(true)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list PKCS#11 slots: %w", err)
+	}
+	for _, slot := range slots {
+		info, err := ctx.GetTokenInfo(slot)
+		if err == nil && strings.TrimSpace(info.Label) == tokenLabel {
+			return slot, nil
+		}
+	}
+	return 0, fmt.Errorf("no PKCS#11 token labelled %s", tokenLabel)
+}
+
+func findPKCS11Key(ctx *pkcs11.Ctx, session pkcs11.SessionHandle, match *pkcs11.Attribute) (pkcs11.ObjectHandle, error) {
+	template := []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_PRIVATE_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_EC),
+		match,
+	}
+	if err := ctx.FindObjectsInit(session, template); err != nil {
+		return 0, err
+	}
+	objects, _, err := ctx.FindObjects(session, 2)
+	ctx.FindObjectsFinal(session)
+	if err != nil {
+		return 0, err
+	}
+	switch len(objects) {
+	case 0:
+		return 0, fmt.Errorf("no EC private key matches the certificate")
+	case 1:
+		return objects[0], nil
+	}
+	return 0, fmt.Errorf("more than one EC private key matches, set signer.keyLabel")
+}
+
+// The certificate's SKI, or the SHA-256 of the public point Fabric uses when there is none
+func subjectKeyID(certificate *x509.Certificate, public *ecdsa.PublicKey) []byte {
+	if len(certificate.SubjectKeyId) > 0 {
+		return certificate.SubjectKeyId
+	}
+	point := elliptic.Marshal(public.Curve, public.X, public.Y)
+	ski := sha256.Sum256(point)
+	return ski[:]
+}
+
+func (s *pkcs11Signer) Sign(digest []byte) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.ctx.SignInit(s.session, []*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_ECDSA, nil)}, s.key); err != nil {
+		return nil, fmt.Errorf("PKCS#11 sign failed: %w", err)
+	}
+	raw, err := s.ctx.Sign(s.session, digest)
+	if err != nil {
+		return nil, fmt.Errorf("PKCS#11 sign failed: %w", err)
+	}
+	if len(raw)%2 != 0 {
+		return nil, fmt.Errorf("PKCS#11 returned a %d byte ECDSA signature", len(raw))
+	}
+
+	// Tokens return r || s; Fabric wants DER with s in the lower half of the order
+	r := new(big.Int).SetBytes(raw[:len(raw)/2])
+	sv := new(big.Int).SetBytes(raw[len(raw)/2:])
+	order := s.curve.Params().N
+	if sv.Cmp(new(big.Int).Rsh(order, 1)) > 0 {
+		sv.Sub(order, sv)
+	}
+	return asn1.Marshal(struct{ R, S *big.Int }{r, sv})
+}
+
+func (s *pkcs11Signer) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ctx.CloseSession(s.session)
+}
@@ -0,0 +1,75 @@
+//go:build pkcs11
+
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/asn1"
+	"fmt"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+)
+
+// Runs against a real token, e.g. SoftHSM:
+//
+//	RICE_TEST_PKCS11_LIBRARY=/usr/lib/softhsm/libsofthsm2.so
+//	RICE_TEST_PKCS11_TOKEN_LABEL=rice RICE_TEST_PKCS11_PIN=98765432
+//	RICE_TEST_PKCS11_CERT=path/to/cert.pem [RICE_TEST_PKCS11_KEY_LABEL=...]
+//	go test -tags pkcs11 -run PKCS11 .
+//
+// The certificate's private key must be on the token.
+func TestPKCS11SignerSignsLowS(t *testing.T) {
+	library := os.Getenv("RICE_TEST_PKCS11_LIBRARY")
+	certPath := os.Getenv("RICE_TEST_PKCS11_CERT")
+	if library == "" || certPath == "" {
+		t.Skip("RICE_TEST_PKCS11_LIBRARY and RICE_TEST_PKCS11_CERT are not set")
+	}
+	certPEM, err := os.ReadFile(certPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate, err := identity.CertificateFromPEM(certPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	public, ok := certificate.PublicKey.(*ecdsa.PublicKey)
+	if !ok {
+		t.Fatal("certificate does not hold an ECDSA key")
+	}
+
+	signer, err := newSigner(SignerConfig{
+		Type:       SignerPKCS11,
+		Library:    library,
+		TokenLabel: os.Getenv("RICE_TEST_PKCS11_TOKEN_LABEL"),
+		PIN:        os.Getenv("RICE_TEST_PKCS11_PIN"),
+		KeyLabel:   os.Getenv("RICE_TEST_PKCS11_KEY_LABEL"),
+	}, certificate, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer signer.Close()
+
+	halfOrder := new(big.Int).Rsh(public.Curve.Params().N, 1)
+	// About half of raw ECDSA signatures are high-S, so enough rounds exercise the normalisation
+	for i := 0; i < 32; i++ {
+		digest := sha256.Sum256([]byte(fmt.Sprintf("rice-%d", i)))
+		signature, err := signer.Sign(digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ecdsa.VerifyASN1(public, digest[:], signature) {
+			t.Fatalf("signature %d does not verify against the certificate's key", i)
+		}
+		var rs struct{ R, S *big.Int }
+		if _, err := asn1.Unmarshal(signature, &rs); err != nil {
+			t.Fatal(err)
+		}
+		if rs.S.Cmp(halfOrder) > 0 {
+			t.Fatalf("signature %d has high S", i)
+		}
+	}
+}
@@ -0,0 +1,404 @@
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"ricetypes"
+)
+
+// Sensor readings are kept off-chain in a per-batch append-only file. Every
+// telemetryAnchorInterval the readings received since the last anchor are
+// hashed into a Merkle tree and its root is written to the ledger with
+// AnchorTelemetry, so any single reading can later be proven untampered.
+
+const telemetryAnchorInterval = 5 * time.Minute
+
+var safeIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+type Reading struct {
+	Seq          int     `json:"seq"`
+	BatchID      string  `json:"batchID"`
+	SensorID     string  `json:"sensorID"`
+	Source       string  `json:"source"` // warehouse or truck
+	Timestamp    string  `json:"timestamp"`
+	TemperatureC float64 `json:"temperatureC"`
+	HumidityPct  float64 `json:"humidityPct"`
+	MoisturePct  float64 `json:"moisturePct"`
+}
+
+type TelemetryAnchor struct {
+	AnchorID     string `json:"anchorID"`
+	BatchID      string `json:"batchID"`
+	MerkleRoot   string `json:"merkleRoot"`
+	FromSeq      int    `json:"fromSeq"`
+	ToSeq        int    `json:"toSeq"` // exclusive
+	FirstReading string `json:"firstReading"`
+	LastReading  string `json:"lastReading"`
+}
+
+type TelemetryStore struct {
+	mu  sync.Mutex
+	dir string
+}
+
+func newTelemetryStore(dir string) *TelemetryStore {
+	return &TelemetryStore{dir: dir}
+}
+
+// Append readings to their batch files and assign sequence numbers
+func (s *TelemetryStore) Append(readings []Reading) ([]Reading, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var stored []Reading
+	counts := make(map[string]int)
+	for _, reading := range readings {
+		if _, ok := counts[reading.BatchID]; !ok {
+			existing, err := s.readings(reading.BatchID)
+			if err != nil {
+				return stored, err
+			}
+			counts[reading.BatchID] = len(existing)
+		}
+		reading.Seq = counts[reading.BatchID]
+		counts[reading.BatchID]++
+
+		if err := os.MkdirAll(filepath.Join(s.dir, reading.BatchID), 0o755); err != nil {
+			return stored, err
+		}
+		file, err := os.OpenFile(s.readingsPath(reading.BatchID), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+		if err != nil {
+			return stored, err
+		}
+		line, _ := json.Marshal(reading)
+		_, err = file.Write(append(line, '\n'))
+		file.Close()
+		if err != nil {
+			return stored, err
+		}
+		stored = append(stored, reading)
+	}
+	return stored, nil
+}
+
+// Readings returns every stored reading for a batch in sequence order
+func (s *TelemetryStore) Readings(batchID string) ([]Reading, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.readings(batchID)
+}
+
+// Anchors returns the anchors already written to the ledger for a batch
+func (s *TelemetryStore) Anchors(batchID string) ([]TelemetryAnchor, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.anchors(batchID)
+}
+
+func (s *TelemetryStore) readingsPath(batchID string) string {
+	return filepath.Join(s.dir, batchID, "readings.jsonl")
+}
+
+func (s *TelemetryStore) anchorsPath(batchID string) string {
+	return filepath.Join(s.dir, batchID, "anchors.json")
+}
+
+func (s *TelemetryStore) readings(batchID string) ([]Reading, error) {
+	file, err := os.Open(s.readingsPath(batchID))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var readings []Reading
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var reading Reading
+		if err := json.Unmarshal(scanner.Bytes(), &reading); err != nil {
+			return nil, fmt.Errorf("corrupt telemetry file for batch %s: %w", batchID, err)
+		}
+		readings = append(readings, reading)
+	}
+	return readings, scanner.Err()
+}
+
+func (s *TelemetryStore) anchors(batchID string) ([]TelemetryAnchor, error) {
+	data, err := os.ReadFile(s.anchorsPath(batchID))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var anchors []TelemetryAnchor
+	err = json.Unmarshal(data, &anchors)
+	return anchors, err
+}
+
+func (s *TelemetryStore) saveAnchor(anchor TelemetryAnchor) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	anchors, err := s.anchors(anchor.BatchID)
+	if err != nil {
+		return err
+	}
+	anchors = append(anchors, anchor)
+	data, _ := json.MarshalIndent(anchors, "", "  ")
+	return os.WriteFile(s.anchorsPath(anchor.BatchID), data, 0o644)
+}
+
+// Batch IDs that have readings on disk
+func (s *TelemetryStore) batches() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var batchIDs []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			batchIDs = append(batchIDs, entry.Name())
+		}
+	}
+	return batchIDs, nil
+}
+
+// Hash readings into Merkle leaves
+func readingLeaves(readings []Reading) [][]byte {
+	leaves := make([][]byte, len(readings))
+	for i, reading := range readings {
+		data, _ := json.Marshal(reading)
+		leaves[i] = leafHash(data)
+	}
+	return leaves
+}
+
+// anchorPending writes a Merkle root for every batch with unanchored readings
+func (s *TelemetryStore) anchorPending() {
+	batchIDs, err := s.batches()
+	if err != nil {
+		logger.Println("telemetry: cannot list batches:", err)
+		return
+	}
+	for _, batchID := range batchIDs {
+		if err := s.anchorBatch(batchID); err != nil {
+			logger.Printf("telemetry: anchoring batch %s failed: %v", batchID, err)
+		}
+	}
+}
+
+func (s *TelemetryStore) anchorBatch(batchID string) error {
+	readings, err := s.Readings(batchID)
+	if err != nil {
+		return err
+	}
+	anchors, err := s.Anchors(batchID)
+	if err != nil {
+		return err
+	}
+
+	from := 0
+	if len(anchors) > 0 {
+		from = anchors[len(anchors)-1].ToSeq
+	}
+	if from >= len(readings) {
+		return nil
+	}
+	window := readings[from:]
+
+	anchor := TelemetryAnchor{
+		AnchorID:     fmt.Sprintf("TEL-%s-%d", batchID, len(anchors)+1),
+		BatchID:      batchID,
+		MerkleRoot:   hex.EncodeToString(merkleRoot(readingLeaves(window))),
+		FromSeq:      from,
+		ToSeq:        len(readings),
+		FirstReading: window[0].Timestamp,
+		LastReading:  window[len(window)-1].Timestamp,
+	}
+
+	caller := serviceCaller(appConfig.Roles.Farmer)
+	_, err = submitTransaction(caller, nil, "AnchorTelemetry",
+		anchor.AnchorID, batchID, anchor.MerkleRoot, strconv.Itoa(len(window)), anchor.FirstReading, anchor.LastReading)
+	if err != nil {
+		if toAPIError(err).Code == ErrCodeConflict {
+			// An earlier run committed this anchor but could not save it locally
+			return s.recoverAnchor(caller, anchor, readings)
+		}
+		return err
+	}
+
+	return s.saveAnchor(anchor)
+}
+
+// recoverAnchor saves the ledger's copy of an anchor that is missing locally.
+// Readings may have arrived since it was written, so the window is taken from
+// its reading count and checked against its root before it is saved.
+func (s *TelemetryStore) recoverAnchor(caller Caller, anchor TelemetryAnchor, readings []Reading) error {
+	var onLedger ricetypes.TelemetryAnchor
+	if err := evaluateJSON(caller, "ReadTelemetryAnchor", &onLedger, anchor.AnchorID); err != nil {
+		return err
+	}
+	to := anchor.FromSeq + onLedger.ReadingCount
+	if onLedger.BatchID != anchor.BatchID || onLedger.ReadingCount <= 0 || to > len(readings) {
+		return fmt.Errorf("anchor %s on the ledger does not cover the stored readings", anchor.AnchorID)
+	}
+	window := readings[anchor.FromSeq:to]
+	if hex.EncodeToString(merkleRoot(readingLeaves(window))) != onLedger.MerkleRoot {
+		return fmt.Errorf("anchor %s on the ledger does not match the stored readings", anchor.AnchorID)
+	}
+
+	anchor.MerkleRoot = onLedger.MerkleRoot
+	anchor.ToSeq = to
+	anchor.FirstReading = onLedger.FirstReading
+	anchor.LastReading = onLedger.LastReading
+	return s.saveAnchor(anchor)
+}
+
+// anchorPeriodically runs anchorPending on a fixed interval
+func (s *TelemetryStore) anchorPeriodically(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		s.anchorPending()
+	}
+}
+
+func validateReading(reading Reading) error {
+	if !safeIDPattern.MatchString(reading.BatchID) {
+		return fmt.Errorf("invalid batchID %q", reading.BatchID)
+	}
+	if reading.SensorID == "" {
+		return fmt.Errorf("sensorID is required")
+	}
+	if _, err := time.Parse(time.RFC3339, reading.Timestamp); err != nil {
+		return fmt.Errorf("timestamp must be RFC3339: %v", err)
+	}
+	return nil
+}
+
+func registerTelemetryRoutes(router *gin.Engine, store *TelemetryStore) {
+	// Ingest a batch of sensor readings
+	router.POST("/api/telemetry", func(c *gin.Context) {
+		var req struct {
+			Readings []Reading `json:"readings"`
+		}
+		if err := c.BindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Bad request", "error": err.Error()})
+			return
+		}
+		if len(req.Readings) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "No readings supplied"})
+			return
+		}
+		for i, reading := range req.Readings {
+			if err := validateReading(reading); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("Invalid reading %d", i), "error": err.Error()})
+				return
+			}
+		}
+
+		stored, err := store.Append(req.Readings)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to store readings", "error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Stored %d readings", len(stored)), "data": stored})
+	})
+
+	// Raw readings and anchors for a batch
+	router.GET("/api/telemetry/:batchID", func(c *gin.Context) {
+		batchID := c.Param("batchID")
+		if !safeIDPattern.MatchString(batchID) {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid batch ID"})
+			return
+		}
+		readings, err := store.Readings(batchID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to read telemetry", "error": err.Error()})
+			return
+		}
+		anchors, err := store.Anchors(batchID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to read anchors", "error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": readings, "anchors": anchors})
+	})
+
+	// Prove a single reading is included in a root anchored on the ledger
+	router.GET("/api/telemetry/:batchID/verify/:seq", func(c *gin.Context) {
+		batchID := c.Param("batchID")
+		seq, err := strconv.Atoi(c.Param("seq"))
+		if !safeIDPattern.MatchString(batchID) || err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid batch ID or sequence number"})
+			return
+		}
+
+		readings, err := store.Readings(batchID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to read telemetry", "error": err.Error()})
+			return
+		}
+		if seq < 0 || seq >= len(readings) {
+			c.JSON(http.StatusNotFound, gin.H{"message": fmt.Sprintf("Reading %d not found for batch %s", seq, batchID)})
+			return
+		}
+		anchors, err := store.Anchors(batchID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to read anchors", "error": err.Error()})
+			return
+		}
+		var anchor *TelemetryAnchor
+		for i := range anchors {
+			if seq >= anchors[i].FromSeq && seq < anchors[i].ToSeq {
+				anchor = &anchors[i]
+			}
+		}
+		if anchor == nil {
+			c.JSON(http.StatusAccepted, gin.H{"message": "Reading has not been anchored yet", "verified": false})
+			return
+		}
+
+		leaves := readingLeaves(readings[anchor.FromSeq:anchor.ToSeq])
+		proof, err := merkleProof(leaves, seq-anchor.FromSeq)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to build proof", "error": err.Error()})
+			return
+		}
+
+		var onLedger ricetypes.TelemetryAnchor
+		if err := evaluateJSON(callerFrom(c), "ReadTelemetryAnchor", &onLedger, anchor.AnchorID); err != nil {
+			respondError(c, err)
+			return
+		}
+
+		root, _ := hex.DecodeString(onLedger.MerkleRoot)
+		verified := verifyMerkleProof(leaves[seq-anchor.FromSeq], proof, root)
+		c.JSON(http.StatusOK, gin.H{
+			"reading":    readings[seq],
+			"anchorID":   anchor.AnchorID,
+			"merkleRoot": onLedger.MerkleRoot,
+			"leafHash":   hex.EncodeToString(leaves[seq-anchor.FromSeq]),
+			"proof":      proof,
+			"verified":   verified,
+		})
+	})
+}
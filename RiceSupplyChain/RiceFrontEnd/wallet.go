@@ -0,0 +1,261 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// A wallet holds enrolled identities by label, one file per identity in the
+// same JSON layout as the Fabric Node SDK file system wallet. The encrypted
+// backend seals each file with AES-256-GCM under a key derived from a
+// passphrase with scrypt, so private keys are never stored in the clear.
+
+const walletFileExt = ".id"
+
+// Opened in main, shared by the gateway pool and the identity manager
+var wallet Wallet
+
+var walletLabelPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9@._-]*$`)
+
+type WalletIdentity struct {
+	Label        string `json:"-"`
+	Type         string `json:"type"` // Always X.509
+	Version      int    `json:"version"`
+	MSPID        string `json:"mspId"`
+	Org          string `json:"org"`
+	EnrollmentID string `json:"enrollmentID"`
+	Credentials  struct {
+		Certificate string `json:"certificate"`
+		PrivateKey  string `json:"privateKey"`
+	} `json:"credentials"`
+}
+
+type Wallet interface {
+	Put(identity *WalletIdentity) error
+	// Get returns nil when there is no identity with the label
+	Get(label string) (*WalletIdentity, error)
+	Remove(label string) error
+	List() ([]string, error)
+	ModTime(label string) (time.Time, error)
+}
+
+// Label an identity enrolled for an org
+func walletLabel(enrollmentID string, org string) string {
+	return enrollmentID + "@" + org
+}
+
+func newWallet(config WalletConfig) (Wallet, error) {
+	files := &fileWallet{dir: config.Path}
+	switch config.Type {
+	case "file":
+		return files, nil
+	case "encrypted":
+		return &encryptedWallet{files: files, passphrase: []byte(config.Passphrase)}, nil
+	}
+	return nil, fmt.Errorf("unknown wallet type %q", config.Type)
+}
+
+type fileWallet struct {
+	mu  sync.Mutex
+	dir string
+}
+
+func (w *fileWallet) path(label string) (string, error) {
+	if !walletLabelPattern.MatchString(label) {
+		return "", newAPIError(ErrCodeInvalidArgument, "invalid wallet label %q", label)
+	}
+	return filepath.Join(w.dir, label+walletFileExt), nil
+}
+
+func (w *fileWallet) Put(identity *WalletIdentity) error {
+	data, _ := json.MarshalIndent(identity, "", "  ")
+	return w.write(identity.Label, data)
+}
+
+func (w *fileWallet) Get(label string) (*WalletIdentity, error) {
+	data, err := w.read(label)
+	if data == nil || err != nil {
+		return nil, err
+	}
+	return decodeWalletIdentity(label, data)
+}
+
+func decodeWalletIdentity(label string, data []byte) (*WalletIdentity, error) {
+	var identity WalletIdentity
+	if err := json.Unmarshal(data, &identity); err != nil {
+		return nil, fmt.Errorf("invalid wallet entry %s: %w", label, err)
+	}
+	identity.Label = label
+	return &identity, nil
+}
+
+// Write through a temporary file so a crash never leaves half an identity
+func (w *fileWallet) write(label string, data []byte) error {
+	path, err := w.path(label)
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := os.MkdirAll(w.dir, 0o700); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// read returns nil data when the label is not in the wallet
+func (w *fileWallet) read(label string) ([]byte, error) {
+	path, err := w.path(label)
+	if err != nil {
+		return nil, err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return data, err
+}
+
+func (w *fileWallet) Remove(label string) error {
+	path, err := w.path(label)
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (w *fileWallet) List() ([]string, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	entries, err := os.ReadDir(w.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var labels []string
+	for _, entry := range entries {
+		if name := entry.Name(); strings.HasSuffix(name, walletFileExt) {
+			labels = append(labels, strings.TrimSuffix(name, walletFileExt))
+		}
+	}
+	sort.Strings(labels)
+	return labels, nil
+}
+
+func (w *fileWallet) ModTime(label string) (time.Time, error) {
+	path, err := w.path(label)
+	if err != nil {
+		return time.Time{}, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
+
+type encryptedWallet struct {
+	files      *fileWallet
+	passphrase []byte
+}
+
+// Sealed file layout; every file has its own salt and nonce
+type sealedIdentity struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+func (w *encryptedWallet) aead(salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key(w.passphrase, salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (w *encryptedWallet) Put(identity *WalletIdentity) error {
+	plaintext, _ := json.Marshal(identity)
+	sealed := sealedIdentity{Version: 1, KDF: "scrypt", Salt: make([]byte, 16)}
+	if _, err := rand.Read(sealed.Salt); err != nil {
+		return err
+	}
+	aead, err := w.aead(sealed.Salt)
+	if err != nil {
+		return err
+	}
+	sealed.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(sealed.Nonce); err != nil {
+		return err
+	}
+	// The label is authenticated too, so a sealed file cannot be renamed to another identity
+	sealed.Ciphertext = aead.Seal(nil, sealed.Nonce, plaintext, []byte(identity.Label))
+	data, _ := json.MarshalIndent(sealed, "", "  ")
+	return w.files.write(identity.Label, data)
+}
+
+func (w *encryptedWallet) Get(label string) (*WalletIdentity, error) {
+	data, err := w.files.read(label)
+	if data == nil || err != nil {
+		return nil, err
+	}
+	var sealed sealedIdentity
+	if err := json.Unmarshal(data, &sealed); err != nil {
+		return nil, fmt.Errorf("invalid wallet entry %s: %w", label, err)
+	}
+	if sealed.Version != 1 || sealed.KDF != "scrypt" {
+		return nil, fmt.Errorf("wallet entry %s is not an encrypted identity", label)
+	}
+	aead, err := w.aead(sealed.Salt)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := aead.Open(nil, sealed.Nonce, sealed.Ciphertext, []byte(label))
+	if err != nil {
+		return nil, fmt.Errorf("wallet entry %s cannot be decrypted, check the wallet passphrase", label)
+	}
+	return decodeWalletIdentity(label, plaintext)
+}
+
+func (w *encryptedWallet) Remove(label string) error {
+	return w.files.Remove(label)
+}
+
+func (w *encryptedWallet) List() ([]string, error) {
+	return w.files.List()
+}
+
+func (w *encryptedWallet) ModTime(label string) (time.Time, error) {
+	return w.files.ModTime(label)
+}
@@ -0,0 +1,45 @@
+package ricetypes
+
+// AgronomicEvent is one entry in a field's log for a crop season. Batches
+// carry the FieldID and CropSeason they were harvested in, which links them
+// to the events that produced them.
+type AgronomicEvent struct {
+	AssetType              string `json:"assetType"`
+	EventID                string `json:"eventID"`
+	FieldID                string `json:"fieldID"`
+	CropSeason             string `json:"cropSeason"`
+	EventType              string `json:"eventType"`
+	Date                   string `json:"date"`
+	SeedLot                string `json:"seedLot,omitempty" metadata:",optional"`
+	Variety                string `json:"variety,omitempty" metadata:",optional"`
+	Product                string `json:"product,omitempty" metadata:",optional"`
+	ActiveIngredient       string `json:"activeIngredient,omitempty" metadata:",optional"`
+	Dose                   string `json:"dose,omitempty" metadata:",optional"`
+	DoseUnit               string `json:"doseUnit,omitempty" metadata:",optional"`
+	PreHarvestIntervalDays int    `json:"preHarvestIntervalDays,omitempty" metadata:",optional"`
+	IrrigationMm           int    `json:"irrigationMm,omitempty" metadata:",optional"`
+	RecordedBy             string `json:"recordedBy"`
+	RecordedAt             string `json:"recordedAt"`
+}
+
+// PreHarvestCheck reports whether every pesticide applied before a harvest respected its interval
+type PreHarvestCheck struct {
+	BatchID      string                  `json:"batchID"`
+	FieldID      string                  `json:"fieldID"`
+	CropSeason   string                  `json:"cropSeason"`
+	HarvestDate  string                  `json:"harvestDate"`
+	Compliant    bool                    `json:"compliant"`
+	Applications []*PreHarvestCheckEntry `json:"applications"`
+}
+
+// PreHarvestCheckEntry is the interval check of a single pesticide application
+type PreHarvestCheckEntry struct {
+	EventID                string `json:"eventID"`
+	Product                string `json:"product"`
+	ActiveIngredient       string `json:"activeIngredient"`
+	AppliedOn              string `json:"appliedOn"`
+	PreHarvestIntervalDays int    `json:"preHarvestIntervalDays"`
+	EarliestHarvest        string `json:"earliestHarvest"`
+	DaysBeforeHarvest      int    `json:"daysBeforeHarvest"`
+	Compliant              bool   `json:"compliant"`
+}
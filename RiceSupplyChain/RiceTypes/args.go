@@ -0,0 +1,170 @@
+package ricetypes
+
+import "strconv"
+
+// Argument structs for the transactions the frontend submits. Args gives the
+// positional arguments in the order the contract function declares them, and
+// Transient the private values it reads from the transient map under the
+// keys below. Numbers travel as decimal strings, as the contract parses them.
+
+// Transient data keys read by the contract
+const (
+	TransientVariety               = "variety"
+	TransientMillerName            = "millerName"
+	TransientQuantityInKg          = "quantityInKg"
+	TransientUnitPrice             = "unitPrice"
+	TransientCurrency              = "currency"
+	TransientTaxRateBps            = "taxRateBps"
+	TransientWaterUsageM3          = "waterUsageM3"
+	TransientFertiliserNKg         = "fertiliserNKg"
+	TransientCultivatedAreaCentiHa = "cultivatedAreaCentiHa"
+	TransientSeasonDays            = "seasonDays"
+	TransientFieldEmissionsMethod  = "fieldEmissionsMethod"
+	TransientFieldEmissionsKgCO2e  = "fieldEmissionsKgCO2e"
+)
+
+// TransactionArgs are the arguments of one contract transaction
+type TransactionArgs interface {
+	Function() string
+	Args() []string
+	Transient() map[string][]byte
+}
+
+var (
+	_ TransactionArgs = (*CreateRiceBatchArgs)(nil)
+	_ TransactionArgs = (*CreateProcessingOrderArgs)(nil)
+	_ TransactionArgs = (*MatchProcessingOrderArgs)(nil)
+	_ TransactionArgs = (*DispatchToRetailerArgs)(nil)
+)
+
+// CreateRiceBatchArgs creates a batch harvested from a registered field. The
+// farm inputs are optional and only sent when FieldEmissionsMethod is set.
+type CreateRiceBatchArgs struct {
+	BatchID      string `json:"batchID"`
+	Variety      string `json:"variety"`
+	HarvestDate  string `json:"harvestDate"`
+	QuantityInKg int    `json:"quantityInKg"`
+	FarmerName   string `json:"farmerName"`
+	FieldID      string `json:"fieldID"`
+
+	WaterUsageM3          int64  `json:"waterUsageM3,omitempty" metadata:",optional"`
+	FertiliserNKg         int64  `json:"fertiliserNKg,omitempty" metadata:",optional"`
+	CultivatedAreaCentiHa int64  `json:"cultivatedAreaCentiHa,omitempty" metadata:",optional"` // hundredths of a hectare
+	SeasonDays            int64  `json:"seasonDays,omitempty" metadata:",optional"`
+	FieldEmissionsMethod  string `json:"fieldEmissionsMethod,omitempty" metadata:",optional"`
+	FieldEmissionsKgCO2e  int64  `json:"fieldEmissionsKgCO2e,omitempty" metadata:",optional"`
+}
+
+func (a *CreateRiceBatchArgs) Function() string {
+	return "CreateRiceBatch"
+}
+
+func (a *CreateRiceBatchArgs) Args() []string {
+	return []string{a.BatchID, a.Variety, a.HarvestDate, strconv.Itoa(a.QuantityInKg), a.FarmerName, a.FieldID}
+}
+
+func (a *CreateRiceBatchArgs) Transient() map[string][]byte {
+	if a.FieldEmissionsMethod == "" {
+		return nil
+	}
+	transient := map[string][]byte{
+		TransientFieldEmissionsMethod: []byte(a.FieldEmissionsMethod),
+		TransientWaterUsageM3:         formatInt(a.WaterUsageM3),
+		TransientFertiliserNKg:        formatInt(a.FertiliserNKg),
+		TransientFieldEmissionsKgCO2e: formatInt(a.FieldEmissionsKgCO2e),
+	}
+	if a.CultivatedAreaCentiHa != 0 {
+		transient[TransientCultivatedAreaCentiHa] = formatInt(a.CultivatedAreaCentiHa)
+	}
+	if a.SeasonDays != 0 {
+		transient[TransientSeasonDays] = formatInt(a.SeasonDays)
+	}
+	return transient
+}
+
+// SaleTerms price a sale so the contract raises an invoice for it. A zero
+// UnitPrice leaves the sale unpriced.
+type SaleTerms struct {
+	UnitPrice  int64  `json:"unitPrice,omitempty" metadata:",optional"` // minor units per kg
+	Currency   string `json:"currency,omitempty" metadata:",optional"`
+	TaxRateBps int64  `json:"taxRateBps,omitempty" metadata:",optional"` // basis points, 1800 = 18%
+}
+
+func (t SaleTerms) addTransient(transient map[string][]byte) {
+	if t.UnitPrice == 0 {
+		return
+	}
+	transient[TransientUnitPrice] = formatInt(t.UnitPrice)
+	transient[TransientCurrency] = []byte(t.Currency)
+	transient[TransientTaxRateBps] = formatInt(t.TaxRateBps)
+}
+
+// CreateProcessingOrderArgs places a miller's order; everything but the ID is private
+type CreateProcessingOrderArgs struct {
+	OrderID      string `json:"orderID"`
+	Variety      string `json:"variety"`
+	MillerName   string `json:"millerName"`
+	QuantityInKg int    `json:"quantityInKg"`
+	SaleTerms
+}
+
+func (a *CreateProcessingOrderArgs) Function() string {
+	return "CreateProcessingOrder"
+}
+
+func (a *CreateProcessingOrderArgs) Args() []string {
+	return []string{a.OrderID}
+}
+
+func (a *CreateProcessingOrderArgs) Transient() map[string][]byte {
+	transient := map[string][]byte{
+		TransientVariety:      []byte(a.Variety),
+		TransientMillerName:   []byte(a.MillerName),
+		TransientQuantityInKg: []byte(strconv.Itoa(a.QuantityInKg)),
+	}
+	a.SaleTerms.addTransient(transient)
+	return transient
+}
+
+// MatchProcessingOrderArgs mills a batch against an order
+type MatchProcessingOrderArgs struct {
+	BatchID string `json:"batchID"`
+	OrderID string `json:"orderID"`
+}
+
+func (a *MatchProcessingOrderArgs) Function() string {
+	return "MatchProcessingOrder"
+}
+
+func (a *MatchProcessingOrderArgs) Args() []string {
+	return []string{a.BatchID, a.OrderID}
+}
+
+func (a *MatchProcessingOrderArgs) Transient() map[string][]byte {
+	return nil
+}
+
+// DispatchToRetailerArgs dispatches a milled batch, invoicing the retailer when priced
+type DispatchToRetailerArgs struct {
+	BatchID      string `json:"batchID"`
+	RetailerName string `json:"retailerName"`
+	SaleTerms
+}
+
+func (a *DispatchToRetailerArgs) Function() string {
+	return "DispatchToRetailer"
+}
+
+func (a *DispatchToRetailerArgs) Args() []string {
+	return []string{a.BatchID, a.RetailerName}
+}
+
+func (a *DispatchToRetailerArgs) Transient() map[string][]byte {
+	transient := map[string][]byte{}
+	a.SaleTerms.addTransient(transient)
+	return transient
+}
+
+func formatInt(i int64) []byte {
+	return []byte(strconv.FormatInt(i, 10))
+}
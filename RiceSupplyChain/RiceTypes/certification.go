@@ -0,0 +1,20 @@
+package ricetypes
+
+// Certification is an organic, fair-trade or similar certificate held by a
+// producer. Batches harvested by the holder inside the validity window carry
+// its ID in RiceBatch.Certifications.
+type Certification struct {
+	AssetType         string `json:"assetType"`
+	CertificationID   string `json:"certificationID"`
+	Scheme            string `json:"scheme"`
+	CertificateNumber string `json:"certificateNumber"`
+	Holder            string `json:"holder"`
+	Scope             string `json:"scope"`
+	ValidFrom         string `json:"validFrom"`
+	ValidUntil        string `json:"validUntil"`
+	DocumentHash      string `json:"documentHash"`
+	IssuedBy          string `json:"issuedBy"`
+	Status            string `json:"status"`
+	RevokedAt         string `json:"revokedAt,omitempty" metadata:",optional"`
+	RevocationReason  string `json:"revocationReason,omitempty" metadata:",optional"`
+}
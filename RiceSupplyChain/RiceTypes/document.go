@@ -0,0 +1,14 @@
+package ricetypes
+
+// DocumentAnchor ties an off-chain file (lab report, phytosanitary
+// certificate, invoice PDF) to an asset by its SHA-256 hash
+type DocumentAnchor struct {
+	AssetType  string `json:"assetType"`
+	DocumentID string `json:"documentID"`
+	AssetID    string `json:"assetID"`
+	DocType    string `json:"docType"`
+	SHA256     string `json:"sha256"`
+	URI        string `json:"uri"`
+	AttachedBy string `json:"attachedBy"`
+	AttachedAt string `json:"attachedAt"`
+}
@@ -0,0 +1,22 @@
+package ricetypes
+
+// Error codes the frontend returns to API clients in {"error": {"code": ...}}.
+// Contract errors a client can act on start with one of these codes and ": ",
+// which the frontend reads back.
+const (
+	ErrCodeInvalidArgument    = "INVALID_ARGUMENT"
+	ErrCodeUnauthenticated    = "UNAUTHENTICATED"
+	ErrCodeNotFound           = "NOT_FOUND"
+	ErrCodeForbidden          = "FORBIDDEN"
+	ErrCodeConflict           = "CONFLICT"
+	ErrCodeMVCCConflict       = "MVCC_CONFLICT"
+	ErrCodeEndorsementFailed  = "ENDORSEMENT_FAILED"
+	ErrCodeSubmitFailed       = "SUBMIT_FAILED"
+	ErrCodeCommitFailed       = "COMMIT_FAILED"
+	ErrCodeCommitStatusFailed = "COMMIT_STATUS_UNKNOWN"
+	ErrCodeUnavailable        = "GATEWAY_UNAVAILABLE"
+	ErrCodeTimeout            = "GATEWAY_TIMEOUT"
+	ErrCodeConfiguration      = "CONFIGURATION_ERROR"
+	ErrCodeBadLedgerResponse  = "BAD_LEDGER_RESPONSE"
+	ErrCodeInternal           = "INTERNAL"
+)
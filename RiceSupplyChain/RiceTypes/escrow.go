@@ -0,0 +1,17 @@
+package ricetypes
+
+// Escrow holds the miller's tokens from order matching until the retailer
+// accepts delivery (released to the farmer) or the batch is rejected or
+// recalled (refunded to the miller).
+type Escrow struct {
+	AssetType string `json:"assetType"`
+	EscrowID  string `json:"escrowID"`
+	BatchID   string `json:"batchID"`
+	InvoiceID string `json:"invoiceID"`
+	Payer     string `json:"payer"`
+	Payee     string `json:"payee"`
+	Amount    int64  `json:"amount"`
+	Status    string `json:"status"`
+	LockedAt  string `json:"lockedAt"`
+	SettledAt string `json:"settledAt,omitempty" metadata:",optional"`
+}
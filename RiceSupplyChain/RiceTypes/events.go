@@ -0,0 +1,23 @@
+package ricetypes
+
+// Chaincode event names. Each carries a BatchEvent as its JSON payload, so a
+// listener can follow a batch without reading it back from the ledger. A
+// transaction emits at most one event.
+const (
+	EventBatchCreated    = "BatchCreated"
+	EventBatchMilled     = "BatchMilled"
+	EventBatchDispatched = "BatchDispatched"
+	EventBatchDelivered  = "BatchDelivered"
+	EventBatchRejected   = "BatchRejected"
+	EventBatchRecalled   = "BatchRecalled"
+	EventBatchFlagged    = "BatchFlagged"
+)
+
+// BatchEvent is the payload of the batch lifecycle events
+type BatchEvent struct {
+	BatchID   string `json:"batchID"`
+	Status    string `json:"status"`
+	Reason    string `json:"reason,omitempty" metadata:",optional"` // Rejections, recalls and flags
+	ActorMSP  string `json:"actorMSP"`
+	Timestamp string `json:"timestamp"`
+}
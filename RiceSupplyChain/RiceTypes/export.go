@@ -0,0 +1,67 @@
+package ricetypes
+
+// ExportConsignment groups milled batches shipped abroad under one bill of
+// lading. Customs clearance moves through the steps in clearanceSequence,
+// each recorded by its own transaction.
+type ExportConsignment struct {
+	AssetType          string            `json:"assetType"`
+	ConsignmentID      string            `json:"consignmentID"`
+	Lots               []*ConsignmentLot `json:"lots"`
+	HSCode             string            `json:"hsCode"`
+	DestinationCountry string            `json:"destinationCountry"`
+	Incoterm           string            `json:"incoterm"`
+	Consignee          string            `json:"consignee"`
+	ContainerNumbers   []string          `json:"containerNumbers"`
+	BillOfLading       string            `json:"billOfLading,omitempty" metadata:",optional"`
+	Vessel             string            `json:"vessel,omitempty" metadata:",optional"`
+	ClearanceStatus    string            `json:"clearanceStatus"`
+	ClearanceSteps     []*ClearanceStep  `json:"clearanceSteps"`
+	Exporter           string            `json:"exporter"`
+	CreatedAt          string            `json:"createdAt"`
+}
+
+// ConsignmentLot is one batch loaded into an export consignment
+type ConsignmentLot struct {
+	BatchID      string `json:"batchID"`
+	Variety      string `json:"variety"`
+	HarvestDate  string `json:"harvestDate"`
+	QuantityInKg int    `json:"quantityInKg"`
+}
+
+// ClearanceStep records one customs milestone of a consignment
+type ClearanceStep struct {
+	Status     string `json:"status"`
+	Reference  string `json:"reference"`
+	Note       string `json:"note,omitempty" metadata:",optional"`
+	Timestamp  string `json:"timestamp"`
+	RecordedBy string `json:"recordedBy"`
+}
+
+// PackingList is the consolidated packing list of a consignment
+type PackingList struct {
+	ConsignmentID      string             `json:"consignmentID"`
+	Exporter           string             `json:"exporter"`
+	Consignee          string             `json:"consignee"`
+	DestinationCountry string             `json:"destinationCountry"`
+	Incoterm           string             `json:"incoterm"`
+	HSCode             string             `json:"hsCode"`
+	BillOfLading       string             `json:"billOfLading,omitempty" metadata:",optional"`
+	Vessel             string             `json:"vessel,omitempty" metadata:",optional"`
+	ContainerNumbers   []string           `json:"containerNumbers"`
+	Lines              []*PackingListLine `json:"lines"`
+	TotalPackages      int                `json:"totalPackages"`
+	TotalNetWeightInKg int                `json:"totalNetWeightInKg"`
+}
+
+// PackingListLine is a packaging run, or the unpacked remainder of a lot shipped in bulk
+type PackingListLine struct {
+	BatchID        string `json:"batchID"`
+	Variety        string `json:"variety"`
+	HarvestDate    string `json:"harvestDate"`
+	Description    string `json:"description"`
+	Packages       int    `json:"packages"`
+	PackSizeKg     int    `json:"packSizeKg,omitempty" metadata:",optional"`
+	NetWeightInKg  int    `json:"netWeightInKg"`
+	SerialRange    string `json:"serialRange,omitempty" metadata:",optional"`
+	PackagingRunID string `json:"packagingRunID,omitempty" metadata:",optional"`
+}
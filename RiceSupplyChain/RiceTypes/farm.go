@@ -0,0 +1,28 @@
+package ricetypes
+
+// Farm is a registered producer holding one or more fields
+type Farm struct {
+	AssetType    string `json:"assetType"`
+	FarmID       string `json:"farmID"`
+	Name         string `json:"name"`
+	Owner        string `json:"owner"`
+	Region       string `json:"region"`
+	RegisteredBy string `json:"registeredBy"`
+}
+
+// Field is a plot of a farm with its GeoJSON boundary and current crop season
+type Field struct {
+	AssetType   string          `json:"assetType"`
+	FieldID     string          `json:"fieldID"`
+	FarmID      string          `json:"farmID"`
+	Name        string          `json:"name"`
+	Boundary    *GeoJSONPolygon `json:"boundary"`
+	AreaCentiHa int64           `json:"areaCentiHa"` // Hundredths of a hectare, measured by the client
+	CropSeason  string          `json:"cropSeason"`
+}
+
+// GeoJSONPolygon is a GeoJSON Polygon geometry, outer ring first then holes
+type GeoJSONPolygon struct {
+	Type        string        `json:"type"`
+	Coordinates [][][]float64 `json:"coordinates"`
+}
@@ -0,0 +1,50 @@
+package ricetypes
+
+// FarmInputs are the field-level inputs recorded when a batch is created
+type FarmInputs struct {
+	WaterUsageM3          int64  `json:"waterUsageM3"`
+	FertiliserNKg         int64  `json:"fertiliserNKg"`
+	CultivatedAreaCentiHa int64  `json:"cultivatedAreaCentiHa"` // hundredths of a hectare
+	SeasonDays            int64  `json:"seasonDays"`
+	FieldEmissionsMethod  string `json:"fieldEmissionsMethod"`
+}
+
+// FootprintEntry is one emission or water use recorded against an asset
+type FootprintEntry struct {
+	Stage       string `json:"stage"`
+	Description string `json:"description"`
+	CO2eGrams   int64  `json:"co2eGrams"`
+	WaterLitres int64  `json:"waterLitres"`
+	RecordedBy  string `json:"recordedBy"`
+	RecordedAt  string `json:"recordedAt"`
+}
+
+// FootprintRecord holds the entries recorded directly against an asset
+type FootprintRecord struct {
+	AssetType  string            `json:"assetType"`
+	AssetID    string            `json:"assetID"`
+	FarmInputs *FarmInputs       `json:"farmInputs,omitempty" metadata:",optional"`
+	Entries    []*FootprintEntry `json:"entries"`
+}
+
+// Footprint is the total carbon and water footprint of an asset
+type Footprint struct {
+	AssetID        string                 `json:"assetID"`
+	Kind           string                 `json:"kind"`
+	MassInKg       int                    `json:"massInKg"`
+	CO2eGrams      int64                  `json:"co2eGrams"`
+	CO2eGramsPerKg int64                  `json:"co2eGramsPerKg"`
+	WaterLitres    int64                  `json:"waterLitres"`
+	ByStage        map[string]int64       `json:"byStage"`
+	Entries        []*FootprintEntry      `json:"entries"`
+	Allocations    []*FootprintAllocation `json:"allocations"`
+}
+
+// FootprintAllocation is the share of a source asset's footprint carried by another asset
+type FootprintAllocation struct {
+	SourceID       string `json:"sourceID"`
+	MassInKg       int    `json:"massInKg"`
+	SourceMassInKg int    `json:"sourceMassInKg"`
+	CO2eGrams      int64  `json:"co2eGrams"`
+	WaterLitres    int64  `json:"waterLitres"`
+}
@@ -0,0 +1,3 @@
+module ricetypes
+
+go 1.24.4
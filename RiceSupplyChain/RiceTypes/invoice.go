@@ -0,0 +1,47 @@
+package ricetypes
+
+// Invoice is raised when a batch changes hands for a price. It is kept in the
+// private collection shared by payer and payee; InvoiceStatus is its public side.
+// All amounts are integer minor units (paise, cents) of Currency.
+type Invoice struct {
+	AssetType    string     `json:"assetType"`
+	InvoiceID    string     `json:"invoiceID"`
+	BatchID      string     `json:"batchID"`
+	OrderID      string     `json:"orderID,omitempty" metadata:",optional"`
+	Payer        string     `json:"payer"`
+	Payee        string     `json:"payee"`
+	QuantityInKg int        `json:"quantityInKg"`
+	UnitPrice    int64      `json:"unitPrice"`
+	Subtotal     int64      `json:"subtotal"`
+	TaxRateBps   int64      `json:"taxRateBps"`
+	TaxAmount    int64      `json:"taxAmount"`
+	Total        int64      `json:"total"`
+	Currency     string     `json:"currency"`
+	AmountPaid   int64      `json:"amountPaid"`
+	Status       string     `json:"status"`
+	IssuedAt     string     `json:"issuedAt"`
+	Payments     []*Payment `json:"payments"`
+}
+
+// InvoiceStatus is the public record of an invoice: who owes whom and whether it
+// is settled, without quantities or prices
+type InvoiceStatus struct {
+	AssetType  string `json:"assetType"`
+	InvoiceID  string `json:"invoiceID"`
+	BatchID    string `json:"batchID"`
+	OrderID    string `json:"orderID,omitempty" metadata:",optional"`
+	Payer      string `json:"payer"`
+	Payee      string `json:"payee"`
+	Collection string `json:"collection"`
+	Status     string `json:"status"`
+	IssuedAt   string `json:"issuedAt"`
+}
+
+// Payment is a settlement recorded by the payer and confirmed by the payee
+type Payment struct {
+	PaymentRef  string `json:"paymentRef"`
+	Amount      int64  `json:"amount"`
+	RecordedAt  string `json:"recordedAt"`
+	Confirmed   bool   `json:"confirmed"`
+	ConfirmedAt string `json:"confirmedAt,omitempty" metadata:",optional"`
+}
@@ -0,0 +1,28 @@
+package ricetypes
+
+// A packaging run turns part of a milled batch into UnitCount retail bags.
+// Serials are "<runID>-<unit number>" so a serial resolves to its run with a
+// single read and no key is written per bag.
+type PackagingRun struct {
+	AssetType   string `json:"assetType"`
+	RunID       string `json:"runID"`
+	BatchID     string `json:"batchID"`
+	PackSizeKg  int    `json:"packSizeKg"`
+	UnitCount   int    `json:"unitCount"`
+	FirstSerial string `json:"firstSerial"`
+	LastSerial  string `json:"lastSerial"`
+	PackDate    string `json:"packDate"`
+	BestBefore  string `json:"bestBefore"`
+	PackedBy    string `json:"packedBy"`
+}
+
+// RetailUnitProvenance is everything known about a single retail bag
+type RetailUnitProvenance struct {
+	Serial    string                `json:"serial"`
+	Expired   bool                  `json:"expired"`
+	Run       *PackagingRun         `json:"run"`
+	Batch     *RiceBatch            `json:"batch"`
+	History   []*HistoryQueryResult `json:"history"`
+	Storage   []*StorageRecord      `json:"storage"`
+	Shipments []*Shipment           `json:"shipments"`
+}
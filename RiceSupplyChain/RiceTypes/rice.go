@@ -0,0 +1,50 @@
+// Package ricetypes holds the ledger types of the rice contract, shared by
+// the chaincode that writes them and the frontend that reads them back.
+// Fields that may be left out are tagged metadata:",optional" as well as
+// omitempty, or the contract API rejects them as missing when returned.
+package ricetypes
+
+type RiceBatch struct {
+	AssetType      string       `json:"assetType"`
+	BatchID        string       `json:"batchID"`
+	Variety        string       `json:"variety"`
+	HarvestDate    string       `json:"harvestDate"`
+	QuantityInKg   int          `json:"quantityInKg"`
+	ProducedBy     string       `json:"producedBy"`
+	FarmerName     string       `json:"farmerName,omitempty" metadata:",optional"` // set at creation, unlike ProducedBy
+	FieldID        string       `json:"fieldID,omitempty" metadata:",optional"`
+	CropSeason     string       `json:"cropSeason,omitempty" metadata:",optional"`
+	Status         string       `json:"status"`
+	ShippedInKg    int          `json:"shippedInKg,omitempty" metadata:",optional"`
+	DeliveredInKg  int          `json:"deliveredInKg,omitempty" metadata:",optional"`
+	PackedInKg     int          `json:"packedInKg,omitempty" metadata:",optional"`
+	Flags          []*BatchFlag `json:"flags,omitempty" metadata:",optional"`
+	Certifications []string     `json:"certifications,omitempty" metadata:",optional"`
+}
+
+// BatchFlag marks a batch that breached a storage or lifecycle rule
+type BatchFlag struct {
+	RuleID    string `json:"ruleID"`
+	Reason    string `json:"reason"`
+	FlaggedAt string `json:"flaggedAt"`
+	FlaggedBy string `json:"flaggedBy"`
+}
+
+type ProcessingOrder struct {
+	AssetType    string `json:"assetType"`
+	OrderID      string `json:"orderID"`
+	Variety      string `json:"variety"`
+	MillerName   string `json:"millerName"`
+	QuantityInKg int    `json:"quantityInKg"`
+	UnitPrice    int64  `json:"unitPrice"` // minor units per kg
+	Currency     string `json:"currency"`
+	TaxRateBps   int64  `json:"taxRateBps"` // basis points, 1800 = 18%
+}
+
+// HistoryQueryResult is one entry of a batch's history; Record is nil for deletes
+type HistoryQueryResult struct {
+	Record    *RiceBatch `json:"record"`
+	TxId      string     `json:"txId"`
+	Timestamp string     `json:"timestamp"`
+	IsDelete  bool       `json:"isDelete"`
+}
@@ -0,0 +1,44 @@
+package ricetypes
+
+// Shipment tracks a dispatched batch while it is in transit to the retailer
+type Shipment struct {
+	AssetType              string        `json:"assetType"`
+	ShipmentID             string        `json:"shipmentID"`
+	BatchID                string        `json:"batchID"`
+	Carrier                string        `json:"carrier"`
+	VehicleID              string        `json:"vehicleID"`
+	Origin                 string        `json:"origin"`
+	Destination            string        `json:"destination"`
+	DepartureTime          string        `json:"departureTime"`
+	ExpectedArrival        string        `json:"expectedArrival"`
+	ActualArrival          string        `json:"actualArrival,omitempty" metadata:",optional"`
+	SealNumbers            []string      `json:"sealNumbers"`
+	DispatchedQuantityInKg int           `json:"dispatchedQuantityInKg"`
+	DeliveredQuantityInKg  int           `json:"deliveredQuantityInKg"`
+	Checkpoints            []*Checkpoint `json:"checkpoints"`
+	Status                 string        `json:"status"`
+	DiscrepancyID          string        `json:"discrepancyID,omitempty" metadata:",optional"`
+	StartedBy              string        `json:"startedBy"`
+}
+
+// Checkpoint is a location report made while a shipment is in transit
+type Checkpoint struct {
+	Location   string `json:"location"`
+	Note       string `json:"note"`
+	Timestamp  string `json:"timestamp"`
+	RecordedBy string `json:"recordedBy"`
+}
+
+// Discrepancy records a mismatch between dispatched and delivered quantity
+type Discrepancy struct {
+	AssetType              string `json:"assetType"`
+	DiscrepancyID          string `json:"discrepancyID"`
+	ShipmentID             string `json:"shipmentID"`
+	BatchID                string `json:"batchID"`
+	DispatchedQuantityInKg int    `json:"dispatchedQuantityInKg"`
+	DeliveredQuantityInKg  int    `json:"deliveredQuantityInKg"`
+	DifferenceInKg         int    `json:"differenceInKg"`
+	SealsIntact            bool   `json:"sealsIntact"`
+	RecordedAt             string `json:"recordedAt"`
+	RecordedBy             string `json:"recordedBy"`
+}
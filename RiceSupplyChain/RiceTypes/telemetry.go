@@ -0,0 +1,15 @@
+package ricetypes
+
+// TelemetryAnchor commits to a set of off-chain sensor readings for a batch.
+// Raw readings stay with the frontend; only their Merkle root is on-ledger.
+type TelemetryAnchor struct {
+	AssetType    string `json:"assetType"`
+	AnchorID     string `json:"anchorID"`
+	BatchID      string `json:"batchID"`
+	MerkleRoot   string `json:"merkleRoot"`
+	ReadingCount int    `json:"readingCount"`
+	FirstReading string `json:"firstReading"`
+	LastReading  string `json:"lastReading"`
+	AnchoredAt   string `json:"anchoredAt"`
+	AnchoredBy   string `json:"anchoredBy"`
+}
@@ -0,0 +1,11 @@
+package ricetypes
+
+// TokenInfo describes the settlement token and the org allowed to mint it
+type TokenInfo struct {
+	AssetType   string `json:"assetType"`
+	Name        string `json:"name"`
+	Symbol      string `json:"symbol"`
+	Decimals    int    `json:"decimals"`
+	MinterMSP   string `json:"minterMSP"`
+	TotalSupply int64  `json:"totalSupply"`
+}
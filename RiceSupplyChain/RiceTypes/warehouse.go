@@ -0,0 +1,36 @@
+package ricetypes
+
+// Warehouse is a storage site operated by one org
+type Warehouse struct {
+	AssetType    string `json:"assetType"`
+	WarehouseID  string `json:"warehouseID"`
+	Name         string `json:"name"`
+	Location     string `json:"location"`
+	CapacityInKg int    `json:"capacityInKg"`
+	Operator     string `json:"operator"`
+}
+
+// StorageRecord tracks one stay of a batch in a warehouse bin or silo.
+// The difference between weight in and weight out is storage shrinkage.
+type StorageRecord struct {
+	AssetType     string `json:"assetType"`
+	StorageID     string `json:"storageID"`
+	WarehouseID   string `json:"warehouseID"`
+	BatchID       string `json:"batchID"`
+	Variety       string `json:"variety"`
+	Bin           string `json:"bin"`
+	EntryDate     string `json:"entryDate"`
+	ExitDate      string `json:"exitDate,omitempty" metadata:",optional"`
+	WeightInKg    int    `json:"weightInKg"`
+	WeightOutKg   int    `json:"weightOutKg"`
+	ShrinkageInKg int    `json:"shrinkageInKg"`
+	Status        string `json:"status"`
+}
+
+// WarehouseInventory is the current stock of a warehouse
+type WarehouseInventory struct {
+	WarehouseID string           `json:"warehouseID"`
+	TotalInKg   int              `json:"totalInKg"`
+	ByVariety   map[string]int   `json:"byVariety"`
+	Records     []*StorageRecord `json:"records"`
+}